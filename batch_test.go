@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArticlesBatch(t *testing.T) {
+	server := newTestServer(t,
+		testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."},
+		testPage{ID: 2, Title: "Chemistry", Text: "Chemistry is a physical science."},
+		testPage{ID: 3, Title: "Biology", Text: "Biology is a natural science."},
+	)
+
+	tooMany := make([]int64, 501)
+	for i := range tooMany {
+		tooMany[i] = int64(i + 1)
+	}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		titles []string
+	}{
+		{"empty ids", `{"ids": []}`, http.StatusOK, []string{}},
+		{"all hits", `{"ids": [3, 1]}`, http.StatusOK, []string{"Biology", "Physics"}},
+		{"partial hits", `{"ids": [2, 42, 1, 7]}`, http.StatusOK, []string{"Chemistry", "Physics"}},
+		{"over the limit", mustJSON(t, map[string][]int64{"ids": tooMany}), http.StatusBadRequest, nil},
+		{"invalid body", `{"ids": "1,2"}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/api/articles/batch", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.titles == nil {
+				return
+			}
+			var result struct {
+				Articles []struct {
+					Title string `json:"title"`
+				} `json:"articles"`
+				Count int `json:"count"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			titles := []string{}
+			for _, article := range result.Articles {
+				titles = append(titles, article.Title)
+			}
+			if !slices.Equal(titles, tt.titles) || result.Count != len(tt.titles) {
+				t.Errorf("got %d articles %q, want %q", result.Count, titles, tt.titles)
+			}
+		})
+	}
+}
+
+// mustJSON returns the JSON encoding of v
+func mustJSON(tb testing.TB, v interface{}) string {
+	tb.Helper()
+
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(v); err != nil {
+		tb.Fatal(err)
+	}
+	return b.String()
+}
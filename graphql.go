@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/fabriceboyer/wikipedia_sqlite/wikipedia"
+	"github.com/graphql-go/graphql"
+)
+
+// graphqlRequest is the body of POST /api/graphql
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphqlLoadersKey struct{}
+
+// graphqlLoaders batches the lookups made by the resolvers of one request.
+// Resolvers register their key and return a thunk; graphql-go runs thunks
+// breadth-first, so the first thunk of a level fetches the keys registered by
+// all of that level's resolvers with a single query.
+type graphqlLoaders struct {
+	wiki *wikipedia.Wiki
+	mu   sync.Mutex
+
+	pendingArticles []int64
+	articles        map[int64]*wikipedia.Article
+
+	// pendingBacklinks holds the largest limit requested for each title
+	pendingBacklinks map[string]int
+	backlinks        map[string][]*wikipedia.Article
+}
+
+func newGraphQLLoaders(wiki *wikipedia.Wiki) *graphqlLoaders {
+	return &graphqlLoaders{
+		wiki:             wiki,
+		articles:         make(map[int64]*wikipedia.Article),
+		pendingBacklinks: make(map[string]int),
+		backlinks:        make(map[string][]*wikipedia.Article),
+	}
+}
+
+func loadersFromContext(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+}
+
+// article returns a thunk resolving to the article with the given ID, or nil
+// when it is not in the database
+func (l *graphqlLoaders) article(id int64) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.articles[id]; !ok {
+		l.pendingArticles = append(l.pendingArticles, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for len(l.pendingArticles) > 0 {
+			batch := l.pendingArticles
+			if len(batch) > wikipedia.MaxBatchIDs {
+				batch = batch[:wikipedia.MaxBatchIDs]
+			}
+			l.pendingArticles = l.pendingArticles[len(batch):]
+
+			articles, err := l.wiki.GetArticlesByIDs(batch)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range batch {
+				l.articles[id] = nil
+			}
+			for _, article := range articles {
+				l.articles[article.ID] = article
+			}
+		}
+
+		if article := l.articles[id]; article != nil {
+			return article, nil
+		}
+		return nil, nil
+	}
+}
+
+// backlinksOf returns a thunk resolving to up to limit articles linking to
+// title
+func (l *graphqlLoaders) backlinksOf(title string, limit int) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.backlinks[title]; !ok && l.pendingBacklinks[title] < limit {
+		l.pendingBacklinks[title] = limit
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pendingBacklinks) > 0 {
+			titles := make([]string, 0, len(l.pendingBacklinks))
+			maxLimit := 0
+			for pending, pendingLimit := range l.pendingBacklinks {
+				titles = append(titles, pending)
+				if pendingLimit > maxLimit {
+					maxLimit = pendingLimit
+				}
+			}
+			l.pendingBacklinks = make(map[string]int)
+
+			for len(titles) > 0 {
+				batch := titles
+				if len(batch) > wikipedia.MaxBatchIDs {
+					batch = batch[:wikipedia.MaxBatchIDs]
+				}
+				titles = titles[len(batch):]
+
+				backlinks, err := l.wiki.GetBacklinksBatch(batch, maxLimit)
+				if err != nil {
+					return nil, err
+				}
+				for batchTitle, articles := range backlinks {
+					l.backlinks[batchTitle] = articles
+				}
+			}
+		}
+
+		backlinks := l.backlinks[title]
+		if len(backlinks) > limit {
+			backlinks = backlinks[:limit]
+		}
+		return backlinks, nil
+	}
+}
+
+// newGraphQLSchema defines the Article, Section, ArticleMeta and SearchResult
+// types and the article, search and random queries of /api/graphql
+func newGraphQLSchema() (graphql.Schema, error) {
+	sectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Section",
+		Fields: graphql.Fields{
+			"level":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"title":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"byteOffset": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	articleMetaType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "ArticleMeta",
+		Description: "An article without its content",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"namespace": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"redirect":  &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	articleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Article",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"namespace": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"content":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"redirect":  &graphql.Field{Type: graphql.String},
+			"wordCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"sections": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(sectionType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return wikipedia.ParseSections(p.Source.(*wikipedia.Article).Content), nil
+				},
+			},
+			"backlinks": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(articleMetaType))),
+				Description: "Articles linking to this one, batched across the articles of a query",
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 50},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, _ := p.Args["limit"].(int)
+					if limit <= 0 {
+						limit = 50
+					}
+					return loadersFromContext(p.Context).backlinksOf(p.Source.(*wikipedia.Article).Title, limit), nil
+				},
+			},
+		},
+	})
+
+	searchResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SearchResult",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"score": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"article": &graphql.Field{
+				Type:        articleType,
+				Description: "The matching article, loaded with a single query for all results",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFromContext(p.Context).article(p.Source.(wikipedia.SearchResult).ID), nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"article": &graphql.Field{
+				Type:        articleType,
+				Description: "An article by ID or by title; title lookups follow redirects",
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.ID},
+					"title": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					idArg, hasID := p.Args["id"].(string)
+					title, hasTitle := p.Args["title"].(string)
+					if hasID == hasTitle {
+						return nil, errors.New("exactly one of id and title is required")
+					}
+
+					wiki := loadersFromContext(p.Context).wiki
+					if hasTitle {
+						return wiki.GetArticleContext(p.Context, title)
+					}
+					id, err := strconv.ParseInt(idArg, 10, 64)
+					if err != nil {
+						return nil, errors.New("invalid article id")
+					}
+					return wiki.GetArticleByIDContext(p.Context, id)
+				},
+			},
+			"search": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(searchResultType))),
+				Args: graphql.FieldConfigArgument{
+					"q":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, _ := p.Args["limit"].(int)
+					offset, _ := p.Args["offset"].(int)
+					return loadersFromContext(p.Context).wiki.SearchTitleResults(p.Context, p.Args["q"].(string), limit, offset)
+				},
+			},
+			"random": &graphql.Field{
+				Type:        articleType,
+				Description: "A random non-redirect article of namespace ns",
+				Args: graphql.FieldConfigArgument{
+					"ns": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ns, _ := p.Args["ns"].(int)
+					return loadersFromContext(p.Context).wiki.RandomArticle(ns)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// graphqlHandler serves GraphQL queries sent as a JSON body by POST, or with
+// the query, operationName and variables parameters by GET
+func graphqlHandler(schema graphql.Schema) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var request graphqlRequest
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+				return nil
+			}
+		} else {
+			request.Query = r.URL.Query().Get("query")
+			request.OperationName = r.URL.Query().Get("operationName")
+			if variables := r.URL.Query().Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+					http.Error(w, "Invalid variables parameter", http.StatusBadRequest)
+					return nil
+				}
+			}
+		}
+		if request.Query == "" {
+			http.Error(w, "Missing GraphQL query", http.StatusBadRequest)
+			return nil
+		}
+
+		stop := TimingFromContext(r.Context()).Start("query")
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  request.Query,
+			OperationName:  request.OperationName,
+			VariableValues: request.Variables,
+			Context:        context.WithValue(r.Context(), graphqlLoadersKey{}, newGraphQLLoaders(requestWiki(r))),
+		})
+		stop()
+
+		return writeJSON(w, r, result)
+	}
+}
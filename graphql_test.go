@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// postGraphQL sends query to the GraphQL endpoint of the server at url and
+// decodes the data of the response into v
+func postGraphQL(tb testing.TB, url, query string, v interface{}) {
+	tb.Helper()
+
+	body, err := json.Marshal(graphqlRequest{Query: query})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	resp, err := http.Post(url+"/api/graphql", "application/json", bytes.NewReader(body))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		tb.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		tb.Fatal(err)
+	}
+	if len(result.Errors) > 0 {
+		tb.Fatalf("GraphQL errors: %v", result.Errors)
+	}
+	if err := json.Unmarshal(result.Data, v); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestGraphQLIntrospection(t *testing.T) {
+	server := newTestServer(t, testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."})
+
+	var schema struct {
+		Schema struct {
+			QueryType struct {
+				Fields []struct {
+					Name string `json:"name"`
+					Args []struct {
+						Name string `json:"name"`
+					} `json:"args"`
+				} `json:"fields"`
+			} `json:"queryType"`
+			Types []struct {
+				Name   string `json:"name"`
+				Fields []struct {
+					Name string `json:"name"`
+				} `json:"fields"`
+			} `json:"types"`
+		} `json:"__schema"`
+	}
+	postGraphQL(t, server.URL, `{
+		__schema {
+			queryType { fields { name args { name } } }
+			types { name fields { name } }
+		}
+	}`, &schema)
+
+	queries := make(map[string][]string)
+	for _, field := range schema.Schema.QueryType.Fields {
+		for _, arg := range field.Args {
+			queries[field.Name] = append(queries[field.Name], arg.Name)
+		}
+	}
+	wantQueries := map[string][]string{
+		"article": {"id", "title"},
+		"search":  {"q", "limit", "offset"},
+		"random":  {"ns"},
+	}
+	for name, args := range wantQueries {
+		got := slices.Clone(queries[name])
+		slices.Sort(got)
+		slices.Sort(args)
+		if !slices.Equal(got, args) {
+			t.Errorf("query %s: got arguments %q, want %q", name, queries[name], args)
+		}
+	}
+
+	types := make(map[string][]string)
+	for _, typ := range schema.Schema.Types {
+		for _, field := range typ.Fields {
+			types[typ.Name] = append(types[typ.Name], field.Name)
+		}
+	}
+	wantFields := map[string][]string{
+		"Article":      {"id", "title", "namespace", "content", "redirect", "wordCount", "sections", "backlinks"},
+		"SearchResult": {"id", "title", "score", "article"},
+		"Section":      {"level", "title", "byteOffset"},
+	}
+	for name, fields := range wantFields {
+		got, ok := types[name]
+		if !ok {
+			t.Errorf("type %s is missing", name)
+			continue
+		}
+		for _, field := range fields {
+			if !slices.Contains(got, field) {
+				t.Errorf("type %s: field %s is missing from %q", name, field, got)
+			}
+		}
+	}
+}
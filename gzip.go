@@ -0,0 +1,150 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize is the response size below which responses are sent
+// uncompressed, compression costing more than it saves
+const gzipMinSize = 1024
+
+type gzipKey struct{}
+
+// disableGzip makes gzipMiddleware send the response to r uncompressed,
+// keeping the Content-Length of downloads that are already compact, such as
+// database snapshots. It must be called before the response is written.
+func disableGzip(r *http.Request) {
+	if gw, ok := r.Context().Value(gzipKey{}).(*gzipResponseWriter); ok {
+		gw.disabled = true
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of r allows gzip,
+// i.e. lists it without a zero quality value
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			return err == nil && quality > 0
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the first gzipMinSize bytes of a response to
+// decide whether to compress it
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    []byte
+	gz     *gzip.Writer
+	// started is set once the headers have been sent
+	started bool
+	// disabled is set by disableGzip
+	disabled bool
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if gw.status == 0 {
+		gw.status = status
+	}
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if gw.started {
+		if gw.gz != nil {
+			return gw.gz.Write(b)
+		}
+		return gw.ResponseWriter.Write(b)
+	}
+
+	gw.buf = append(gw.buf, b...)
+	if len(gw.buf) >= gzipMinSize || gw.disabled {
+		if err := gw.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// start sends the headers and the buffered bytes, compressed when compress
+// is set and the handler neither disabled compression, encoded the response
+// itself nor answered a range request
+func (gw *gzipResponseWriter) start(compress bool) error {
+	gw.started = true
+	if gw.status == 0 {
+		gw.status = http.StatusOK
+	}
+
+	header := gw.Header()
+	if compress && !gw.disabled && header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" && gw.status != http.StatusPartialContent {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		gw.gz, _ = gzip.NewWriterLevel(gw.ResponseWriter, gzip.BestSpeed)
+	}
+	gw.ResponseWriter.WriteHeader(gw.status)
+
+	buf := gw.buf
+	gw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if gw.gz != nil {
+		_, err := gw.gz.Write(buf)
+		return err
+	}
+	_, err := gw.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush compresses streamed responses, such as NDJSON search results, as they
+// are written
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.started {
+		gw.start(true)
+	}
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close sends responses shorter than gzipMinSize uncompressed and ends the
+// gzip stream of the others
+func (gw *gzipResponseWriter) close() error {
+	if !gw.started {
+		return gw.start(false)
+	}
+	if gw.gz != nil {
+		return gw.gz.Close()
+	}
+	return nil
+}
+
+// gzipMiddleware compresses the responses of at least gzipMinSize bytes with
+// gzip when the client accepts it, unless the handler calls disableGzip.
+// WebSocket upgrades are passed through.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r.WithContext(context.WithValue(r.Context(), gzipKey{}, gw)))
+	})
+}
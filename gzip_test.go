@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGzipMiddlewareRanges(t *testing.T) {
+	content := strings.Repeat("wikipedia ", 1000)
+	handler := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "dump.txt", time.Time{}, strings.NewReader(content))
+	}))
+
+	tests := []struct {
+		name     string
+		rangeHdr string
+		status   int
+		encoding string
+	}{
+		{"full response", "", http.StatusOK, "gzip"},
+		{"range request", "bytes=0-2047", http.StatusPartialContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/dump", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			if tt.rangeHdr != "" {
+				req.Header.Set("Range", tt.rangeHdr)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d", rec.Code, tt.status)
+			}
+			if encoding := rec.Header().Get("Content-Encoding"); encoding != tt.encoding {
+				t.Errorf("got Content-Encoding %q, want %q", encoding, tt.encoding)
+			}
+			if tt.encoding == "" && !bytes.Equal(rec.Body.Bytes(), []byte(content[:2048])) {
+				t.Errorf("got %d bytes, want the first 2048 bytes of the content", rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestDisableGzip(t *testing.T) {
+	content := strings.Repeat("wikipedia ", 1000)
+	handler := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		disableGzip(r)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		io.WriteString(w, content)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/snapshot", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("got Content-Encoding %q, want an uncompressed response", encoding)
+	}
+	if length := rec.Header().Get("Content-Length"); length != strconv.Itoa(len(content)) {
+		t.Errorf("got Content-Length %q, want %d", length, len(content))
+	}
+	if rec.Body.String() != content {
+		t.Errorf("got %d bytes, want the %d bytes of the content", rec.Body.Len(), len(content))
+	}
+
+	// Requests that the middleware passed through are left alone
+	disableGzip(req)
+}
+
+func TestGzipLargeArticle(t *testing.T) {
+	content := strings.Repeat("Physics is the natural science of matter and energy. ", 2000)
+	server := newTestServer(t, testPage{ID: 1, Title: "Physics", Text: content})
+
+	get := func(path string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Setting the header disables the transparent decompression of the client
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := get("/api/article/1")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("got Content-Encoding %q, want gzip", encoding)
+	}
+	if vary := resp.Header.Get("Vary"); !strings.Contains(vary, "Accept-Encoding") {
+		t.Errorf("got Vary %q, want Accept-Encoding", vary)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("the response is not gzip-compressed: %v", err)
+	}
+	var article struct {
+		Title   string `json:"title"`
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(gz).Decode(&article); err != nil {
+		t.Fatalf("failed to decode the decompressed response: %v", err)
+	}
+	if article.Title != "Physics" || article.Content != content {
+		t.Errorf("got article %q with %d bytes of content, want Physics with %d bytes", article.Title, len(article.Content), len(content))
+	}
+
+	// Responses below gzipMinSize are sent as is
+	if resp := get("/api/health"); resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("got Content-Encoding %q for a small response", resp.Header.Get("Content-Encoding"))
+	}
+}
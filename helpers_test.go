@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriceboyer/wikipedia_sqlite/wikipedia"
+)
+
+// testPage is a main namespace page of the dumps imported by newTestServer
+type testPage struct {
+	ID       int
+	Title    string
+	Redirect string
+	Text     string
+}
+
+// writeTestDump writes pages as an uncompressed XML dump to path
+func writeTestDump(tb testing.TB, path string, pages []testPage) {
+	tb.Helper()
+
+	escape := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("<mediawiki>\n")
+	for _, page := range pages {
+		fmt.Fprintf(&b, "<page><title>%s</title><ns>0</ns><id>%d</id>", escape(page.Title), page.ID)
+		if page.Redirect != "" {
+			fmt.Fprintf(&b, `<redirect title="%s" />`, escape(page.Redirect))
+		}
+		fmt.Fprintf(&b, "<revision><id>%d</id><timestamp>2024-01-01T00:00:00Z</timestamp><text>%s</text></revision></page>\n",
+			1000+page.ID, escape(page.Text))
+	}
+	b.WriteString("</mediawiki>\n")
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// newTestWiki imports pages into a database in a temporary directory, returned
+// with the Wiki, and makes it the only language of the server, restoring the
+// previous configuration at the end of the test
+func newTestWiki(tb testing.TB, pages ...testPage) (*wikipedia.Wiki, string) {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	writeTestDump(tb, filepath.Join(dir, "articles.xml"), pages)
+	pool := wikipedia.NewWikiPool(map[string]wikipedia.WikiConfig{
+		"en": {DumpPath: dir, IndexFile: "index.txt", ArticlesFile: "articles.xml"},
+	})
+	w, _ := pool.Get("en")
+	w.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		tb.Fatalf("failed to import test pages: %v", err)
+	}
+
+	serveWikis(tb, pool)
+	return w, dir
+}
+
+// serveWikis makes the "en" Wiki of pool the only language of the server and
+// restores the previous configuration at the end of the test
+func serveWikis(tb testing.TB, pool *wikipedia.WikiPool) {
+	prevWikis, prevWiki, prevLanguage := wikis, wiki, defaultLanguage
+	wikis, defaultLanguage = pool, "en"
+	wiki, _ = pool.Get("en")
+	tb.Cleanup(func() {
+		pool.Close()
+		wikis, wiki, defaultLanguage = prevWikis, prevWiki, prevLanguage
+	})
+}
+
+// newFixtureServer serves the router on the fixture dump of testdata, whose
+// main namespace pages are imported while its templates and categories are
+// only in the index
+func newFixtureServer(tb testing.TB) *httptest.Server {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	const indexFile, articlesFile = "fixture-index.txt.bz2", "fixture-articles.xml.bz2"
+	for _, name := range []string{indexFile, articlesFile} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	pool := wikipedia.NewWikiPool(map[string]wikipedia.WikiConfig{
+		"en": {DumpPath: dir, IndexFile: indexFile, ArticlesFile: articlesFile},
+	})
+	w, _ := pool.Get("en")
+	w.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	serveWikis(tb, pool)
+	if err := w.LoadIndex(-1); err != nil {
+		tb.Fatalf("failed to load the fixture index: %v", err)
+	}
+	if err := w.ProcessArticles(-1); err != nil {
+		tb.Fatalf("failed to import the fixture: %v", err)
+	}
+
+	server := httptest.NewServer(newRouter())
+	tb.Cleanup(server.Close)
+	return server
+}
+
+// newTestServer serves the router on a database holding pages
+func newTestServer(tb testing.TB, pages ...testPage) *httptest.Server {
+	tb.Helper()
+
+	newTestWiki(tb, pages...)
+	server := httptest.NewServer(newRouter())
+	tb.Cleanup(server.Close)
+	return server
+}
+
+// getJSON decodes the JSON response of a GET request to url into v and
+// returns its status code
+func getJSON(tb testing.TB, url string, v interface{}) int {
+	tb.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK && v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			tb.Fatalf("failed to decode response of %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fabriceboyer/wikipedia_sqlite/wikipedia"
+)
+
+// defaultLanguage is the language served when a request has no lang
+// parameter; its Wiki is also the one the command line flags operate on
+var defaultLanguage string
+
+// wikis holds the Wiki of every configured language edition
+var wikis *wikipedia.WikiPool
+
+type wikiKey struct{}
+
+// languageMiddleware selects the Wiki of the lang query parameter, defaulting
+// to defaultLanguage, and answers 404 for languages that are not configured
+func languageMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("lang")
+		if lang == "" {
+			lang = defaultLanguage
+		}
+
+		selected, ok := wikis.Get(lang)
+		if !ok {
+			http.Error(w, "Unknown language: "+lang, http.StatusNotFound)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), wikiKey{}, selected)))
+	})
+}
+
+// requestWiki returns the Wiki selected for r by languageMiddleware, or the
+// default one for routes outside the API subrouter
+func requestWiki(r *http.Request) *wikipedia.Wiki {
+	if selected, ok := r.Context().Value(wikiKey{}).(*wikipedia.Wiki); ok {
+		return selected
+	}
+	return wiki
+}
+
+func handleLanguages(w http.ResponseWriter, r *http.Request) error {
+	languages := wikis.Languages()
+	return writeJSON(w, r, map[string]interface{}{
+		"languages": languages,
+		"default":   defaultLanguage,
+		"count":     len(languages),
+	})
+}
@@ -1,29 +1,158 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/fabriceboyer/common_go_utils/utils"
+	"github.com/fabriceboyer/wikipedia_sqlite/middleware"
 	"github.com/fabriceboyer/wikipedia_sqlite/wikipedia"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
 )
 
+// wiki is the Wiki of the default language, used by the command line flags
 var wiki *wikipedia.Wiki
 
+// federated is set when PEERS lists other servers to fan searches out to
+var federated *wikipedia.FederatedWiki
+
+// adminToken protects the /api/admin endpoints; they are disabled when empty
+var adminToken string
+
+// corsAllowedOrigins lists the origins browser clients may call the API from
+var corsAllowedOrigins []string
+
+// rateLimitRPS and rateLimitBurst limit requests per client IP on the /api
+// endpoints; rate limiting is disabled when rateLimitRPS is not positive
+var (
+	rateLimitRPS   float64
+	rateLimitBurst int
+)
+
+// metricsEnabled exposes Prometheus metrics at /metrics
+var metricsEnabled bool
+
+// defaultListenAddr is used when LISTEN_ADDR is not configured
+const defaultListenAddr = ":9096"
+
+// healthTimeout bounds the database check of /api/health
+const healthTimeout = 2 * time.Second
+
+// defaultShutdownTimeout is how long in-flight requests may take to complete
+// on shutdown when SHUTDOWN_TIMEOUT is not configured
+const defaultShutdownTimeout = 30 * time.Second
+
+// federatedTimeout bounds each request made to a peer server
+const federatedTimeout = 5 * time.Second
+
+// Limits of the stress test endpoint
+const (
+	maxStressDuration    = 5 * time.Minute
+	maxStressConcurrency = 100
+)
+
 func main() {
 	// Command line flags
-	loadIndex := flag.Bool("load-index", false, "Load the index file into the database")
-	processArticles := flag.Bool("process-articles", false, "Process articles from the dump file")
+	loadIndex := flag.Bool("load-index", false, "Load the index file (bzip2, gzip, Zstandard or uncompressed) into the database")
+	processArticles := flag.Bool("process-articles", false, "Process articles from the dump file (bzip2, gzip, Zstandard or uncompressed)")
+	importArticlesOnly := flag.Bool("import-articles-only", false, "Process every article of the dump file without loading or consulting the index")
+	dryRun := flag.Bool("dry-run", false, "Parse the dump with -load-index and -process-articles and report what would be inserted, without writing to the database")
+	resume := flag.Bool("resume", false, "Skip the articles already committed by an interrupted -process-articles run")
+	incremental := flag.Bool("incremental", false, "Skip the articles already stored with the revision timestamp they have in the dump when processing articles")
+	namespaces := flag.String("namespaces", "0", "Comma-separated namespace IDs of the pages stored when processing articles")
+	stripText := flag.Bool("strip-text", false, "Also store the plain text of each article when processing articles")
+	workers := flag.Int("workers", 0, "Decompress the streams of a bzip2 multistream articles file with this many parallel workers when processing articles (0 processes it sequentially, -1 uses one worker per CPU; other formats are always processed sequentially)")
+	mailtoLinks := flag.Bool("mailto-links", false, "Also store mailto: links in the external links table when processing articles")
+	readOnly := flag.Bool("read-only", false, "Open the databases read-only, rejecting writes such as -load-index and -process-articles")
+	rebuildFTS := flag.Bool("rebuild-fts", false, "Rebuild the full-text index from the articles table")
+	optimize := flag.Bool("optimize", false, "Optimize the full-text index (done automatically by -process-articles)")
+	vacuum := flag.Bool("vacuum", false, "Rebuild the database file to reclaim unused space")
+	vacuumInto := flag.String("vacuum-into", "", "Write a compacted copy of the database to this file (must not exist), leaving the database untouched")
 	limit := flag.Int("limit", -1, "Limit the number of entries to process (for testing)")
+	parseOnly := flag.Bool("parse-only", false, "Measure index and articles parsing throughput without writing to the database")
+	namespaceStats := flag.Bool("namespace-stats", false, "Count the pages of each namespace in the articles file, then exit")
+	estimateSize := flag.Bool("estimate-size", false, "Estimate the uncompressed and database size of the dump, then exit")
+	computeMinHash := flag.Bool("compute-minhash", false, "Compute MinHash signatures of articles for near-duplicate detection")
+	computeSignificance := flag.Bool("compute-significance", false, "Compute the significance scores of articles from their PageRank, categories and word count")
+	computeNgrams := flag.Int("compute-ngrams", 0, "Compute the frequencies of all N-word n-grams across articles")
+	buildWordFrequencies := flag.Bool("build-word-frequencies", false, "Count the terms of the plain text of each article for /api/stats/terms and /api/article/{id}/terms")
+	ngramMinFrequency := flag.Int("ngram-min-frequency", 2, "Discard n-grams seen fewer times than this when computing n-grams")
+	recoverDB := flag.String("recover-db", "", "Copy the readable contents of a corrupt database to -recover-out, then exit")
+	recoverOut := flag.String("recover-out", "", "Output path of the recovered database (must not exist)")
+	checkIndex := flag.Bool("check-index", false, "Check that the index seek offsets point to bzip2 streams of the articles file, then exit (non-zero when one does not)")
+	verify := flag.Bool("verify", false, "Check the integrity and consistency of the database, then exit (non-zero when a check fails)")
+	deleteArticleID := flag.Int64("delete-article-id", 0, "Delete the article with this ID from the database, then exit")
+	deleteArticleTitle := flag.String("delete-article-title", "", "Delete the article with this exact title from the database, then exit")
+	exportNDJSON := flag.Bool("export-ndjson", false, "Export all articles to -output as newline-delimited JSON, then exit")
+	exportCSV := flag.Bool("export-csv", false, "Export the metadata of all articles to -output as CSV, then exit")
+	output := flag.String("output", "", "Output file of -export-ndjson and -export-csv")
+	includeContent := flag.Bool("include-content", false, "Include the article content in exports")
+	listen := flag.String("listen", "", "Address the HTTP server listens on (overrides LISTEN_ADDR, default :9096)")
+	enableMetrics := flag.Bool("enable-metrics", false, "Expose Prometheus metrics at /metrics")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file; serves HTTPS together with -tls-key (overrides TLS_CERT_FILE)")
+	tlsKey := flag.String("tls-key", "", "TLS private key file; serves HTTPS together with -tls-cert (overrides TLS_KEY_FILE)")
+	logFormat := flag.String("log-format", "text", "Log format: text, or json for log aggregation systems")
 	flag.Parse()
 
+	// JSON logs also capture the standard log package output
+	logger := slog.Default()
+	switch *logFormat {
+	case "text":
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+		slog.SetDefault(logger)
+	default:
+		log.Fatalf("Unknown -log-format %q: must be text or json", *logFormat)
+	}
+
+	// Recovery works on explicit paths and needs no configuration
+	if *recoverDB != "" {
+		if *recoverOut == "" {
+			log.Fatal("-recover-out is required with -recover-db")
+		}
+		result, err := wikipedia.RecoverDatabase(context.Background(), *recoverDB, *recoverOut)
+		if err != nil {
+			log.Fatalf("Failed to recover database: %v", err)
+		}
+		log.Printf("Recovered %d tables, %d rows (%d rows lost) into %s",
+			result.TablesRecovered, result.RowsRecovered, result.RowsLost, *recoverOut)
+		os.Exit(0)
+	}
+
+	viper.SetDefault("LISTEN_ADDR", defaultListenAddr)
+	viper.SetDefault("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", "*")
+	viper.SetDefault("WS_MAX_CONNECTIONS", defaultWSMaxConnections)
+	viper.SetDefault("CACHE_SIZE", wikipedia.DefaultCacheSize)
+	viper.SetDefault("WIKI_LANGUAGE", "en")
+	viper.SetDefault("DB_MAX_OPEN_CONNS", wikipedia.DefaultMaxOpenConns)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", wikipedia.DefaultMaxIdleConns)
+	viper.SetDefault("DB_CONN_MAX_IDLE_SECONDS", int(wikipedia.DefaultConnMaxIdleTime/time.Second))
+	viper.SetDefault("COMPRESSION", wikipedia.CompressionNone)
+	viper.SetDefault("MAX_ARTICLE_BYTES", wikipedia.DefaultMaxArticleBytes)
+	viper.SetDefault("MAX_RETRY_COUNT", wikipedia.DefaultMaxRetries)
+	viper.SetDefault("SEARCH_LOGGING", false)
 	err := utils.SetupConfigPath(".")
 	if err != nil {
 		log.Fatalf("Failed to setup config: %v", err)
@@ -45,7 +174,209 @@ func main() {
 		articlesFile = "articles-multistream.xml.bz2"
 	}
 
-	wiki = wikipedia.NewWiki(dumpPath, indexFile, articlesFile)
+	var processNamespaces []int
+	for _, ns := range strings.Split(*namespaces, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(ns))
+		if err != nil {
+			log.Fatalf("Invalid namespace %q in -namespaces", ns)
+		}
+		processNamespaces = append(processNamespaces, id)
+	}
+
+	// DUMP_PATH holds the WIKI_LANGUAGE edition; LANGUAGE_DUMP_PATHS serves
+	// other editions from their own directories, e.g. de=/dumps/dewiki
+	defaultLanguage = viper.GetString("WIKI_LANGUAGE")
+	cacheSize := viper.GetInt("CACHE_SIZE")
+	langs := map[string]wikipedia.WikiConfig{
+		defaultLanguage: {DumpPath: dumpPath, IndexFile: indexFile, ArticlesFile: articlesFile, CacheSize: cacheSize},
+	}
+	if paths := viper.GetString("LANGUAGE_DUMP_PATHS"); paths != "" {
+		for _, entry := range strings.Split(paths, ",") {
+			lang, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || lang == "" || path == "" {
+				log.Fatalf("Invalid entry %q in LANGUAGE_DUMP_PATHS, expected lang=path", entry)
+			}
+			if _, exists := langs[lang]; exists {
+				log.Fatalf("Language %q is configured more than once", lang)
+			}
+			langs[lang] = wikipedia.WikiConfig{DumpPath: path, IndexFile: indexFile, ArticlesFile: articlesFile, CacheSize: cacheSize}
+		}
+	}
+	wikis = wikipedia.NewWikiPool(langs)
+	for _, lang := range wikis.Languages() {
+		w, _ := wikis.Get(lang)
+		w.WithConnPool(viper.GetInt("DB_MAX_OPEN_CONNS"), viper.GetInt("DB_MAX_IDLE_CONNS"),
+			time.Duration(viper.GetInt("DB_CONN_MAX_IDLE_SECONDS"))*time.Second).
+			WithMaxRetries(viper.GetInt("MAX_RETRY_COUNT")).
+			WithSearchLogging(viper.GetBool("SEARCH_LOGGING"))
+		w.SetLogger(logger.With("language", lang))
+		if *readOnly {
+			if err := w.OpenReadOnly(); err != nil {
+				log.Fatalf("Failed to open %s database read-only: %v", lang, err)
+			}
+		}
+	}
+
+	compression := viper.GetString("COMPRESSION")
+	if compression != wikipedia.CompressionNone && compression != wikipedia.CompressionGzip {
+		log.Fatalf("Invalid COMPRESSION %q, expected %s or %s", compression, wikipedia.CompressionNone, wikipedia.CompressionGzip)
+	}
+
+	maxArticleBytes := viper.GetInt("MAX_ARTICLE_BYTES")
+	if maxArticleBytes <= 0 {
+		log.Fatalf("Invalid MAX_ARTICLE_BYTES %d, expected a positive size in bytes", maxArticleBytes)
+	}
+
+	wiki, _ = wikis.Get(defaultLanguage)
+	wiki.WithCompression(compression).WithMaxArticleBytes(maxArticleBytes).WithStripText(*stripText).WithResume(*resume).WithIncremental(*incremental).WithNamespaces(processNamespaces...).
+		WithDryRun(*dryRun).WithMailtoLinks(*mailtoLinks)
+	adminToken = viper.GetString("ADMIN_TOKEN")
+	corsAllowedOrigins = strings.Split(viper.GetString("CORS_ALLOWED_ORIGINS"), ",")
+	rateLimitRPS = viper.GetFloat64("RATE_LIMIT_RPS")
+	rateLimitBurst = viper.GetInt("RATE_LIMIT_BURST")
+	wsConnections = make(chan struct{}, viper.GetInt("WS_MAX_CONNECTIONS"))
+
+	// Optional peers for federated search, e.g. PEERS=http://host1:9096,http://host2:9096
+	if peers := viper.GetString("PEERS"); peers != "" {
+		federated = wikipedia.NewFederatedWiki(strings.Split(peers, ","), federatedTimeout)
+		federated.SetLogger(logger.With("component", "federated"))
+		log.Printf("Federated search enabled across %d peers", len(federated.Peers()))
+	}
+
+	if *namespaceStats {
+		counts, err := wiki.ScanNamespaces(*limit)
+		if err != nil {
+			log.Fatalf("Failed to scan namespaces: %v", err)
+		}
+
+		ids := make([]int, 0, len(counts))
+		for id := range counts {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(table, "ID\tNamespace\tPages\t")
+		for _, id := range ids {
+			fmt.Fprintf(table, "%d\t%s\t%d\t\n", id, wikipedia.GetNamespaceName(id), counts[id])
+		}
+		table.Flush()
+		os.Exit(0)
+	}
+
+	if *estimateSize {
+		estimate, err := wiki.EstimateDumpSize(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to estimate dump size: %v", err)
+		}
+		log.Printf("Compressed: %d bytes (index %d, articles %d)",
+			estimate.CompressedSizeBytes, estimate.IndexFileSizeBytes, estimate.ArticlesFileSizeBytes)
+		log.Printf("Estimated uncompressed size: %d MB, estimated database size: %d MB",
+			estimate.EstimatedUncompressedMB, estimate.EstimatedDBSizeMB)
+		os.Exit(0)
+	}
+
+	if *checkIndex {
+		result, err := wiki.CheckIndex()
+		if err != nil {
+			log.Fatalf("Failed to check index: %v", err)
+		}
+		log.Printf("Seek offsets: %d, valid: %d, invalid: %d",
+			result.TotalSeeks, result.ValidSeeks, len(result.InvalidSeeks))
+		if len(result.InvalidSeeks) > 0 {
+			shown := result.InvalidSeeks
+			if len(shown) > 10 {
+				shown = shown[:10]
+			}
+			log.Fatalf("Index check failed, invalid seek offsets include %v: the articles file may be truncated or not match the index", shown)
+		}
+		log.Println("Index check passed")
+		os.Exit(0)
+	}
+
+	if *verify {
+		result, err := wiki.Verify()
+		if err != nil {
+			log.Fatalf("Failed to verify database: %v", err)
+		}
+		log.Printf("Integrity check: %s", strings.Join(result.IntegrityCheck, "; "))
+		log.Printf("Articles: %d, indexed articles: %d, articles without index entry: %d",
+			result.Articles, result.IndexedArticles, result.ArticlesWithoutIndexEntry)
+		log.Printf("Broken redirects: %d, articles missing content: %d",
+			result.BrokenRedirects, result.ArticlesMissingContent)
+		if result.CompressedArticles > 0 {
+			log.Printf("Compressed articles: %d, %d bytes compressed from %d bytes",
+				result.CompressedArticles, result.CompressedBytes, result.UncompressedBytes)
+		}
+		if !result.OK {
+			log.Fatal("Database verification failed")
+		}
+		log.Println("Database verification passed")
+		os.Exit(0)
+	}
+
+	if *deleteArticleID != 0 || *deleteArticleTitle != "" {
+		var err error
+		if *deleteArticleID != 0 {
+			err = wiki.DeleteArticle(*deleteArticleID)
+		} else {
+			err = wiki.DeleteArticleByTitle(*deleteArticleTitle)
+		}
+		if err != nil {
+			log.Fatalf("Failed to delete article: %v", err)
+		}
+		os.Exit(0)
+	}
+
+	if *exportNDJSON || *exportCSV {
+		if *output == "" {
+			log.Fatal("-export-ndjson and -export-csv require -output")
+		}
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+
+		start := time.Now()
+		if *exportNDJSON {
+			var count int64
+			count, err = wiki.ExportNDJSON(context.Background(), file, *includeContent)
+			if err == nil {
+				log.Printf("Exported %d articles to %s in %s", count, *output, time.Since(start))
+			}
+		} else {
+			err = wiki.ExportCSV(file, *includeContent)
+			if err == nil {
+				log.Printf("Exported articles to %s in %s", *output, time.Since(start))
+			}
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			log.Fatalf("Failed to export articles: %v", err)
+		}
+		os.Exit(0)
+	}
+
+	// Profiling phase: parse the dump files without touching the database
+	if *parseOnly {
+		ctx := context.Background()
+		indexResult, err := wiki.ParseIndexOnly(ctx, *limit)
+		if err != nil {
+			log.Fatalf("Failed to parse index: %v", err)
+		}
+		log.Printf("Index: %d entries, %d bytes in %s (%.2f MB/s)",
+			indexResult.ArticlesParsed, indexResult.BytesRead, indexResult.Duration, indexResult.ThroughputMBps)
+
+		articlesResult, err := wiki.ParseArticlesOnly(ctx, *limit)
+		if err != nil {
+			log.Fatalf("Failed to parse articles: %v", err)
+		}
+		log.Printf("Articles: %d pages, %d bytes in %s (%.2f MB/s)",
+			articlesResult.ArticlesParsed, articlesResult.BytesRead, articlesResult.Duration, articlesResult.ThroughputMBps)
+		os.Exit(0)
+	}
 
 	// Preprocessing phase
 	if *loadIndex {
@@ -58,42 +389,280 @@ func main() {
 
 	if *processArticles {
 		log.Println("Processing articles...")
-		if err := wiki.ProcessArticles(*limit); err != nil {
+		var err error
+		if *workers != 0 {
+			err = wiki.ProcessArticlesParallel(*limit, *workers)
+		} else {
+			err = wiki.ProcessArticles(*limit)
+		}
+		if err != nil {
+			log.Fatalf("Failed to process articles: %v", err)
+		}
+		log.Println("Articles processed successfully")
+	}
+
+	if *importArticlesOnly {
+		log.Println("Processing articles without the index...")
+		if err := wiki.ProcessArticlesSequential(*limit); err != nil {
 			log.Fatalf("Failed to process articles: %v", err)
 		}
 		log.Println("Articles processed successfully")
 	}
 
+	if *rebuildFTS {
+		log.Println("Rebuilding full-text index...")
+		if err := wiki.RebuildFTS(); err != nil {
+			log.Fatalf("Failed to rebuild full-text index: %v", err)
+		}
+	}
+
+	if *optimize {
+		log.Println("Optimizing full-text index...")
+		if err := wiki.OptimizeFTS(); err != nil {
+			log.Fatalf("Failed to optimize full-text index: %v", err)
+		}
+	}
+
+	if *vacuum {
+		log.Println("Vacuuming database...")
+		if err := wiki.Vacuum(); err != nil {
+			log.Fatalf("Failed to vacuum database: %v", err)
+		}
+	}
+
+	if *vacuumInto != "" {
+		if err := wiki.VacuumInto(*vacuumInto); err != nil {
+			log.Fatalf("Failed to vacuum database: %v", err)
+		}
+	}
+
+	if *computeNgrams > 0 {
+		log.Printf("Computing %d-grams...", *computeNgrams)
+		err := wiki.ComputeNgrams(context.Background(), *computeNgrams, *ngramMinFrequency, func(processed int64) {
+			if processed%100000 == 0 {
+				log.Printf("Counted n-grams in %d articles", processed)
+			}
+		})
+		if err != nil {
+			log.Fatalf("Failed to compute n-grams: %v", err)
+		}
+		log.Println("N-grams computed successfully")
+	}
+
+	if *computeSignificance {
+		log.Println("Computing significance scores...")
+		if err := wiki.ComputeSignificanceScores(context.Background()); err != nil {
+			log.Fatalf("Failed to compute significance scores: %v", err)
+		}
+		log.Println("Significance scores computed successfully")
+	}
+
+	if *buildWordFrequencies {
+		log.Println("Building word frequency index...")
+		if err := wiki.BuildWordFrequencyIndex(); err != nil {
+			log.Fatalf("Failed to build word frequency index: %v", err)
+		}
+		log.Println("Word frequency index built successfully")
+	}
+
+	if *computeMinHash {
+		log.Println("Computing MinHash signatures...")
+		err := wiki.ComputeMinHash(context.Background(), func(processed int64) {
+			if processed%100000 == 0 {
+				log.Printf("Computed MinHash signatures of %d articles", processed)
+			}
+		})
+		if err != nil {
+			log.Fatalf("Failed to compute MinHash signatures: %v", err)
+		}
+		log.Println("MinHash signatures computed successfully")
+	}
+
 	// If only preprocessing, exit
-	if *loadIndex || *processArticles {
+	if *loadIndex || *processArticles || *importArticlesOnly || *computeNgrams > 0 || *computeSignificance || *buildWordFrequencies || *computeMinHash {
 		if err := wiki.Close(); err != nil {
 			log.Printf("Error closing database: %v", err)
 		}
 		os.Exit(0)
 	}
 
-	// Open database for serving
+	// Open database for serving; it is closed once the server has shut down
 	if err := wiki.Open(); err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
-	defer func() {
-		if err := wiki.Close(); err != nil {
-			log.Printf("Error closing database: %v", err)
+
+	addr := viper.GetString("LISTEN_ADDR")
+	if *listen != "" {
+		addr = *listen
+	}
+
+	certFile := viper.GetString("TLS_CERT_FILE")
+	if *tlsCert != "" {
+		certFile = *tlsCert
+	}
+	keyFile := viper.GetString("TLS_KEY_FILE")
+	if *tlsKey != "" {
+		keyFile = *tlsKey
+	}
+	if (certFile == "") != (keyFile == "") {
+		log.Fatal("TLS requires both a certificate and a key: set TLS_CERT_FILE and TLS_KEY_FILE (or -tls-cert and -tls-key)")
+	}
+
+	if *enableMetrics {
+		if err := wiki.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+			log.Fatalf("Failed to register metrics: %v", err)
 		}
+		metricsEnabled = true
+	}
+
+	log.Printf("Starting Wikipedia SQLite server on %s...", addr)
+	handleRequests(addr, certFile, keyFile, viper.GetDuration("SHUTDOWN_TIMEOUT"))
+}
+
+// handleRequests serves the API and frontend on addr, over HTTPS when a
+// certificate and key are given. On SIGINT or SIGTERM, it stops accepting
+// connections, waits up to shutdownTimeout for in-flight requests and closes
+// the databases.
+func handleRequests(addr, certFile, keyFile string, shutdownTimeout time.Duration) {
+	server := &http.Server{Addr: addr, Handler: newRouter()}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- serve(server, listener, certFile, keyFile)
 	}()
 
-	log.Println("Starting Wikipedia SQLite server...")
-	handleRequests()
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process without waiting
+	stop()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests...", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+
+	if err := wikis.Close(); err != nil {
+		log.Printf("Error closing database: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+// serve accepts the connections of listener until the server is shut down,
+// over HTTPS when certFile is set
+func serve(server *http.Server, listener net.Listener, certFile, keyFile string) error {
+	if certFile != "" {
+		return server.ServeTLS(listener, certFile, keyFile)
+	}
+	return server.Serve(listener)
 }
 
-func handleRequests() {
+// newRouter returns the handler of the server: the /api endpoints and the
+// static files of the web interface
+func newRouter() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
 
+	// Health check for liveness and readiness probes, registered ahead of the
+	// API subrouter so that it bypasses its middleware and rate limiting
+	router.HandleFunc("/api/health", utils.ErrorHandler(handleHealth)).Methods(http.MethodGet)
+
 	// API endpoints (must be before static file serving)
 	apiRouter := router.PathPrefix("/api").Subrouter()
+	if metricsEnabled {
+		router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+		metricsMiddleware, err := middleware.MetricsMiddleware(prometheus.DefaultRegisterer)
+		if err != nil {
+			log.Fatalf("Failed to register metrics: %v", err)
+		}
+		apiRouter.Use(metricsMiddleware)
+	}
+	apiRouter.Use(middleware.CORSMiddleware(corsAllowedOrigins))
+	if rateLimitRPS > 0 {
+		apiRouter.Use(middleware.RateLimitMiddleware(rateLimitRPS, rateLimitBurst))
+	}
+	apiRouter.Use(gzipMiddleware)
+	apiRouter.Use(languageMiddleware)
+	apiRouter.Use(serverTimingMiddleware)
+	graphqlSchema, err := newGraphQLSchema()
+	if err != nil {
+		log.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+	apiRouter.HandleFunc("/graphql", utils.ErrorHandler(graphqlHandler(graphqlSchema))).Methods(http.MethodGet, http.MethodPost)
+	apiRouter.HandleFunc("/languages", utils.ErrorHandler(handleLanguages)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/stats", utils.ErrorHandler(handleStats)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/stats/redirects", utils.ErrorHandler(handleRedirectStats)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/stats/terms", utils.ErrorHandler(handleTopTerms)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/search", utils.ErrorHandler(handleSearch))
+	apiRouter.HandleFunc("/search/content", utils.ErrorHandler(handleSearchContent))
+	apiRouter.HandleFunc("/search/federated", utils.ErrorHandler(handleFederatedSearch))
+	apiRouter.HandleFunc("/search/infobox", utils.ErrorHandler(handleSearchInfobox))
+	apiRouter.HandleFunc("/search/stream", utils.ErrorHandler(handleSearchStream))
+	apiRouter.HandleFunc("/ws/search", utils.ErrorHandler(handleSearchWebSocket)).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/article", utils.ErrorHandler(handleGetArticle))
+	apiRouter.HandleFunc("/article/mentions", utils.ErrorHandler(handleArticleMentions))
+	apiRouter.HandleFunc("/article/ambiguity", utils.ErrorHandler(handleArticleAmbiguity))
 	apiRouter.HandleFunc("/article/{id:[0-9]+}", utils.ErrorHandler(handleGetArticleByID))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/attribution", utils.ErrorHandler(handleArticleAttribution))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/backlinks", utils.ErrorHandler(handleBacklinks))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/categories", utils.ErrorHandler(handleArticleCategories))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/links/external", utils.ErrorHandler(handleExternalLinks))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/media", utils.ErrorHandler(handleArticleMedia))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/history", utils.ErrorHandler(handleArticleHistory))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/summary", utils.ErrorHandler(handleArticleSummary))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/sections", utils.ErrorHandler(handleArticleSections))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/language-links", utils.ErrorHandler(handleLanguageLinks))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/terms", utils.ErrorHandler(handleArticleTerms))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/related", utils.ErrorHandler(handleRelatedArticles))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/significance", utils.ErrorHandler(handleArticleSignificance))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/infobox", utils.ErrorHandler(handleArticleInfobox))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/wikitext", utils.ErrorHandler(handleGetArticleWikitext))
+	apiRouter.HandleFunc("/article/{id:[0-9]+}/plain", utils.ErrorHandler(handleGetArticlePlain))
+	apiRouter.HandleFunc("/diff", utils.ErrorHandler(handleDiffArticles))
+	apiRouter.HandleFunc("/category", utils.ErrorHandler(handleCategoryMembers))
+	apiRouter.HandleFunc("/category/ancestors", utils.ErrorHandler(handleCategoryAncestors))
+	apiRouter.HandleFunc("/categories/popular", utils.ErrorHandler(handlePopularCategories))
+	apiRouter.HandleFunc("/random", utils.ErrorHandler(handleRandomArticle))
+	apiRouter.HandleFunc("/random/batch", utils.ErrorHandler(handleRandomArticles))
+	apiRouter.HandleFunc("/articles", utils.ErrorHandler(handleListArticles))
+	apiRouter.HandleFunc("/articles/batch", utils.ErrorHandler(handleArticlesBatch)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/articles/people", utils.ErrorHandler(handleListPeople))
+	apiRouter.HandleFunc("/infobox-types", utils.ErrorHandler(handleInfoboxTypes))
+	apiRouter.HandleFunc("/namespaces", utils.ErrorHandler(handleNamespaces))
+	apiRouter.HandleFunc("/namespaces/registry", utils.ErrorHandler(handleNamespaceRegistry))
+	apiRouter.HandleFunc("/namespaces/counts", utils.ErrorHandler(handleNamespaceCounts))
+	apiRouter.HandleFunc("/lists", utils.ErrorHandler(handleListReadingLists)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/lists", utils.ErrorHandler(handleCreateReadingList)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/lists/{id}", utils.ErrorHandler(handleGetReadingList)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/lists/{id}", utils.ErrorHandler(handleDeleteReadingList)).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/lists/{id}/articles", utils.ErrorHandler(handleGetReadingList)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/lists/{id}/articles", utils.ErrorHandler(handleAddToReadingList)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/lists/{id}/articles", utils.ErrorHandler(handleRemoveFromReadingList)).Methods(http.MethodDelete)
+
+	// Admin endpoints (require ADMIN_TOKEN)
+	adminRouter := apiRouter.PathPrefix("/admin").Subrouter()
+	adminRouter.HandleFunc("/import-runs", requireAdmin(handleListImportRuns)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/import-runs/{runID}", requireAdmin(handleRollbackImportRun)).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/most-changed", requireAdmin(handleMostChanged)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/cleanup-index", requireAdmin(handleCleanupIndex)).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/duplicates", requireAdmin(handleDuplicates)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/stress-test", requireAdmin(handleStressTest)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/snapshot", requireAdmin(handleSnapshot)).Methods(http.MethodGet)
+
+	// Let CORS preflight requests reach the middleware on method-restricted routes
+	apiRouter.Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
 
 	// Serve static files (React app)
 	staticDir := "./static"
@@ -114,7 +683,79 @@ func handleRequests() {
 		}
 	})
 
-	log.Fatal(http.ListenAndServe(":9096", router))
+	return router
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	if err := requestWiki(r).Ping(ctx); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return writeJSON(w, r, map[string]interface{}{
+			"status": "degraded",
+			"error":  err.Error(),
+		})
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"status":      "ok",
+		"fts_version": requestWiki(r).FTSVersion(),
+	})
+}
+
+func handleStats(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	stats, err := requestWiki(r).Stats(r.URL.Query().Get("orphans") == "true")
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, stats)
+}
+
+func handleRedirectStats(w http.ResponseWriter, r *http.Request) error {
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	targets, err := requestWiki(r).TopRedirectTargets(limit)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"targets": targets,
+		"count":   len(targets),
+	})
+}
+
+func handleTopTerms(w http.ResponseWriter, r *http.Request) error {
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	terms, err := requestWiki(r).GetTopTerms(limit)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"terms": terms,
+		"count": len(terms),
+	})
 }
 
 func handleSearch(w http.ResponseWriter, r *http.Request) error {
@@ -131,53 +772,1463 @@ func handleSearch(w http.ResponseWriter, r *http.Request) error {
 			limit = parsed
 		}
 	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	opts := wikipedia.SearchOptions{
+		WithContent: r.URL.Query().Get("fields") == "content",
+		Category:    r.URL.Query().Get("category"),
+		Highlight:   r.URL.Query().Get("highlight") == "true",
+
+		ExcludeRedirects: r.URL.Query().Get("exclude_redirects") == "true",
+		RedirectsOnly:    r.URL.Query().Get("redirects_only") == "true",
+	}
+	if opts.ExcludeRedirects && opts.RedirectsOnly {
+		http.Error(w, "exclude_redirects and redirects_only cannot both be set", http.StatusBadRequest)
+		return nil
+	}
+	for param, bound := range map[string]*int{"min_words": &opts.MinWords, "max_words": &opts.MaxWords} {
+		if value := r.URL.Query().Get(param); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid "+param+" parameter", http.StatusBadRequest)
+				return nil
+			}
+			*bound = parsed
+		}
+	}
+
+	if r.URL.Query().Get("ranked") == "true" {
+		if opts != (wikipedia.SearchOptions{}) {
+			http.Error(w, "The ranked parameter cannot be combined with fields, category, min_words, max_words, highlight, exclude_redirects or redirects_only", http.StatusBadRequest)
+			return nil
+		}
+		return handleRankedSearch(w, r, query, limit, offset)
+	}
 
-	titles, err := wiki.SearchTitles(query, limit)
+	stop := TimingFromContext(r.Context()).Start("query")
+	totalCount, err := requestWiki(r).CountSearchResultsWithOptions(r.Context(), query, opts)
+	if err != nil {
+		stop()
+		return err
+	}
+	articles, err := requestWiki(r).SearchArticlesWithOptions(r.Context(), query, limit, offset, opts)
+	stop()
 	if err != nil {
 		return err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(map[string]interface{}{
-		"query":   query,
-		"results": titles,
-		"count":   len(titles),
+	var firstID int64
+	if len(articles) > 0 {
+		firstID = articles[0].ID
+	}
+	recordSearch(r, query, offset, firstID)
+
+	titles := make([]string, 0, len(articles))
+	for _, article := range articles {
+		titles = append(titles, article.Title)
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"query":       query,
+		"results":     titles,
+		"articles":    articles,
+		"count":       len(titles),
+		"total_count": totalCount,
+		"limit":       limit,
+		"offset":      offset,
 	})
 }
 
-func handleGetArticle(w http.ResponseWriter, r *http.Request) error {
-	title := r.URL.Query().Get("title")
-	if title == "" {
-		http.Error(w, "Missing title parameter", http.StatusBadRequest)
-		return nil
+// recordSearch logs a search and its first hit for /api/categories/popular.
+// Only the first page is recorded, so that paging through the results of a
+// search counts it once.
+func recordSearch(r *http.Request, query string, offset int, firstID int64) {
+	if offset != 0 {
+		return
+	}
+	if err := requestWiki(r).RecordSearch(r.Context(), query, firstID); err != nil {
+		log.Printf("Failed to record search: %v", err)
 	}
+}
 
-	article, err := wiki.GetArticle(title)
+// handleRankedSearch serves /api/search?ranked=true, whose articles carry the
+// BM25 score they are ordered by
+func handleRankedSearch(w http.ResponseWriter, r *http.Request, query string, limit, offset int) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	totalCount, err := requestWiki(r).CountSearchResults(r.Context(), query)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return nil
+		stop()
+		return err
+	}
+	articles, err := requestWiki(r).SearchArticlesRanked(r.Context(), query, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	var firstID int64
+	if len(articles) > 0 {
+		firstID = articles[0].ID
+	}
+	recordSearch(r, query, offset, firstID)
+
+	titles := make([]string, 0, len(articles))
+	for _, article := range articles {
+		titles = append(titles, article.Title)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(article)
+	return writeJSON(w, r, map[string]interface{}{
+		"query":       query,
+		"results":     titles,
+		"articles":    articles,
+		"count":       len(titles),
+		"total_count": totalCount,
+		"limit":       limit,
+		"offset":      offset,
+	})
 }
 
-func handleGetArticleByID(w http.ResponseWriter, r *http.Request) error {
-	vars := mux.Vars(r)
-	idStr := vars["id"]
+func handleSearchContent(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing query parameter 'q'", http.StatusBadRequest)
+		return nil
+	}
 
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).SearchContent(query, limit, offset)
+	stop()
 	if err != nil {
-		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"query":    query,
+		"articles": articles,
+		"count":    len(articles),
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+func handleSearchStream(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing query parameter 'q'", http.StatusBadRequest)
 		return nil
 	}
 
-	article, err := wiki.GetArticleByID(id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
 		return nil
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(article)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	count := 0
+	err := requestWiki(r).StreamSearchResults(r.Context(), query, limit, 0, func(result wikipedia.SearchResult) error {
+		if err := writeSSEEvent(w, result); err != nil {
+			return err
+		}
+		flusher.Flush()
+		count++
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent; a cancelled context means the client went away
+		if r.Context().Err() == nil {
+			log.Printf("Search stream error: %v", err)
+		}
+		return nil
+	}
+
+	if err := writeSSEEvent(w, map[string]interface{}{"done": true, "count": count}); err != nil {
+		return nil
+	}
+	flusher.Flush()
+	return nil
+}
+
+// writeSSEEvent writes v as a single Server-Sent Events data message
+func writeSSEEvent(w http.ResponseWriter, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+func handleFederatedSearch(w http.ResponseWriter, r *http.Request) error {
+	if federated == nil {
+		http.Error(w, "Federated search is not configured (set PEERS)", http.StatusNotFound)
+		return nil
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing query parameter 'q'", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	results, err := federated.FederatedSearch(r.Context(), query, limit)
+	stop()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return nil
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"query":   query,
+		"peers":   federated.Peers(),
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+func handleGetArticle(w http.ResponseWriter, r *http.Request) error {
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		http.Error(w, "Missing title parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	maxRedirects := wikipedia.DefaultMaxRedirects
+	if maxStr := r.URL.Query().Get("max_redirects"); maxStr != "" {
+		parsed, err := strconv.Atoi(maxStr)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid max_redirects parameter", http.StatusBadRequest)
+			return nil
+		}
+		maxRedirects = parsed
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	article, err := requestWiki(r).GetArticleByTitleContext(r.Context(), title, wikipedia.LookupOptions{
+		CaseInsensitive:  true,
+		FollowRedirects:  maxRedirects > 0,
+		MaxRedirectDepth: maxRedirects,
+	})
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if errors.Is(err, wikipedia.ErrRedirectLoop) || errors.Is(err, wikipedia.ErrTooManyRedirects) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, article)
+}
+
+func handleGetArticleByID(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	article, err := requestWiki(r).GetArticleByIDContext(r.Context(), id)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		// Fall back to the dump for articles that were not imported
+		stop = TimingFromContext(r.Context()).Start("dump")
+		fetched, fetchErr := requestWiki(r).FetchArticleFromDump(id)
+		stop()
+		if fetchErr != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return nil
+		}
+		article = fetched
+	} else if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, article)
+}
+
+func handleArticleMentions(w http.ResponseWriter, r *http.Request) error {
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		http.Error(w, "Missing title parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	results, err := requestWiki(r).SearchMentions(r.Context(), title, limit)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"title":   title,
+		"match":   "phrase",
+		"note":    "Includes every article containing the exact phrase, not only articles linking to it",
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+func handleArticleAmbiguity(w http.ResponseWriter, r *http.Request) error {
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		http.Error(w, "Missing title parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	report, err := requestWiki(r).GetAmbiguousTitle(r.Context(), title)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, report)
+}
+
+func handleArticlesBatch(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Request body must be a JSON object with an ids array", http.StatusBadRequest)
+		return nil
+	}
+	if len(body.IDs) > wikipedia.MaxBatchIDs {
+		http.Error(w, fmt.Sprintf("Too many IDs: %d requested, at most %d allowed per request", len(body.IDs), wikipedia.MaxBatchIDs), http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).GetArticlesByIDs(body.IDs)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"articles": articles,
+		"count":    len(articles),
+	})
+}
+
+func handleRandomArticle(w http.ResponseWriter, r *http.Request) error {
+	ns := 0
+	if nsStr := r.URL.Query().Get("ns"); nsStr != "" {
+		parsed, err := strconv.Atoi(nsStr)
+		if err != nil {
+			http.Error(w, "Invalid ns parameter", http.StatusBadRequest)
+			return nil
+		}
+		ns = parsed
+	}
+
+	var article *wikipedia.Article
+	var err error
+	stop := TimingFromContext(r.Context()).Start("query")
+	if seedStr := r.URL.Query().Get("seed"); seedStr != "" {
+		seed, parseErr := strconv.ParseInt(seedStr, 10, 64)
+		if parseErr != nil {
+			stop()
+			http.Error(w, "Invalid seed parameter", http.StatusBadRequest)
+			return nil
+		}
+		article, err = requestWiki(r).RandomArticleSeeded(ns, seed)
+	} else {
+		article, err = requestWiki(r).RandomArticle(ns)
+	}
+	stop()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+
+	return writeJSON(w, r, article)
+}
+
+func handleRandomArticles(w http.ResponseWriter, r *http.Request) error {
+	n := 10
+	if nStr := r.URL.Query().Get("n"); nStr != "" {
+		parsed, err := strconv.Atoi(nStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid n parameter", http.StatusBadRequest)
+			return nil
+		}
+		n = parsed
+	}
+	if n > wikipedia.MaxRandomArticles {
+		http.Error(w, fmt.Sprintf("n must be at most %d", wikipedia.MaxRandomArticles), http.StatusBadRequest)
+		return nil
+	}
+
+	ns := 0
+	if nsStr := r.URL.Query().Get("ns"); nsStr != "" {
+		parsed, err := strconv.Atoi(nsStr)
+		if err != nil {
+			http.Error(w, "Invalid ns parameter", http.StatusBadRequest)
+			return nil
+		}
+		ns = parsed
+	}
+
+	full := false
+	if fullStr := r.URL.Query().Get("full"); fullStr != "" {
+		parsed, err := strconv.ParseBool(fullStr)
+		if err != nil {
+			http.Error(w, "Invalid full parameter", http.StatusBadRequest)
+			return nil
+		}
+		full = parsed
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).GetRandomArticles(n, ns, full)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"articles": articles,
+		"count":    len(articles),
+	})
+}
+
+func handleArticleAttribution(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	attribution, err := requestWiki(r).GetAttribution(r.Context(), id)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, attribution)
+}
+
+func handleBacklinks(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil {
+			offset = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	article, err := requestWiki(r).GetArticleByIDContext(r.Context(), id)
+	if err != nil {
+		stop()
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	backlinks, err := requestWiki(r).GetBacklinks(article.Title, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	metas := make([]*wikipedia.ArticleMeta, 0, len(backlinks))
+	for _, backlink := range backlinks {
+		metas = append(metas, &wikipedia.ArticleMeta{
+			ID:        backlink.ID,
+			Title:     backlink.Title,
+			Namespace: backlink.Namespace,
+			Redirect:  backlink.Redirect,
+		})
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":       id,
+		"title":    article.Title,
+		"articles": metas,
+		"count":    len(metas),
+	})
+}
+
+func handleExternalLinks(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	links, err := requestWiki(r).GetArticleExternalLinks(id)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":             id,
+		"external_links": links,
+		"count":          len(links),
+	})
+}
+
+func handleArticleMedia(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	media, err := requestWiki(r).GetArticleMedia(id)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":    id,
+		"media": media,
+		"count": len(media),
+	})
+}
+
+func handleArticleTerms(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	terms, err := requestWiki(r).GetArticleTopTerms(id, limit)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":    id,
+		"terms": terms,
+		"count": len(terms),
+	})
+}
+
+func handleRelatedArticles(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).GetRelatedArticles(id, limit)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":       id,
+		"articles": articles,
+		"count":    len(articles),
+	})
+}
+
+func handleArticleSignificance(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	significance, err := requestWiki(r).GetSignificance(r.Context(), id)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) || errors.Is(err, wikipedia.ErrSignificanceNotComputed) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, significance)
+}
+
+func handleArticleCategories(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	categories, err := requestWiki(r).GetArticleCategories(id)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":         id,
+		"categories": categories,
+		"count":      len(categories),
+	})
+}
+
+func handleCategoryMembers(w http.ResponseWriter, r *http.Request) error {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing name parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil {
+			offset = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	members, err := requestWiki(r).GetCategoryMembers(name, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	metas := make([]*wikipedia.ArticleMeta, 0, len(members))
+	for _, member := range members {
+		metas = append(metas, &wikipedia.ArticleMeta{
+			ID:        member.ID,
+			Title:     member.Title,
+			Namespace: member.Namespace,
+			Redirect:  member.Redirect,
+		})
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"name":     name,
+		"articles": metas,
+		"count":    len(metas),
+	})
+}
+
+// maxCategoryDepth bounds the depth of /api/category/ancestors
+const maxCategoryDepth = 20
+
+func handleCategoryAncestors(w http.ResponseWriter, r *http.Request) error {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing name parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	depth := 5
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		if parsed, err := strconv.Atoi(depthStr); err == nil && parsed >= 0 {
+			depth = min(parsed, maxCategoryDepth)
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	ancestors, err := requestWiki(r).GetCategoryAncestors(r.Context(), name, depth)
+	stop()
+	if errors.Is(err, wikipedia.ErrCategoryDataNotAvailable) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"name":      name,
+		"depth":     depth,
+		"ancestors": ancestors,
+	})
+}
+
+func handlePopularCategories(w http.ResponseWriter, r *http.Request) error {
+	since := time.Now().AddDate(0, 0, -30)
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.DateOnly, sinceStr)
+		if err != nil {
+			parsed, err = time.Parse(time.RFC3339, sinceStr)
+		}
+		if err != nil {
+			http.Error(w, "Invalid since parameter, expected YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+			return nil
+		}
+		since = parsed
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = min(parsed, 500)
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	categories, err := requestWiki(r).GetPopularCategories(r.Context(), since, limit)
+	stop()
+	if errors.Is(err, wikipedia.ErrSearchLoggingDisabled) {
+		http.Error(w, err.Error()+" (set SEARCH_LOGGING=true)", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"since":      since.UTC().Format(time.RFC3339),
+		"limit":      limit,
+		"categories": categories,
+	})
+}
+
+func handleGetArticleWikitext(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "gzip" {
+		http.Error(w, "Invalid format parameter, expected gzip", http.StatusBadRequest)
+		return nil
+	}
+
+	var body []byte
+	stop := TimingFromContext(r.Context()).Start("query")
+	if format == "gzip" {
+		body, err = requestWiki(r).GetArticleContentGzip(id)
+	} else {
+		var article *wikipedia.Article
+		if article, err = requestWiki(r).GetArticleByIDContext(r.Context(), id); err == nil {
+			body = []byte(article.Content)
+		}
+	}
+	stop()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if format == "gzip" {
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func handleGetArticlePlain(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	var names []string
+	if sections := r.URL.Query().Get("sections"); sections != "" {
+		for _, name := range strings.Split(sections, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	article, err := requestWiki(r).GetArticleByIDContext(r.Context(), id)
+	stop()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+
+	content := article.Content
+	if len(names) > 0 {
+		if content, err = wikipedia.SectionWikitext(content, names); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return nil
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err = w.Write([]byte(wikipedia.StripWikitext(content)))
+	return err
+}
+
+func handleArticleInfobox(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	infobox, err := requestWiki(r).GetArticleInfobox(id)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, infobox)
+}
+
+func handleArticleSummary(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	maxChars := wikipedia.DefaultSummaryChars
+	if maxCharsStr := r.URL.Query().Get("max_chars"); maxCharsStr != "" {
+		parsed, err := strconv.Atoi(maxCharsStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid max_chars parameter", http.StatusBadRequest)
+			return nil
+		}
+		maxChars = parsed
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	summary, err := requestWiki(r).GetArticleSummary(id, maxChars)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, summary)
+}
+
+func handleArticleSections(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	sections, err := requestWiki(r).GetArticleSections(id)
+	stop()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":       id,
+		"sections": sections,
+		"count":    len(sections),
+	})
+}
+
+func handleArticleHistory(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	revisions, err := requestWiki(r).GetArticleHistory(r.Context(), id)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":        id,
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+func handleDiffArticles(w http.ResponseWriter, r *http.Request) error {
+	id1, err1 := strconv.ParseInt(r.URL.Query().Get("id1"), 10, 64)
+	id2, err2 := strconv.ParseInt(r.URL.Query().Get("id2"), 10, 64)
+	if err1 != nil || err2 != nil {
+		http.Error(w, "Invalid or missing article IDs 'id1' and 'id2'", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	diff, err := requestWiki(r).DiffArticles(id1, id2)
+	stop()
+	if errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id1":  id1,
+		"id2":  id2,
+		"diff": diff,
+	})
+}
+
+func handleLanguageLinks(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return nil
+	}
+
+	// A single target language returns just the foreign title; lang selects
+	// the edition the article is looked up in
+	if lang := r.URL.Query().Get("target"); lang != "" {
+		stop := TimingFromContext(r.Context()).Start("query")
+		title, err := requestWiki(r).GetArticleInLanguage(r.Context(), id, lang)
+		stop()
+		if errors.Is(err, wikipedia.ErrLanguageLinkNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, r, map[string]interface{}{
+			"id":    id,
+			"lang":  lang,
+			"title": title,
+		})
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	links, err := requestWiki(r).GetLanguageLinks(r.Context(), id)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":             id,
+		"language_links": links,
+		"count":          len(links),
+	})
+}
+
+func handleSearchInfobox(w http.ResponseWriter, r *http.Request) error {
+	field := r.URL.Query().Get("field")
+	value := r.URL.Query().Get("value")
+	if field == "" || value == "" {
+		http.Error(w, "Missing field or value parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	op := r.URL.Query().Get("op")
+	if op == "" {
+		op = "eq"
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).SearchByInfoboxField(r.Context(), field, op, value, limit)
+	stop()
+	if errors.Is(err, wikipedia.ErrInvalidInfoboxQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"field":    field,
+		"op":       op,
+		"value":    value,
+		"articles": articles,
+		"count":    len(articles),
+	})
+}
+
+func handleListArticles(w http.ResponseWriter, r *http.Request) error {
+	nsStr := r.URL.Query().Get("ns")
+	infoboxType := r.URL.Query().Get("infobox_type")
+	if nsStr == "" && infoboxType == "" {
+		http.Error(w, "Missing ns or infobox_type parameter", http.StatusBadRequest)
+		return nil
+	}
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy != "" && sortBy != "id" && sortBy != "significance_desc" {
+		http.Error(w, "Invalid sort parameter, expected id or significance_desc", http.StatusBadRequest)
+		return nil
+	}
+	if sortBy == "significance_desc" && infoboxType != "" {
+		http.Error(w, "sort=significance_desc cannot be combined with infobox_type", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil {
+			offset = parsed
+		}
+	}
+
+	if infoboxType == "" {
+		ns, err := strconv.Atoi(nsStr)
+		if err != nil {
+			http.Error(w, "Invalid ns parameter", http.StatusBadRequest)
+			return nil
+		}
+		if sortBy == "significance_desc" {
+			return listArticlesBySignificance(w, r, ns, limit, offset)
+		}
+		return listArticlesByNamespace(w, r, ns, limit, offset)
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).GetArticlesByInfoboxType(r.Context(), infoboxType, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"infobox_type": infoboxType,
+		"articles":     articles,
+		"count":        len(articles),
+	})
+}
+
+// listArticlesByNamespace writes a page of the articles in a namespace, without
+// their content
+func listArticlesByNamespace(w http.ResponseWriter, r *http.Request, ns, limit, offset int) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	total, err := requestWiki(r).CountArticlesByNamespace(ns)
+	if err != nil {
+		stop()
+		return err
+	}
+	articles, err := requestWiki(r).GetArticlesByNamespace(ns, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	metas := make([]*wikipedia.ArticleMeta, 0, len(articles))
+	for _, article := range articles {
+		metas = append(metas, &wikipedia.ArticleMeta{
+			ID:        article.ID,
+			Title:     article.Title,
+			Namespace: article.Namespace,
+			Redirect:  article.Redirect,
+		})
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"namespace": ns,
+		"total":     total,
+		"articles":  metas,
+		"count":     len(metas),
+	})
+}
+
+// listArticlesBySignificance is listArticlesByNamespace for
+// sort=significance_desc
+func listArticlesBySignificance(w http.ResponseWriter, r *http.Request, ns, limit, offset int) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	total, err := requestWiki(r).CountArticlesByNamespace(ns)
+	if err != nil {
+		stop()
+		return err
+	}
+	articles, err := requestWiki(r).GetArticlesBySignificance(r.Context(), ns, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"namespace": ns,
+		"total":     total,
+		"articles":  articles,
+		"count":     len(articles),
+	})
+}
+
+func handleListPeople(w http.ResponseWriter, r *http.Request) error {
+	birthYear, err := strconv.Atoi(r.URL.Query().Get("birth_year"))
+	if err != nil {
+		http.Error(w, "Missing or invalid birth_year parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil {
+			offset = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	people, err := requestWiki(r).GetPeopleByBirthYear(r.Context(), birthYear, limit, offset)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"birth_year": birthYear,
+		"articles":   people,
+		"count":      len(people),
+	})
+}
+
+func handleInfoboxTypes(w http.ResponseWriter, r *http.Request) error {
+	top := 20
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		if parsed, err := strconv.Atoi(topStr); err == nil {
+			top = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	types, err := requestWiki(r).ListInfoboxTypes(r.Context(), top)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"types": types,
+	})
+}
+
+func handleNamespaces(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	namespaces, err := requestWiki(r).GetNamespaces()
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, namespaces)
+}
+
+func handleNamespaceRegistry(w http.ResponseWriter, r *http.Request) error {
+	return writeJSON(w, r, wikipedia.NamespaceRegistry)
+}
+
+func handleNamespaceCounts(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	namespaces, err := requestWiki(r).ListNamespaces(r.Context())
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"namespaces": namespaces,
+	})
+}
+
+// requireAdmin wraps an admin handler with a bearer token check against ADMIN_TOKEN
+func requireAdmin(f func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	handler := utils.ErrorHandler(f)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			http.Error(w, "Admin endpoints are disabled (set ADMIN_TOKEN)", http.StatusForbidden)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func handleListImportRuns(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	runs, err := requestWiki(r).ListImportRuns(r.Context())
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+func handleRollbackImportRun(w http.ResponseWriter, r *http.Request) error {
+	runID := mux.Vars(r)["runID"]
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	result, err := requestWiki(r).RollbackImportRun(r.Context(), runID)
+	stop()
+	if errors.Is(err, wikipedia.ErrImportRunNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, result)
+}
+
+func handleDuplicates(w http.ResponseWriter, r *http.Request) error {
+	threshold := 0.8
+	if thresholdStr := r.URL.Query().Get("threshold"); thresholdStr != "" {
+		parsed, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil {
+			http.Error(w, "Invalid threshold parameter", http.StatusBadRequest)
+			return nil
+		}
+		threshold = parsed
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	pairs, err := requestWiki(r).GetDuplicateContent(r.Context(), threshold, limit)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"threshold": threshold,
+		"pairs":     pairs,
+		"count":     len(pairs),
+	})
+}
+
+func handleStressTest(w http.ResponseWriter, r *http.Request) error {
+	duration := 10 * time.Second
+	if durationStr := r.URL.Query().Get("duration"); durationStr != "" {
+		parsed, err := time.ParseDuration(durationStr)
+		if err != nil || parsed <= 0 || parsed > maxStressDuration {
+			http.Error(w, fmt.Sprintf("Invalid duration parameter (max %s)", maxStressDuration), http.StatusBadRequest)
+			return nil
+		}
+		duration = parsed
+	}
+
+	concurrency := 10
+	if concurrencyStr := r.URL.Query().Get("concurrency"); concurrencyStr != "" {
+		parsed, err := strconv.Atoi(concurrencyStr)
+		if err != nil || parsed <= 0 || parsed > maxStressConcurrency {
+			http.Error(w, fmt.Sprintf("Invalid concurrency parameter (max %d)", maxStressConcurrency), http.StatusBadRequest)
+			return nil
+		}
+		concurrency = parsed
+	}
+
+	result, err := requestWiki(r).RunStressTest(r.Context(), duration, concurrency)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, result)
+}
+
+func handleMostChanged(w http.ResponseWriter, r *http.Request) error {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	changes, err := requestWiki(r).GetMostChangedArticles(r.Context(), limit)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"articles": changes,
+		"count":    len(changes),
+	})
+}
+
+func handleSnapshot(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	path, err := requestWiki(r).Snapshot()
+	stop()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Every request takes a new snapshot, so ranges of an earlier download
+	// cannot be resumed from it
+	disableGzip(r)
+	w.Header().Set("Content-Type", "application/x-sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="wikipedia.db"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func handleCleanupIndex(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	deleted, err := requestWiki(r).CleanupOrphanedIndexEntries(r.Context())
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"deleted": deleted,
+	})
 }
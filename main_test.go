@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	server := newTestServer(t,
+		testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."},
+		testPage{ID: 2, Title: "Chemistry", Text: "Chemistry is a physical science."},
+	)
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	if status := getJSON(t, server.URL+"/api/health", &health); status != http.StatusOK || health.Status != "ok" {
+		t.Errorf("health: got status %d and %q, want 200 and ok", status, health.Status)
+	}
+
+	var search struct {
+		Results []string `json:"results"`
+	}
+	if status := getJSON(t, server.URL+"/api/search?q=Physics", &search); status != http.StatusOK {
+		t.Fatalf("search: got status %d, want 200", status)
+	}
+	if len(search.Results) != 1 || search.Results[0] != "Physics" {
+		t.Errorf("search: got %q, want [Physics]", search.Results)
+	}
+
+	if status := getJSON(t, server.URL+"/api/search?q=Physics&lang=xx", nil); status != http.StatusNotFound {
+		t.Errorf("unknown language: got status %d, want 404", status)
+	}
+}
+
+func TestHealthAfterClose(t *testing.T) {
+	server := newTestServer(t, testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."})
+	if err := wiki.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/api/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || health.Status != "degraded" || health.Error == "" {
+		t.Errorf("got status %d and %+v, want 503 and degraded with an error", resp.StatusCode, health)
+	}
+}
+
+func TestArticleAttributionStatus(t *testing.T) {
+	server := newTestServer(t, testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."})
+
+	if status := getJSON(t, server.URL+"/api/article/1/attribution", nil); status != http.StatusOK {
+		t.Errorf("existing article: got status %d, want 200", status)
+	}
+	if status := getJSON(t, server.URL+"/api/article/99/attribution", nil); status != http.StatusNotFound {
+		t.Errorf("missing article: got status %d, want 404", status)
+	}
+
+	// A failing database is not reported as a missing article
+	if err := wiki.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if status := getJSON(t, server.URL+"/api/article/1/attribution", nil); status != http.StatusInternalServerError {
+		t.Errorf("closed database: got status %d, want 500", status)
+	}
+}
+
+func TestGetArticleByID(t *testing.T) {
+	server := newFixtureServer(t)
+
+	var article struct {
+		Title string `json:"title"`
+	}
+	if status := getJSON(t, server.URL+"/api/article/12", &article); status != http.StatusOK || article.Title != "Article 12" {
+		t.Errorf("imported article: got status %d and %q, want 200 and Article 12", status, article.Title)
+	}
+
+	// Categories are not imported and are read from the dump
+	if status := getJSON(t, server.URL+"/api/article/97", &article); status != http.StatusOK || article.Title != "Category:Group 1" {
+		t.Errorf("article of the dump: got status %d and %q, want 200 and Category:Group 1", status, article.Title)
+	}
+
+	if status := getJSON(t, server.URL+"/api/article/1000", nil); status != http.StatusNotFound {
+		t.Errorf("missing article: got status %d, want 404", status)
+	}
+
+	// Other errors are not hidden by the dump fallback
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/article/3", nil).WithContext(ctx))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("cancelled request: got status %d, want 500", rec.Code)
+	}
+}
+
+func TestGetArticleStatus(t *testing.T) {
+	server := newTestServer(t,
+		testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."},
+		testPage{ID: 2, Title: "Loop A", Redirect: "Loop B", Text: "#REDIRECT [[Loop B]]"},
+		testPage{ID: 3, Title: "Loop B", Redirect: "Loop A", Text: "#REDIRECT [[Loop A]]"},
+	)
+
+	if status := getJSON(t, server.URL+"/api/article?title=physics", nil); status != http.StatusOK {
+		t.Errorf("existing article: got status %d, want 200", status)
+	}
+	if status := getJSON(t, server.URL+"/api/article?title=Chemistry", nil); status != http.StatusNotFound {
+		t.Errorf("missing article: got status %d, want 404", status)
+	}
+	if status := getJSON(t, server.URL+"/api/article?title=Loop+A", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("circular redirect: got status %d, want 422", status)
+	}
+	if status := getJSON(t, server.URL+"/api/article?title=Loop+A&max_redirects=1", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("too many redirects: got status %d, want 422", status)
+	}
+
+	// A failing query is not reported as a missing article
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/article?title=Loop+B&max_redirects=0", nil).WithContext(ctx))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("cancelled request: got status %d, want 500", rec.Code)
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// limiterTTL is how long an idle client's limiter is kept
+	limiterTTL = 10 * time.Minute
+	// limiterSweepInterval is how often idle limiters are evicted
+	limiterSweepInterval = time.Minute
+)
+
+// clientLimiter is the token bucket of one client IP
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64
+}
+
+// RateLimitMiddleware allows each client IP rps requests per second with
+// bursts of up to burst requests. Requests over the limit get a 429 response
+// with a Retry-After header. Limiters idle for 10 minutes are evicted.
+func RateLimitMiddleware(rps float64, burst int) mux.MiddlewareFunc {
+	if burst < 1 {
+		burst = 1
+	}
+
+	var limiters sync.Map
+	go func() {
+		for range time.Tick(limiterSweepInterval) {
+			cutoff := time.Now().Add(-limiterTTL).UnixNano()
+			limiters.Range(func(key, value interface{}) bool {
+				if value.(*clientLimiter).lastSeen.Load() < cutoff {
+					limiters.Delete(key)
+				}
+				return true
+			})
+		}
+	}()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+			value, ok := limiters.Load(ip)
+			if !ok {
+				value, _ = limiters.LoadOrStore(ip, &clientLimiter{limiter: rate.NewLimiter(rate.Limit(rps), burst)})
+			}
+			client := value.(*clientLimiter)
+			client.lastSeen.Store(time.Now().UnixNano())
+
+			reservation := client.limiter.Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the IP of the connection the request came from. Headers
+// such as X-Forwarded-For are ignored since clients can forge them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fabriceboyer/wikipedia_sqlite/wikipedia"
+	"github.com/gorilla/mux"
+)
+
+// handleReadingListError reports the not-found errors of reading list
+// operations and returns other errors unchanged
+func handleReadingListError(w http.ResponseWriter, err error) error {
+	if errors.Is(err, wikipedia.ErrReadingListNotFound) || errors.Is(err, wikipedia.ErrArticleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil
+	}
+	return err
+}
+
+func handleListReadingLists(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	lists, err := requestWiki(r).ListLists(r.Context())
+	stop()
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"lists": lists,
+		"count": len(lists),
+	})
+}
+
+func handleCreateReadingList(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		http.Error(w, "Request body must be a JSON object with a name", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	list, err := requestWiki(r).CreateList(r.Context(), body.Name)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return writeJSON(w, r, list)
+}
+
+func handleGetReadingList(w http.ResponseWriter, r *http.Request) error {
+	listID := mux.Vars(r)["id"]
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	articles, err := requestWiki(r).GetList(r.Context(), listID)
+	stop()
+	if err != nil {
+		return handleReadingListError(w, err)
+	}
+
+	return writeJSON(w, r, map[string]interface{}{
+		"id":       listID,
+		"articles": articles,
+		"count":    len(articles),
+	})
+}
+
+func handleDeleteReadingList(w http.ResponseWriter, r *http.Request) error {
+	stop := TimingFromContext(r.Context()).Start("query")
+	err := requestWiki(r).DeleteList(r.Context(), mux.Vars(r)["id"])
+	stop()
+	if err != nil {
+		return handleReadingListError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func handleAddToReadingList(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		ArticleID int64 `json:"article_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ArticleID == 0 {
+		http.Error(w, "Request body must be a JSON object with an article_id", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	err := requestWiki(r).AddToList(r.Context(), mux.Vars(r)["id"], body.ArticleID)
+	stop()
+	if err != nil {
+		return handleReadingListError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func handleRemoveFromReadingList(w http.ResponseWriter, r *http.Request) error {
+	articleID, err := strconv.ParseInt(r.URL.Query().Get("article_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Missing or invalid article_id parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	stop := TimingFromContext(r.Context()).Start("query")
+	err = requestWiki(r).RemoveFromList(r.Context(), mux.Vars(r)["id"], articleID)
+	stop()
+	if err != nil {
+		return handleReadingListError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
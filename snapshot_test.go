@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	_, dir := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."},
+		testPage{ID: 2, Title: "Chemistry", Text: "Chemistry is a physical science."},
+	)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+	prevToken := adminToken
+	adminToken = "secret"
+	t.Cleanup(func() { adminToken = prevToken })
+
+	resp, err := http.Get(server.URL + "/api/admin/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without a token: got status %d, want 401", resp.StatusCode)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/admin/snapshot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	// Setting the header disables the transparent decompression of the client
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d: %s", resp.StatusCode, body)
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+		t.Errorf("got Content-Encoding %q, want an uncompressed snapshot", encoding)
+	}
+	if length := resp.Header.Get("Content-Length"); length != strconv.Itoa(len(body)) {
+		t.Errorf("got Content-Length %q, want the %d bytes of the file", length, len(body))
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var articles int
+	if err := db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&articles); err != nil {
+		t.Fatalf("failed to read the snapshot: %v", err)
+	}
+	if articles != 2 {
+		t.Errorf("the snapshot holds %d articles, want 2", articles)
+	}
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, "snapshot-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Errorf("snapshot files left next to the database: %v", leftovers)
+	}
+}
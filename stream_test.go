@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fabriceboyer/wikipedia_sqlite/wikipedia"
+)
+
+// flushRecorder is a ResponseRecorder counting the events written before each
+// flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []int
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed = append(r.flushed, strings.Count(r.Body.String(), "data: "))
+	r.ResponseRecorder.Flush()
+}
+
+// readSSEEvents returns the data of the Server-Sent Events of body
+func readSSEEvents(t *testing.T, body io.Reader) []string {
+	t.Helper()
+
+	var events []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			t.Fatalf("unexpected line %q", line)
+		}
+		events = append(events, data)
+	}
+	return events
+}
+
+func TestSearchStream(t *testing.T) {
+	newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."},
+		testPage{ID: 2, Title: "Physical chemistry", Text: "Physical chemistry is a branch of chemistry."},
+		testPage{ID: 3, Title: "Biology", Text: "Biology is a natural science."},
+	)
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search/stream?q=physic*", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("got Content-Type %q, want text/event-stream", contentType)
+	}
+
+	events := readSSEEvents(t, rec.Body)
+	if len(events) != 3 {
+		t.Fatalf("got events %q, want 2 results and done", events)
+	}
+
+	titles := make(map[string]bool)
+	for _, data := range events[:2] {
+		var result wikipedia.SearchResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			t.Fatalf("failed to decode event %q: %v", data, err)
+		}
+		titles[result.Title] = true
+	}
+	if !titles["Physics"] || !titles["Physical chemistry"] {
+		t.Errorf("got results %q, want Physics and Physical chemistry", events[:2])
+	}
+
+	var done struct {
+		Done  bool `json:"done"`
+		Count int  `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(events[2]), &done); err != nil || !done.Done || done.Count != 2 {
+		t.Errorf("got last event %q, want done with a count of 2", events[2])
+	}
+
+	// Every event is flushed as soon as it is written
+	if want := []int{1, 2, 3}; !slices.Equal(rec.flushed, want) {
+		t.Errorf("got event counts %v at each flush, want %v", rec.flushed, want)
+	}
+
+	missing := httptest.NewRecorder()
+	newRouter().ServeHTTP(missing, httptest.NewRequest(http.MethodGet, "/api/search/stream", nil))
+	if missing.Code != http.StatusBadRequest {
+		t.Errorf("without q: got status %d, want 400", missing.Code)
+	}
+}
+
+func TestSearchStreamManyResults(t *testing.T) {
+	pages := make([]testPage, 0, 50)
+	for i := 1; i <= 50; i++ {
+		pages = append(pages, testPage{ID: i, Title: fmt.Sprintf("Physics %d", i), Text: "A branch of physics."})
+	}
+	newTestWiki(t, pages...)
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search/stream?q=physics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+
+	events := readSSEEvents(t, rec.Body)
+	if len(events) != 51 {
+		t.Fatalf("got %d events, want 50 results and done", len(events))
+	}
+	ids := make(map[int64]bool)
+	for _, data := range events[:50] {
+		var result wikipedia.SearchResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			t.Fatalf("failed to decode event %q: %v", data, err)
+		}
+		ids[result.ID] = true
+	}
+	if len(ids) != 50 {
+		t.Errorf("got %d distinct results, want 50", len(ids))
+	}
+
+	var done struct {
+		Done  bool `json:"done"`
+		Count int  `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(events[50]), &done); err != nil || !done.Done || done.Count != 50 {
+		t.Errorf("got last event %q, want done with a count of 50", events[50])
+	}
+	if len(rec.flushed) != 51 {
+		t.Errorf("got %d flushes, want one per event", len(rec.flushed))
+	}
+}
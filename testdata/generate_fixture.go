@@ -0,0 +1,153 @@
+//go:build generate
+
+// generate_fixture writes a small bzip2 multistream dump and its index to
+// testdata, for trying the import and the API without downloading a real
+// dump. Run it from the repository root, with the bzip2 command installed:
+//
+//	go run -tags generate ./testdata/generate_fixture.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// pagesPerStream is the number of pages of each bzip2 stream, as in real
+// multistream dumps where it is 100
+const pagesPerStream = 10
+
+const header = `<mediawiki xmlns="http://www.mediawiki.org/xml/export-0.10/" version="0.10" xml:lang="en">
+  <siteinfo>
+    <sitename>Wikipedia</sitename>
+    <dbname>fixturewiki</dbname>
+    <namespaces>
+      <namespace key="0" case="first-letter" />
+      <namespace key="10" case="first-letter">Template</namespace>
+      <namespace key="14" case="first-letter">Category</namespace>
+    </namespaces>
+  </siteinfo>
+`
+
+const footer = "</mediawiki>\n"
+
+type fixturePage struct {
+	id       int
+	title    string
+	ns       int
+	redirect string
+	text     string
+}
+
+// fixturePages returns 100 pages: 90 articles, 5 of them redirects, 5
+// templates and 5 categories
+func fixturePages() []fixturePage {
+	var pages []fixturePage
+	for id := 1; id <= 100; id++ {
+		page := fixturePage{id: id}
+		category := fmt.Sprintf("Group %d", id%5)
+		switch {
+		case id > 95:
+			page.ns = 14
+			page.title = fmt.Sprintf("Category:Group %d", id-96)
+			page.text = "Articles of the group.\n[[Category:Groups]]"
+		case id > 90:
+			page.ns = 10
+			page.title = fmt.Sprintf("Template:Box %d", id-91)
+			page.text = "{{{1}}} <noinclude>[[Category:Templates]]</noinclude>"
+		case id%18 == 0:
+			page.title = fmt.Sprintf("Alias %d", id)
+			page.redirect = fmt.Sprintf("Article %d", id-1)
+			page.text = fmt.Sprintf("#REDIRECT [[%s]]", page.redirect)
+		default:
+			page.title = fmt.Sprintf("Article %d", id)
+			page.text = fmt.Sprintf(`{{Infobox item
+| name = Article %d
+| number = %d
+}}
+'''Article %d''' is a fixture article about topic %d, linking to [[Article %d]].
+
+== History ==
+It was generated for testing as part of [[:Category:%s|%s]].
+
+== See also ==
+* [[Article %d]]
+[[Category:%s]]`, id, id, id, id%7, id%90+1, category, category, (id+1)%90+1, category)
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// writePage appends the XML of page to buf
+func writePage(buf *bytes.Buffer, page fixturePage) {
+	fmt.Fprintf(buf, "  <page>\n    <title>%s</title>\n    <ns>%d</ns>\n    <id>%d</id>\n", html.EscapeString(page.title), page.ns, page.id)
+	if page.redirect != "" {
+		fmt.Fprintf(buf, "    <redirect title=\"%s\" />\n", html.EscapeString(page.redirect))
+	}
+	fmt.Fprintf(buf, `    <revision>
+      <id>%d</id>
+      <timestamp>2024-01-%02dT00:00:00Z</timestamp>
+      <contributor><username>Fixture</username><id>1</id></contributor>
+      <model>wikitext</model>
+      <format>text/x-wiki</format>
+      <text xml:space="preserve">%s</text>
+    </revision>
+  </page>
+`, 1000+page.id, page.id%28+1, html.EscapeString(page.text))
+}
+
+// compress returns data as a single bzip2 stream, written by the bzip2
+// command since the standard library only decompresses bzip2
+func compress(data []byte) []byte {
+	cmd := exec.Command("bzip2", "-c", "-9")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		log.Fatalf("bzip2 failed: %v", err)
+	}
+	return out
+}
+
+func main() {
+	out := flag.String("out", "testdata", "Directory the fixture files are written to")
+	flag.Parse()
+
+	// Like real dumps, the header, each group of pages and the footer are
+	// separate streams, and the index gives the offset of the stream of each page
+	var articles, index bytes.Buffer
+	articles.Write(compress([]byte(header)))
+	pages := fixturePages()
+	for start := 0; start < len(pages); start += pagesPerStream {
+		end := start + pagesPerStream
+		if end > len(pages) {
+			end = len(pages)
+		}
+		offset := articles.Len()
+		var stream bytes.Buffer
+		for _, page := range pages[start:end] {
+			writePage(&stream, page)
+			fmt.Fprintf(&index, "%d:%d:%s\n", offset, page.id, page.title)
+		}
+		articles.Write(compress(stream.Bytes()))
+	}
+	articles.Write(compress([]byte(footer)))
+
+	files := map[string][]byte{
+		"fixture-articles.xml.bz2": articles.Bytes(),
+		"fixture-index.txt.bz2":    compress(index.Bytes()),
+	}
+	for name, data := range files {
+		path := filepath.Join(*out, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Wrote %s (%d bytes)", path, len(data))
+	}
+}
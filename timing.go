@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing collects the durations of named request phases for the
+// Server-Timing response header
+type Timing struct {
+	mu      sync.Mutex
+	metrics []timingMetric
+}
+
+type timingMetric struct {
+	name     string
+	duration time.Duration
+}
+
+type timingKey struct{}
+
+// WithTiming returns a copy of ctx carrying a new Timing
+func WithTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingKey{}, &Timing{})
+}
+
+// TimingFromContext returns the Timing stored in ctx, or nil. A nil Timing is
+// safe to use and records nothing.
+func TimingFromContext(ctx context.Context) *Timing {
+	t, _ := ctx.Value(timingKey{}).(*Timing)
+	return t
+}
+
+// Start begins timing the named phase and returns a function that stops it
+func (t *Timing) Start(name string) func() {
+	if t == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.metrics = append(t.metrics, timingMetric{name: name, duration: time.Since(start)})
+	}
+}
+
+// Header formats the recorded phases as a Server-Timing header value
+func (t *Timing) Header() string {
+	if t == nil {
+		return ""
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parts := make([]string, 0, len(t.metrics))
+	for _, m := range t.metrics {
+		parts = append(parts, fmt.Sprintf("%s;dur=%.3f", m.name, float64(m.duration.Microseconds())/1000))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// timingResponseWriter writes the Server-Timing header just before the
+// response headers are sent
+type timingResponseWriter struct {
+	http.ResponseWriter
+	timing      *Timing
+	wroteHeader bool
+}
+
+func (tw *timingResponseWriter) WriteHeader(status int) {
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		if header := tw.timing.Header(); header != "" {
+			tw.Header().Set("Server-Timing", header)
+		}
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timingResponseWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+func (tw *timingResponseWriter) Flush() {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades take over the connection; the Server-Timing
+// header is not sent for them
+func (tw *timingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := tw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	tw.wroteHeader = true
+	return h.Hijack()
+}
+
+// serverTimingMiddleware attaches a Timing to each request and reports the
+// db-open, query and marshal phases in the Server-Timing header; it runs after
+// languageMiddleware so that the selected Wiki is opened
+func serverTimingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithTiming(r.Context())
+		timing := TimingFromContext(ctx)
+
+		stop := timing.Start("db-open")
+		_ = requestWiki(r).Open()
+		stop()
+
+		next.ServeHTTP(&timingResponseWriter{ResponseWriter: w, timing: timing}, r.WithContext(ctx))
+	})
+}
+
+// writeJSON marshals v as the JSON response, timing the marshal phase
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	stop := TimingFromContext(r.Context()).Start("marshal")
+	body, err := json.Marshal(v)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestServerTiming(t *testing.T) {
+	server := newTestServer(t, testPage{ID: 1, Title: "Physics", Text: "Physics is a natural science."})
+
+	resp, err := http.Get(server.URL + "/api/search?q=Physics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+
+	header := resp.Header.Get("Server-Timing")
+	durations := make(map[string]float64)
+	for _, entry := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		dur, ok := strings.CutPrefix(params, "dur=")
+		if !ok {
+			t.Fatalf("entry %q of Server-Timing %q has no dur", entry, header)
+		}
+		value, err := strconv.ParseFloat(dur, 64)
+		if err != nil || value < 0 {
+			t.Fatalf("entry %q of Server-Timing %q has an invalid dur", entry, header)
+		}
+		durations[name] = value
+	}
+	for _, name := range []string{"db-open", "query", "marshal"} {
+		if _, ok := durations[name]; !ok {
+			t.Errorf("Server-Timing %q has no %s entry", header, name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// defaultWSMaxConnections is the default of WS_MAX_CONNECTIONS
+	defaultWSMaxConnections = 100
+	// wsIdleTimeout closes search sessions that send no message for this long
+	wsIdleTimeout = 60 * time.Second
+	// wsDefaultLimit is the number of titles returned when a message has no limit
+	wsDefaultLimit = 10
+)
+
+// wsConnections holds a token for each open search session, bounding them to
+// its capacity
+var wsConnections chan struct{}
+
+var wsUpgrader = websocket.Upgrader{CheckOrigin: wsCheckOrigin}
+
+// wsCheckOrigin accepts the upgrade requests of the origins allowed by
+// CORS_ALLOWED_ORIGINS, and those without an Origin header
+func wsCheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range corsAllowedOrigins {
+		allowed = strings.TrimRight(strings.TrimSpace(allowed), "/")
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+type wsSearchRequest struct {
+	Query string `json:"q"`
+	Limit int    `json:"limit"`
+}
+
+type wsSearchResponse struct {
+	Query   string   `json:"q"`
+	Results []string `json:"results"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// handleSearchWebSocket runs a title search for every {"q":..., "limit":...}
+// message of the session and answers with {"q":..., "results":[...]}, so
+// search-as-you-type clients avoid a request per keystroke
+func handleSearchWebSocket(w http.ResponseWriter, r *http.Request) error {
+	select {
+	case wsConnections <- struct{}{}:
+		defer func() { <-wsConnections }()
+	default:
+		http.Error(w, "Too many WebSocket connections", http.StatusServiceUnavailable)
+		return nil
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered with an HTTP error
+		return nil
+	}
+	defer conn.Close()
+
+	selected := requestWiki(r)
+	for {
+		conn.SetReadDeadline(time.Now().Add(wsIdleTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			// Closed by the client or idle for too long
+			return nil
+		}
+
+		var request wsSearchRequest
+		response := wsSearchResponse{Results: []string{}}
+		if err := json.Unmarshal(message, &request); err != nil {
+			response.Error = "Invalid message: expected {\"q\":\"...\",\"limit\":10}"
+		} else {
+			response.Query = request.Query
+			if request.Limit <= 0 {
+				request.Limit = wsDefaultLimit
+			}
+			if strings.TrimSpace(request.Query) != "" {
+				results, err := selected.SearchTitlesContext(r.Context(), request.Query, request.Limit, 0)
+				if err != nil {
+					response.Error = err.Error()
+				} else if results != nil {
+					response.Results = results
+				}
+			}
+		}
+
+		if err := conn.WriteJSON(response); err != nil {
+			return nil
+		}
+	}
+}
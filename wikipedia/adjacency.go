@@ -0,0 +1,84 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+)
+
+// BuildAdjacencyList returns the link graph of the articles: the IDs of the
+// articles each article links to, resolved from the target titles of the
+// links table. Links to missing articles are left out, and links to a
+// redirect point to the redirect itself. The graph is loaded with a single
+// query and cached until links are stored or removed by this Wiki; the map
+// is shared by every caller and must not be modified.
+func (w *Wiki) BuildAdjacencyList(ctx context.Context) (map[int64][]int64, error) {
+	w.adjMu.RLock()
+	adjList := w.adjList
+	w.adjMu.RUnlock()
+	if adjList != nil {
+		return adjList, nil
+	}
+
+	return w.loadAdjacencyList(ctx)
+}
+
+// RefreshAdjacencyList reloads the cached link graph of BuildAdjacencyList,
+// e.g. after another process imported articles into the database
+func (w *Wiki) RefreshAdjacencyList(ctx context.Context) error {
+	_, err := w.loadAdjacencyList(ctx)
+	return err
+}
+
+// loadAdjacencyList queries the link graph and caches it, unless links
+// changed during the query
+func (w *Wiki) loadAdjacencyList(ctx context.Context) (map[int64][]int64, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.adjMu.RLock()
+	generation := w.adjGeneration
+	w.adjMu.RUnlock()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT l.source_id, a.id
+		FROM links l
+		JOIN articles a ON a.title = l.target_title
+		ORDER BY l.source_id, a.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	adjList := make(map[int64][]int64)
+	for rows.Next() {
+		var from, to int64
+		if err := rows.Scan(&from, &to); err != nil {
+			return nil, fmt.Errorf("failed to read links: %w", err)
+		}
+		adjList[from] = append(adjList[from], to)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+
+	w.adjMu.Lock()
+	if w.adjGeneration == generation {
+		w.adjList = adjList
+	}
+	w.adjMu.Unlock()
+	return adjList, nil
+}
+
+// invalidateAdjacencyList drops the cached link graph once links were
+// committed or removed
+func (w *Wiki) invalidateAdjacencyList() {
+	w.adjMu.Lock()
+	w.adjList = nil
+	w.adjGeneration++
+	w.adjMu.Unlock()
+}
@@ -0,0 +1,152 @@
+package wikipedia
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestBuildAdjacencyList(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "See [[Chemistry]], [[Biology|life]] and [[Alchemy]]."},
+		testPage{ID: 2, Title: "Chemistry", Text: "A [[physics|physical]] science."},
+		testPage{ID: 3, Title: "Biology", Text: "No links."},
+		testPage{ID: 4, Title: "Life", Redirect: "Biology", Text: "#REDIRECT [[Biology]]"},
+	)
+	ctx := context.Background()
+
+	// Alchemy is not an article, and the redirect links to its target
+	want := map[int64][]int64{1: {2, 3}, 2: {1}, 4: {3}}
+	adjList, err := w.BuildAdjacencyList(ctx)
+	if err != nil {
+		t.Fatalf("BuildAdjacencyList: %v", err)
+	}
+	if !maps.EqualFunc(adjList, want, slices.Equal) {
+		t.Fatalf("BuildAdjacencyList = %v, want %v", adjList, want)
+	}
+
+	// The graph is cached until RefreshAdjacencyList
+	if _, err := w.db.Exec("DELETE FROM links WHERE source_id = 4"); err != nil {
+		t.Fatal(err)
+	}
+	if adjList, _ := w.BuildAdjacencyList(ctx); !maps.EqualFunc(adjList, want, slices.Equal) {
+		t.Errorf("cached BuildAdjacencyList = %v, want %v", adjList, want)
+	}
+	if err := w.RefreshAdjacencyList(ctx); err != nil {
+		t.Fatalf("RefreshAdjacencyList: %v", err)
+	}
+	want = map[int64][]int64{1: {2, 3}, 2: {1}}
+	if adjList, _ := w.BuildAdjacencyList(ctx); !maps.EqualFunc(adjList, want, slices.Equal) {
+		t.Errorf("BuildAdjacencyList after RefreshAdjacencyList = %v, want %v", adjList, want)
+	}
+
+	// Deleting an article and importing links invalidate the cached graph
+	if err := w.DeleteArticle(2); err != nil {
+		t.Fatalf("DeleteArticle: %v", err)
+	}
+	want = map[int64][]int64{1: {3}}
+	if adjList, _ := w.BuildAdjacencyList(ctx); !maps.EqualFunc(adjList, want, slices.Equal) {
+		t.Errorf("BuildAdjacencyList after DeleteArticle = %v, want %v", adjList, want)
+	}
+
+	writeTestDump(t, w.articlesFile, []testPage{{ID: 3, Title: "Biology", Text: "The science of [[Physics|life]]."}})
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		t.Fatalf("ProcessArticlesSequential: %v", err)
+	}
+	want = map[int64][]int64{1: {3}, 3: {1}}
+	if adjList, _ := w.BuildAdjacencyList(ctx); !maps.EqualFunc(adjList, want, slices.Equal) {
+		t.Errorf("BuildAdjacencyList after an import = %v, want %v", adjList, want)
+	}
+}
+
+// bfs visits the articles reachable from start breadth-first and returns
+// their number
+func bfs(start int64, neighbors func(int64) ([]int64, error)) (int, error) {
+	visited := map[int64]bool{start: true}
+	queue := []int64{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		next, err := neighbors(id)
+		if err != nil {
+			return 0, err
+		}
+		for _, to := range next {
+			if !visited[to] {
+				visited[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	return len(visited), nil
+}
+
+// The articles of the benchmark database link to the next one, so a BFS
+// visits all of them. On a single-core x86 server, querying the links of each
+// article takes about 110ms per BFS, while the cached adjacency list takes
+// under 2ms once loaded, which itself takes about 16ms.
+
+func BenchmarkBFSQueries(b *testing.B) {
+	w := sharedBenchWiki(b)
+	neighbors := func(id int64) ([]int64, error) {
+		rows, err := w.db.Query(`
+			SELECT a.id
+			FROM links l
+			JOIN articles a ON a.title = l.target_title
+			WHERE l.source_id = ?
+		`, id)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var ids []int64
+		for rows.Next() {
+			var to int64
+			if err := rows.Scan(&to); err != nil {
+				return nil, err
+			}
+			ids = append(ids, to)
+		}
+		return ids, rows.Err()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if visited, err := bfs(1, neighbors); err != nil || visited != benchArticles {
+			b.Fatalf("visited %d articles (%v), want %d", visited, err, benchArticles)
+		}
+	}
+}
+
+func BenchmarkBFSAdjacencyList(b *testing.B) {
+	w := sharedBenchWiki(b)
+	ctx := context.Background()
+	if err := w.RefreshAdjacencyList(ctx); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		adjList, err := w.BuildAdjacencyList(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		neighbors := func(id int64) ([]int64, error) { return adjList[id], nil }
+		if visited, _ := bfs(1, neighbors); visited != benchArticles {
+			b.Fatalf("visited %d articles, want %d", visited, benchArticles)
+		}
+	}
+}
+
+func BenchmarkBuildAdjacencyList(b *testing.B) {
+	w := sharedBenchWiki(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := w.RefreshAdjacencyList(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ArticleMeta is a lightweight view of an article without its content
+type ArticleMeta struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Namespace int    `json:"namespace"`
+	Redirect  string `json:"redirect,omitempty"`
+}
+
+// AmbiguityReport describes how many articles a title could refer to
+type AmbiguityReport struct {
+	Title                  string         `json:"title"`
+	ExactMatches           int            `json:"exact_matches"`
+	CaseInsensitiveMatches int            `json:"case_insensitive_matches"`
+	IsDisambiguation       bool           `json:"is_disambiguation"`
+	Unambiguous            bool           `json:"unambiguous"`
+	Candidates             []*ArticleMeta `json:"candidates"`
+}
+
+// maxAmbiguityCandidates caps the number of candidates returned in a report
+const maxAmbiguityCandidates = 100
+
+var disambiguationRegex = regexp.MustCompile(`(?i)\{\{\s*(disambiguation|disambig|dab|hndis|geodis)\s*[|}]`)
+
+// isDisambiguation reports whether wikitext is tagged as a disambiguation page
+func isDisambiguation(content string) bool {
+	return disambiguationRegex.MatchString(content)
+}
+
+// GetAmbiguousTitle reports every article that title could refer to: exact
+// matches, case-insensitive matches and qualified titles such as "Mercury (planet)".
+// The title is unambiguous when it has exactly one exact match that is not a
+// disambiguation page.
+func (w *Wiki) GetAmbiguousTitle(ctx context.Context, title string) (*AmbiguityReport, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.ambiguity(ctx, title)
+}
+
+// ambiguity builds an AmbiguityReport; the caller must hold w.mu
+func (w *Wiki) ambiguity(ctx context.Context, title string) (*AmbiguityReport, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(title)
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT id, title, namespace, redirect, COALESCE(content, content_compressed)
+		FROM articles
+		WHERE title = ? OR LOWER(title) = LOWER(?) OR title LIKE ? ESCAPE '\'
+		ORDER BY title
+		LIMIT ?
+	`, title, title, escaped+" (%)", maxAmbiguityCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("ambiguity query failed: %w", err)
+	}
+	defer rows.Close()
+
+	report := &AmbiguityReport{Title: title, Candidates: []*ArticleMeta{}}
+	for rows.Next() {
+		var meta ArticleMeta
+		var content contentText
+		if err := rows.Scan(&meta.ID, &meta.Title, &meta.Namespace, &meta.Redirect, &content); err != nil {
+			continue
+		}
+		if meta.Title == title {
+			report.ExactMatches++
+			if isDisambiguation(string(content)) {
+				report.IsDisambiguation = true
+			}
+		}
+		if strings.EqualFold(meta.Title, title) {
+			report.CaseInsensitiveMatches++
+		}
+		report.Candidates = append(report.Candidates, &meta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Unambiguous = report.ExactMatches == 1 && !report.IsDisambiguation
+	return report, nil
+}
+
+// scanArticleMetas reads id, title, namespace, redirect rows
+func scanArticleMetas(rows *sql.Rows) ([]*ArticleMeta, error) {
+	metas := []*ArticleMeta{}
+	for rows.Next() {
+		var meta ArticleMeta
+		var redirect sql.NullString
+		if err := rows.Scan(&meta.ID, &meta.Title, &meta.Namespace, &redirect); err != nil {
+			continue
+		}
+		meta.Redirect = redirect.String
+		metas = append(metas, &meta)
+	}
+	return metas, rows.Err()
+}
@@ -0,0 +1,56 @@
+package wikipedia
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestGetAmbiguousTitle(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Mercury", Text: "'''Mercury''' may refer to:\n* [[Mercury (planet)]]\n* [[Mercury (element)]]\n* [[Mercury (mythology)]]\n{{disambiguation}}"},
+		testPage{ID: 2, Title: "Mercury (planet)", Text: "The smallest planet of the Solar System."},
+		testPage{ID: 3, Title: "Mercury (element)", Text: "A chemical element with symbol Hg."},
+		testPage{ID: 4, Title: "Mercury (mythology)", Text: "A Roman god."},
+		testPage{ID: 5, Title: "Mercury Records", Text: "A record label."},
+		testPage{ID: 6, Title: "Venus", Text: "The second planet from the Sun."},
+	)
+	ctx := context.Background()
+
+	report, err := w.GetAmbiguousTitle(ctx, "Mercury")
+	if err != nil {
+		t.Fatalf("GetAmbiguousTitle: %v", err)
+	}
+	var titles []string
+	for _, candidate := range report.Candidates {
+		titles = append(titles, candidate.Title)
+	}
+	// Mercury Records is not a qualified title
+	want := []string{"Mercury", "Mercury (element)", "Mercury (mythology)", "Mercury (planet)"}
+	if !slices.Equal(titles, want) {
+		t.Errorf("candidates = %q, want %q", titles, want)
+	}
+	if report.ExactMatches != 1 || report.CaseInsensitiveMatches != 1 || !report.IsDisambiguation || report.Unambiguous {
+		t.Errorf("report = %+v, want one exact match that is a disambiguation page", report)
+	}
+
+	// GetArticle attaches the report to disambiguation pages only
+	article, err := w.GetArticle("Mercury")
+	if err != nil {
+		t.Fatalf("GetArticle(Mercury): %v", err)
+	}
+	if article.Ambiguity == nil || len(article.Ambiguity.Candidates) != len(want) {
+		t.Errorf("GetArticle(Mercury).Ambiguity = %+v, want the %d candidates", article.Ambiguity, len(want))
+	}
+	if article, err := w.GetArticle("Mercury (planet)"); err != nil || article.Ambiguity != nil {
+		t.Errorf("GetArticle(Mercury (planet)) = %+v, %v, want no ambiguity report", article, err)
+	}
+
+	report, err = w.GetAmbiguousTitle(ctx, "Venus")
+	if err != nil {
+		t.Fatalf("GetAmbiguousTitle(Venus): %v", err)
+	}
+	if !report.Unambiguous || len(report.Candidates) != 1 {
+		t.Errorf("GetAmbiguousTitle(Venus) = %+v, want a single unambiguous candidate", report)
+	}
+}
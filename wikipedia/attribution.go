@@ -0,0 +1,52 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	wikipediaPageBaseURL = "https://en.wikipedia.org/wiki/"
+	wikipediaLicenseURL  = "https://creativecommons.org/licenses/by-sa/4.0/"
+)
+
+// Attribution holds the credit line required by Wikipedia's CC BY-SA license
+// when article content is displayed
+type Attribution struct {
+	Title             string `json:"title"`
+	Text              string `json:"text"`
+	PageURL           string `json:"page_url"`
+	LicenseURL        string `json:"license_url"`
+	ContributorsURL   string `json:"contributors_url,omitempty"`
+	ContributorsCount int    `json:"contributors_count,omitempty"`
+}
+
+// GetAttribution builds the attribution for an article
+func (w *Wiki) GetAttribution(ctx context.Context, id int64) (*Attribution, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var title string
+	err := w.db.QueryRowContext(ctx, "SELECT title FROM articles WHERE id = ?", id).Scan(&title)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query article: %w", err)
+	}
+
+	return &Attribution{
+		Title: title,
+		Text: "This article uses material from the Wikipedia article \"" + title +
+			"\", which is released under the Creative Commons Attribution-ShareAlike 4.0 License.",
+		PageURL:    wikipediaPageBaseURL + url.PathEscape(title),
+		LicenseURL: wikipediaLicenseURL,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestGetAttribution(t *testing.T) {
+	titles := []string{"Albert Einstein", "Who? (song)", "AT&T", "C#", "100% Love", "AC/DC", "Zürich"}
+	pages := make([]testPage, 0, len(titles))
+	for i, title := range titles {
+		pages = append(pages, testPage{ID: i + 1, Title: title, Text: "Text."})
+	}
+	w := newTestWiki(t, pages...)
+	ctx := context.Background()
+
+	for i, title := range titles {
+		attribution, err := w.GetAttribution(ctx, int64(i+1))
+		if err != nil {
+			t.Fatalf("GetAttribution(%q): %v", title, err)
+		}
+		if attribution.Title != title {
+			t.Errorf("got title %q, want %q", attribution.Title, title)
+		}
+
+		for _, rawURL := range []string{attribution.PageURL, attribution.LicenseURL} {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				t.Errorf("%q: URL %q does not parse: %v", title, rawURL, err)
+				continue
+			}
+			if u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+				t.Errorf("%q: URL %q is not a plain https URL", title, rawURL)
+			}
+		}
+		if u, err := url.Parse(attribution.PageURL); err == nil && u.Path != "/wiki/"+title {
+			t.Errorf("%q: page URL %q has path %q, want /wiki/%s", title, attribution.PageURL, u.Path, title)
+		}
+	}
+
+	if _, err := w.GetAttribution(ctx, 99); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("GetAttribution(99): got %v, want ErrArticleNotFound", err)
+	}
+
+	// Other failures are not reported as a missing article
+	w.Close()
+	if _, err := w.GetAttribution(ctx, 1); err == nil || errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("GetAttribution on a closed database: got %v, want a query error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BiographyInfo holds the life dates extracted from a biographical article
+type BiographyInfo struct {
+	BirthYear   int    `json:"birth_year"`
+	DeathYear   int    `json:"death_year,omitempty"`
+	IsLiving    bool   `json:"is_living"`
+	Nationality string `json:"nationality,omitempty"`
+}
+
+var (
+	birthTemplateRegex = regexp.MustCompile(`(?i)\{\{\s*(birth (date|year)( and age)?|bda)\s*\|([^{}]*)\}\}`)
+	deathTemplateRegex = regexp.MustCompile(`(?i)\{\{\s*(death (date|year)( and age)?|dda)\s*\|([^{}]*)\}\}`)
+	livingRegex        = regexp.MustCompile(`(?i)\[\[\s*Category\s*:\s*Living people\s*[\]|]|\{\{\s*(birth (date|year) and age|bda)\s*\|`)
+	wikiLinkTextRegex  = regexp.MustCompile(`\[\[(?:[^|\]]*\|)?([^\]]*)\]\]`)
+)
+
+// firstYear returns the first positional template parameter that looks like a year
+func firstYear(params string) int {
+	for _, param := range strings.Split(params, "|") {
+		param = strings.TrimSpace(param)
+		if strings.Contains(param, "=") {
+			continue
+		}
+		if year, err := strconv.Atoi(param); err == nil && year > 0 && year < 10000 {
+			return year
+		}
+	}
+	return 0
+}
+
+// ExtractBiography recognises biographical articles by their {{birth date}}
+// template and extracts birth and death years. It returns nil for articles
+// without a birth date.
+func ExtractBiography(content string) *BiographyInfo {
+	match := birthTemplateRegex.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+
+	info := &BiographyInfo{BirthYear: firstYear(match[4])}
+	if info.BirthYear == 0 {
+		return nil
+	}
+
+	if death := deathTemplateRegex.FindStringSubmatch(content); death != nil {
+		info.DeathYear = firstYear(death[4])
+	}
+	info.IsLiving = info.DeathYear == 0 && livingRegex.MatchString(content)
+
+	if _, fields, ok := extractInfobox(content); ok {
+		nationality := fields["nationality"]
+		if nationality == "" {
+			nationality = fields["citizenship"]
+		}
+		info.Nationality = strings.TrimSpace(wikiLinkTextRegex.ReplaceAllString(nationality, "$1"))
+	}
+
+	return info
+}
+
+// biographyValues returns the birth_year, death_year and is_living column values
+func biographyValues(content string) (sql.NullInt64, sql.NullInt64, sql.NullBool) {
+	info := ExtractBiography(content)
+	if info == nil {
+		return sql.NullInt64{}, sql.NullInt64{}, sql.NullBool{}
+	}
+
+	death := sql.NullInt64{Int64: int64(info.DeathYear), Valid: info.DeathYear != 0}
+	return sql.NullInt64{Int64: int64(info.BirthYear), Valid: true}, death, sql.NullBool{Bool: info.IsLiving, Valid: true}
+}
+
+// GetPeopleByBirthYear lists biographical articles of people born in year
+func (w *Wiki) GetPeopleByBirthYear(ctx context.Context, year, limit, offset int) ([]*ArticleMeta, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT id, title, namespace, redirect
+		FROM articles
+		WHERE birth_year = ?
+		ORDER BY title
+		LIMIT ? OFFSET ?
+	`, year, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query people by birth year: %w", err)
+	}
+	defer rows.Close()
+
+	return scanArticleMetas(rows)
+}
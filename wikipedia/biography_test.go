@@ -0,0 +1,79 @@
+package wikipedia
+
+import (
+	"context"
+	"testing"
+)
+
+// Biographies of a person who died, a living person and a person with a
+// birth year only
+const (
+	curieBiography = `{{Infobox scientist
+| name        = Marie Curie
+| birth_date  = {{birth date|1867|11|7|df=y}}
+| death_date  = {{death date and age|1934|7|4|1867|11|7|df=y}}
+| citizenship = [[Poland]], [[France]]
+}}
+'''Marie Curie''' was a physicist and chemist.
+[[Category:1867 births]]`
+	livingBiography = `{{Infobox person
+| name        = Jane Doe
+| birth_date  = {{birth date and age|1970|5|20}}
+| nationality = [[Canada|Canadian]]
+}}
+'''Jane Doe''' is a writer.
+[[Category:Living people]]`
+	ancientBiography = `{{Infobox philosopher
+| name       = Hypatia
+| birth_date = {{birth year|350}}
+}}
+'''Hypatia''' was a philosopher.`
+)
+
+func TestExtractBiography(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *BiographyInfo
+	}{
+		{"died", curieBiography, &BiographyInfo{BirthYear: 1867, DeathYear: 1934, Nationality: "Poland, France"}},
+		{"living", livingBiography, &BiographyInfo{BirthYear: 1970, IsLiving: true, Nationality: "Canadian"}},
+		{"birth year only", ancientBiography, &BiographyInfo{BirthYear: 350}},
+		{"not a biography", "'''Physics''' is a natural science.", nil},
+		{"birth date without a year", "{{birth date|df=y}}", nil},
+	}
+	for _, tt := range tests {
+		got := ExtractBiography(tt.content)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: ExtractBiography = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetPeopleByBirthYear(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Marie Curie", Text: curieBiography},
+		testPage{ID: 2, Title: "Jane Doe", Text: livingBiography},
+		testPage{ID: 3, Title: "Hypatia", Text: ancientBiography},
+		testPage{ID: 4, Title: "Physics", Text: "Discovered in 1867."},
+	)
+
+	for year, want := range map[int]string{1867: "Marie Curie", 1970: "Jane Doe", 350: "Hypatia"} {
+		people, err := w.GetPeopleByBirthYear(context.Background(), year, 10, 0)
+		if err != nil {
+			t.Fatalf("GetPeopleByBirthYear(%d): %v", year, err)
+		}
+		if len(people) != 1 || people[0].Title != want {
+			t.Errorf("GetPeopleByBirthYear(%d) = %v, want %s", year, people, want)
+		}
+	}
+
+	var living bool
+	var deathYear int
+	if err := w.db.QueryRow("SELECT is_living, COALESCE(death_year, 0) FROM articles WHERE id = 1").Scan(&living, &deathYear); err != nil {
+		t.Fatal(err)
+	}
+	if living || deathYear != 1934 {
+		t.Errorf("Marie Curie stored with is_living %v and death_year %d, want false and 1934", living, deathYear)
+	}
+}
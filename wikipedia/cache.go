@@ -0,0 +1,97 @@
+package wikipedia
+
+import (
+	"strconv"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultCacheSize is the number of articles kept in memory by default
+const DefaultCacheSize = 1000
+
+var (
+	articleCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "wikipedia_article_cache_hits_total",
+		Help: "Number of article lookups served from the in-memory cache",
+	})
+	articleCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "wikipedia_article_cache_misses_total",
+		Help: "Number of article lookups that had to query the database",
+	})
+)
+
+// ArticleCache keeps the most recently read articles in memory. Articles are
+// stored by value so that callers cannot modify the cached copies.
+type ArticleCache struct {
+	entries *lru.Cache[string, Article]
+}
+
+// NewArticleCache creates a cache holding up to size articles, or nil when
+// size is not positive. A nil cache is safe to use and caches nothing.
+func NewArticleCache(size int) *ArticleCache {
+	if size <= 0 {
+		return nil
+	}
+
+	entries, err := lru.New[string, Article](size)
+	if err != nil {
+		return nil
+	}
+	return &ArticleCache{entries: entries}
+}
+
+// get returns a copy of the cached article stored under key
+func (c *ArticleCache) get(key string) (*Article, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	article, ok := c.entries.Get(key)
+	if !ok {
+		articleCacheMisses.Inc()
+		return nil, false
+	}
+	articleCacheHits.Inc()
+	return &article, true
+}
+
+// add stores a copy of article under key
+func (c *ArticleCache) add(key string, article *Article) {
+	if c == nil {
+		return
+	}
+	c.entries.Add(key, *article)
+}
+
+// purge removes all cached articles
+func (c *ArticleCache) purge() {
+	if c == nil {
+		return
+	}
+	c.entries.Purge()
+}
+
+// articleIDKey and articleTitleKey build the cache keys of lookups by ID and
+// by title; title lookups depend on the case sensitivity and on how many
+// redirects are followed
+func articleIDKey(id int64) string {
+	return "id:" + strconv.FormatInt(id, 10)
+}
+
+func articleTitleKey(title string, caseInsensitive bool, maxDepth int) string {
+	return "title:" + strconv.FormatBool(caseInsensitive) + ":" + strconv.Itoa(maxDepth) + ":" + title
+}
+
+// WithCacheSize sets the number of articles cached in memory by GetArticle and
+// GetArticleByID; 0 disables the cache
+func (w *Wiki) WithCacheSize(size int) *Wiki {
+	w.cache = NewArticleCache(size)
+	return w
+}
+
+// ClearCache empties the article cache, so that the next reads query the
+// database
+func (w *Wiki) ClearCache() {
+	w.cache.purge()
+}
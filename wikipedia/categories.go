@@ -0,0 +1,215 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var categoryLinkRegex = regexp.MustCompile(`(?i)\[\[\s*category\s*:([^\[\]|]+)`)
+
+// ErrCategoryDataNotAvailable is returned by GetCategoryAncestors when no
+// category page (namespace 14) was imported with its categories
+var ErrCategoryDataNotAvailable = errors.New("category pages are not in the database, import namespace 14")
+
+// createCategoryTables creates the categories table and a trigger dropping
+// the categories of deleted articles
+func (w *Wiki) createCategoryTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS categories (
+			article_id INTEGER NOT NULL,
+			category_name TEXT NOT NULL,
+			PRIMARY KEY (article_id, category_name)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_categories_name ON categories(category_name)",
+		`CREATE TRIGGER IF NOT EXISTS articles_categories_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM categories WHERE article_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create categories table: %w", err)
+		}
+	}
+	return nil
+}
+
+// categoryName normalizes a category name given with or without its
+// "Category:" prefix
+func categoryName(name string) string {
+	if prefix, rest, ok := strings.Cut(name, ":"); ok && strings.EqualFold(strings.TrimSpace(prefix), "category") {
+		name = rest
+	}
+	return normalizeTitle(name)
+}
+
+// extractCategories returns the distinct categories of an article from its
+// [[Category:Name]] and [[Category:Name|sort key]] links. Links with a
+// leading colon ([[:Category:Physics]]) point to the category page and do not
+// categorize the article.
+func extractCategories(content string) []string {
+	seen := make(map[string]bool)
+	categories := []string{}
+	for _, match := range categoryLinkRegex.FindAllStringSubmatch(maskNowiki(content), -1) {
+		name := normalizeTitle(match[1])
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		categories = append(categories, name)
+	}
+	return categories
+}
+
+// storeCategories replaces the stored categories of an article
+func storeCategories(ctx context.Context, db execer, id int64, content string) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM categories WHERE article_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear categories: %w", err)
+	}
+	for _, name := range extractCategories(content) {
+		if _, err := db.ExecContext(ctx, "INSERT OR IGNORE INTO categories (article_id, category_name) VALUES (?, ?)", id, name); err != nil {
+			return fmt.Errorf("failed to store category: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetArticleCategories returns the names of the categories of an article,
+// without the "Category:" prefix
+func (w *Wiki) GetArticleCategories(id int64) ([]string, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.Query("SELECT category_name FROM categories WHERE article_id = ? ORDER BY category_name", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			continue
+		}
+		categories = append(categories, name)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetCategoryMembers returns the articles in a category, sorted by title and
+// without their content. The category may be given with or without its
+// "Category:" prefix.
+func (w *Wiki) GetCategoryMembers(category string, limit, offset int) ([]*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := w.db.Query(`
+		SELECT a.id, a.title, a.namespace, a.redirect
+		FROM categories c
+		JOIN articles a ON a.id = c.article_id
+		WHERE c.category_name = ?
+		ORDER BY a.title
+		LIMIT ? OFFSET ?
+	`, categoryName(category), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category members: %w", err)
+	}
+	defer rows.Close()
+
+	articles := []*Article{}
+	for rows.Next() {
+		var article Article
+		var redirect sql.NullString
+		if err := rows.Scan(&article.ID, &article.Title, &article.Namespace, &redirect); err != nil {
+			continue
+		}
+		article.Redirect = redirect.String
+		articles = append(articles, &article)
+	}
+
+	return articles, rows.Err()
+}
+
+// GetCategoryAncestors returns a category followed by its parent categories,
+// those of its parents and so on up to maxDepth levels, without the
+// "Category:" prefix. The parents of a category are the categories of its
+// category page, so category pages must be imported (namespace 14). Each
+// ancestor is listed once, at its nearest level, and categories of the same
+// level are sorted by name.
+func (w *Wiki) GetCategoryAncestors(ctx context.Context, category string, maxDepth int) ([]string, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var available bool
+	err := w.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM categories c
+			JOIN articles a ON a.id = c.article_id
+			WHERE a.namespace = 14
+		)
+	`).Scan(&available)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query categories: %w", err)
+	}
+	if !available {
+		return nil, ErrCategoryDataNotAvailable
+	}
+
+	// UNION drops repeated (name, depth) pairs and the depth bounds cycles
+	rows, err := w.db.QueryContext(ctx, `
+		WITH RECURSIVE ancestors(name, depth) AS (
+			SELECT ?, 0
+			UNION
+			SELECT c.category_name, ancestors.depth + 1
+			FROM ancestors
+			JOIN articles a ON a.namespace = 14 AND a.title = 'Category:' || ancestors.name
+			JOIN categories c ON c.article_id = a.id
+			WHERE ancestors.depth < ?
+		)
+		SELECT name
+		FROM ancestors
+		GROUP BY name
+		ORDER BY MIN(depth), name
+	`, categoryName(category), maxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category ancestors: %w", err)
+	}
+	defer rows.Close()
+
+	ancestors := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			continue
+		}
+		ancestors = append(ancestors, name)
+	}
+
+	return ancestors, rows.Err()
+}
@@ -0,0 +1,94 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetCategoryAncestors(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Quantum mechanics", Text: "A theory of physics.\n[[Category:Physics]]"},
+		testPage{ID: 2, Title: "Category:Physics", NS: 14, Text: "[[Category:Science]]"},
+		testPage{ID: 3, Title: "Category:Science", NS: 14, Text: "[[Category:Academic disciplines]]"},
+		testPage{ID: 4, Title: "Category:Academic disciplines", NS: 14, Text: "[[Category:Main topic classifications]]"},
+		testPage{ID: 5, Title: "Category:Main topic classifications", NS: 14, Text: "[[Category:Academic disciplines]]"},
+	)
+
+	tests := []struct {
+		category string
+		depth    int
+		want     []string
+	}{
+		{"Physics", 2, []string{"Physics", "Science", "Academic disciplines"}},
+		{"Category:Physics", 2, []string{"Physics", "Science", "Academic disciplines"}},
+		{"Physics", 0, []string{"Physics"}},
+		// Academic disciplines and Main topic classifications contain each other
+		{"Physics", 10, []string{"Physics", "Science", "Academic disciplines", "Main topic classifications"}},
+		{"Nonexistent", 5, []string{"Nonexistent"}},
+	}
+	for _, tt := range tests {
+		got, err := w.GetCategoryAncestors(context.Background(), tt.category, tt.depth)
+		if err != nil {
+			t.Fatalf("GetCategoryAncestors(%q, %d): %v", tt.category, tt.depth, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("GetCategoryAncestors(%q, %d) = %q, want %q", tt.category, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestGetCategoryAncestorsWithoutCategoryPages(t *testing.T) {
+	w := newTestWiki(t, testPage{ID: 1, Title: "Quantum mechanics", Text: "[[Category:Physics]]"})
+
+	if _, err := w.GetCategoryAncestors(context.Background(), "Physics", 5); !errors.Is(err, ErrCategoryDataNotAvailable) {
+		t.Errorf("got %v, want ErrCategoryDataNotAvailable", err)
+	}
+}
+
+func TestSearchInOverlappingCategories(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Quantum mechanics", Text: "[[Category:Physics]]\n[[Category:Science]]"},
+		testPage{ID: 2, Title: "Quantum chemistry", Text: "[[Category:Chemistry]]\n[[Category:Physics]]\n[[Category:Science]]"},
+		testPage{ID: 3, Title: "Quantum biology", Text: "[[Category:Biology]]\n[[Category:Science]]"},
+		testPage{ID: 4, Title: "Quantum", Text: "No category."},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{"Science", []string{"Quantum biology", "Quantum chemistry", "Quantum mechanics"}},
+		{"Category:Physics", []string{"Quantum chemistry", "Quantum mechanics"}},
+		{"Chemistry", []string{"Quantum chemistry"}},
+		{"Geology", nil},
+		{"", []string{"Quantum", "Quantum biology", "Quantum chemistry", "Quantum mechanics"}},
+	}
+	for _, tt := range tests {
+		// Two pages of 2 hits, so a duplicated hit would show up on both
+		var titles []string
+		for offset := 0; offset < 4; offset += 2 {
+			articles, err := w.SearchInCategory("Quantum", tt.category, 2, offset)
+			if err != nil {
+				t.Fatalf("SearchInCategory(%q): %v", tt.category, err)
+			}
+			for _, article := range articles {
+				titles = append(titles, article.Title)
+			}
+		}
+		slices.Sort(titles)
+		if !slices.Equal(titles, tt.want) {
+			t.Errorf("SearchInCategory(%q) = %q, want %q", tt.category, titles, tt.want)
+		}
+
+		count, err := w.CountSearchResultsWithOptions(ctx, "Quantum", SearchOptions{Category: tt.category})
+		if err != nil {
+			t.Fatalf("CountSearchResultsWithOptions(%q): %v", tt.category, err)
+		}
+		if count != len(tt.want) {
+			t.Errorf("CountSearchResultsWithOptions(%q) = %d, want %d", tt.category, count, len(tt.want))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ArticleChange is an article updated by an import run and how much it changed
+type ArticleChange struct {
+	ID    int64   `json:"id"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+}
+
+// ComputeChangeScore estimates how different two revisions of an article are,
+// from 0.0 (identical) to 1.0 (no line in common). It is computed on lines as
+// 1 - 2*LCS / (oldLines + newLines).
+func ComputeChangeScore(old, new string) float64 {
+	if old == new {
+		return 0.0
+	}
+
+	oldLines := splitLines(old)
+	newLines := splitLines(new)
+	total := len(oldLines) + len(newLines)
+	if total == 0 {
+		return 0.0
+	}
+
+	return 1.0 - 2.0*float64(lcsLength(oldLines, newLines))/float64(total)
+}
+
+// splitLines splits text into lines, treating empty text as no lines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+// Common prefixes and suffixes are trimmed first since most edits are local.
+func lcsLength(a, b []string) int {
+	common := 0
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+		common++
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+		common++
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return common
+	}
+
+	// Two-row dynamic programming over the shorter sequence
+	if len(b) > len(a) {
+		a, b = b, a
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				curr[j] = prev[j-1] + 1
+			case prev[j] >= curr[j-1]:
+				curr[j] = prev[j]
+			default:
+				curr[j] = curr[j-1]
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return common + prev[len(b)]
+}
+
+// GetMostChangedArticles returns the articles updated by the latest import run
+// that has updates, sorted by decreasing change score
+func (w *Wiki) GetMostChangedArticles(ctx context.Context, limit int) ([]ArticleChange, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT ia.article_id, COALESCE(a.title, ''), ia.change_score
+		FROM import_audit ia
+		LEFT JOIN articles a ON a.id = ia.article_id
+		WHERE ia.change_score IS NOT NULL AND ia.run_id = (
+			SELECT r.run_id FROM import_runs r
+			WHERE EXISTS (SELECT 1 FROM import_audit x WHERE x.run_id = r.run_id AND x.change_score IS NOT NULL)
+			ORDER BY r.rowid DESC
+			LIMIT 1
+		)
+		ORDER BY ia.change_score DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changed articles: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []ArticleChange{}
+	for rows.Next() {
+		var change ArticleChange
+		if err := rows.Scan(&change.ID, &change.Title, &change.Score); err != nil {
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,32 @@
+package wikipedia
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeChangeScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     float64
+	}{
+		{"identical", "Line one\nLine two\nLine three", "Line one\nLine two\nLine three", 0},
+		{"both empty", "", "", 0},
+		{"completely different", "Line one\nLine two", "Other line\nAnother line\nLast line", 1},
+		{"emptied", "Line one\nLine two", "", 1},
+		{"created", "", "Line one", 1},
+		// 1 common line out of 2 + 2
+		{"one line changed", "Line one\nLine two", "Line one\nLine 2", 0.5},
+		// 3 common lines out of 4 + 3
+		{"one line removed", "a\nb\nc\nd", "a\nc\nd", 1 - 6.0/7},
+	}
+	for _, tt := range tests {
+		if got := ComputeChangeScore(tt.old, tt.new); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: ComputeChangeScore = %v, want %v", tt.name, got, tt.want)
+		}
+		if got, reverse := ComputeChangeScore(tt.old, tt.new), ComputeChangeScore(tt.new, tt.old); got != reverse {
+			t.Errorf("%s: ComputeChangeScore is not symmetric: %v and %v", tt.name, got, reverse)
+		}
+	}
+}
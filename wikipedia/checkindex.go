@@ -0,0 +1,83 @@
+package wikipedia
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// bzip2BlockMagic starts the first block of every bzip2 stream, after the
+// "BZh" signature and the block size digit
+var bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+
+// IndexCheckResult reports the outcome of CheckIndex
+type IndexCheckResult struct {
+	TotalSeeks   int     `json:"total_seeks"`
+	ValidSeeks   int     `json:"valid_seeks"`
+	InvalidSeeks []int64 `json:"invalid_seeks"`
+}
+
+// CheckIndex verifies that every distinct seek offset of index_entries points
+// to the start of a bzip2 stream in the articles file: "BZh", a block size
+// digit and the block magic 0x314159265359. Offsets past the end of a
+// partially downloaded dump are reported as invalid.
+func (w *Wiki) CheckIndex() (*IndexCheckResult, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	file, err := os.Open(w.articlesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(bzip2Magic))
+	if _, err := file.ReadAt(magic, 0); err != nil || !bytes.Equal(magic, bzip2Magic) {
+		return nil, errors.New("index check requires a bzip2 multistream articles file")
+	}
+
+	rows, err := w.db.Query("SELECT DISTINCT seek FROM index_entries ORDER BY seek")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index seeks: %w", err)
+	}
+	defer rows.Close()
+
+	result := &IndexCheckResult{InvalidSeeks: []int64{}}
+	header := make([]byte, 4+len(bzip2BlockMagic))
+	for rows.Next() {
+		var seek int64
+		if err := rows.Scan(&seek); err != nil {
+			return nil, fmt.Errorf("failed to read index seek: %w", err)
+		}
+		result.TotalSeeks++
+
+		if seek < 0 {
+			result.InvalidSeeks = append(result.InvalidSeeks, seek)
+			continue
+		}
+		_, err := file.ReadAt(header, seek)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read articles file at %d: %w", seek, err)
+		}
+		if err == nil && isBzip2StreamStart(header) {
+			result.ValidSeeks++
+		} else {
+			result.InvalidSeeks = append(result.InvalidSeeks, seek)
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// isBzip2StreamStart reports whether header starts a bzip2 stream
+func isBzip2StreamStart(header []byte) bool {
+	return bytes.HasPrefix(header, []byte("BZh")) &&
+		header[3] >= '1' && header[3] <= '9' &&
+		bytes.Equal(header[4:], bzip2BlockMagic)
+}
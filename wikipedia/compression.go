@@ -0,0 +1,158 @@
+package wikipedia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Values of the COMPRESSION setting
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// ErrCompressionNeedsFTSRebuild is returned by ProcessArticles with
+// compression on a database whose full-text index was created by an older
+// version, which cannot read compressed content
+var ErrCompressionNeedsFTSRebuild = errors.New("the full-text index cannot read compressed content: rebuild it with -rebuild-fts before importing with COMPRESSION=gzip")
+
+// sqliteDriver is the database/sql driver of the databases: SQLite with the
+// decompress_content function, through which the full-text index and the LIKE
+// searches read the content of compressed articles
+const sqliteDriver = "sqlite3_wikipedia"
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("decompress_content", decompressContent, true)
+		},
+	})
+}
+
+// decompressContent implements decompress_content(content_compressed), which
+// returns the text of a compressed article and NULL for NULL
+func decompressContent(value interface{}) (interface{}, error) {
+	if b, ok := value.([]byte); ok && b == nil {
+		return nil, nil
+	}
+	var text contentText
+	if err := text.Scan(value); err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// contentSQL is the content of the articles row named row, e.g. "new" in a
+// trigger, decompressed when it is stored compressed
+func contentSQL(row string) string {
+	return "COALESCE(" + row + ".content, decompress_content(" + row + ".content_compressed))"
+}
+
+// WithCompression makes ProcessArticles store the content of each article
+// gzip-compressed in the content_compressed column, leaving content NULL, when
+// compression is CompressionGzip. Reads decompress it transparently, and the
+// full-text index reads it through decompress_content; databases whose index
+// was created by older versions need RebuildFTS first.
+func (w *Wiki) WithCompression(compression string) *Wiki {
+	w.gzipContent = compression == CompressionGzip
+	return w
+}
+
+// compressContent gzips the content of an article, favouring speed over size
+func compressContent(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(zw, content); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkCompression returns ErrCompressionNeedsFTSRebuild when compressed
+// articles would be left out of the full-text index
+func (w *Wiki) checkCompression() error {
+	if w.gzipContent && w.ftsVersion != "none" && w.legacyFTS {
+		return ErrCompressionNeedsFTSRebuild
+	}
+	return nil
+}
+
+// contentValues returns the content and content_compressed column values of
+// an article
+func (w *Wiki) contentValues(content string) (interface{}, interface{}, error) {
+	if !w.gzipContent {
+		return content, nil, nil
+	}
+	compressed, err := compressContent(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compress content: %w", err)
+	}
+	return nil, compressed, nil
+}
+
+// GetArticleContentGzip returns the wikitext of an article gzip-compressed:
+// the stored blob of compressed articles (see WithCompression), or the
+// content compressed on the fly
+func (w *Wiki) GetArticleContentGzip(id int64) ([]byte, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var content sql.NullString
+	var compressed []byte
+	err := w.db.QueryRow("SELECT content, content_compressed FROM articles WHERE id = ?", id).Scan(&content, &compressed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query article: %w", err)
+	}
+	if compressed != nil {
+		return compressed, nil
+	}
+	return compressContent(content.String)
+}
+
+// contentText scans article content selected as
+// COALESCE(content, content_compressed): text is stored as is, and a blob is
+// the gzip-compressed content. NULL scans as the empty string.
+type contentText string
+
+// Scan implements sql.Scanner
+func (c *contentText) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = ""
+	case string:
+		*c = contentText(v)
+	case []byte:
+		zr, err := gzip.NewReader(bytes.NewReader(v))
+		if err != nil {
+			return fmt.Errorf("failed to decompress content: %w", err)
+		}
+		defer zr.Close()
+		text, err := io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("failed to decompress content: %w", err)
+		}
+		*c = contentText(text)
+	default:
+		return fmt.Errorf("unsupported content type %T", value)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var compressedPages = []testPage{
+	{ID: 1, Title: "Physics", Text: "Physics is the natural science of matter and energy."},
+	{ID: 2, Title: "Banana", Text: "The banana is an elongated fruit, studied by [[Physics|physicists]] only for fun."},
+	{ID: 3, Title: "Apple", Text: "The apple is a round fruit."},
+}
+
+// checkFTSIntegrity fails the test when the full-text index does not match
+// the content of the articles
+func checkFTSIntegrity(tb testing.TB, w *Wiki) {
+	tb.Helper()
+
+	if _, err := w.db.Exec("INSERT INTO articles_fts(articles_fts) VALUES('integrity-check')"); err != nil {
+		tb.Errorf("full-text index integrity check: %v", err)
+	}
+}
+
+// Compressed articles are indexed with their decompressed content
+func TestCompressedContentSearch(t *testing.T) {
+	w := newTestDump(t, compressedPages...).WithCompression(CompressionGzip)
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		t.Fatalf("ProcessArticlesSequential: %v", err)
+	}
+
+	var stored int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM articles WHERE content IS NULL AND content_compressed IS NOT NULL").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != len(compressedPages) {
+		t.Fatalf("%d of %d articles stored compressed", stored, len(compressedPages))
+	}
+
+	articles, err := w.SearchContent("elongated", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchContent: %v", err)
+	}
+	if len(articles) != 1 || articles[0].Title != "Banana" || !strings.Contains(articles[0].Snippet, "elongated") {
+		t.Errorf("SearchContent(elongated) = %v, want Banana with a snippet", articles)
+	}
+
+	titles, err := w.SearchTitles("fruit", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchTitles: %v", err)
+	}
+	if len(titles) != 2 {
+		t.Errorf("SearchTitles(fruit) = %q, want Apple and Banana", titles)
+	}
+
+	highlighted, err := w.SearchArticlesWithOptions(context.Background(), "round", 10, 0, SearchOptions{Highlight: true})
+	if err != nil {
+		t.Fatalf("SearchArticlesWithOptions: %v", err)
+	}
+	if len(highlighted) != 1 || !strings.Contains(highlighted[0].Snippet, "<b>round</b>") {
+		t.Errorf("highlighted search for round = %v, want Apple with a highlighted snippet", highlighted)
+	}
+
+	mentions, err := w.SearchMentions(context.Background(), "Physics", 10)
+	if err != nil {
+		t.Fatalf("SearchMentions: %v", err)
+	}
+	if len(mentions) != 1 || mentions[0].Title != "Banana" {
+		t.Errorf("SearchMentions(Physics) = %v, want Banana", mentions)
+	}
+
+	checkFTSIntegrity(t, w)
+	if err := w.DeleteArticle(2); err != nil {
+		t.Fatalf("DeleteArticle: %v", err)
+	}
+	checkFTSIntegrity(t, w)
+	if titles, err := w.SearchTitles("elongated", 10, 0); err != nil || len(titles) != 0 {
+		t.Errorf("SearchTitles(elongated) after deleting Banana = %q, %v", titles, err)
+	}
+}
+
+// Re-importing an article replaces its indexed content
+func TestCompressedReimport(t *testing.T) {
+	w := newTestDump(t, compressedPages...).WithCompression(CompressionGzip)
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		t.Fatalf("ProcessArticlesSequential: %v", err)
+	}
+	writeTestDump(t, w.articlesFile, []testPage{{ID: 2, Title: "Banana", Text: "The banana is a yellow fruit."}})
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+
+	checkFTSIntegrity(t, w)
+	if titles, err := w.SearchTitles("elongated", 10, 0); err != nil || len(titles) != 0 {
+		t.Errorf("SearchTitles(elongated) after the re-import = %q, %v", titles, err)
+	}
+	if titles, err := w.SearchTitles("yellow", 10, 0); err != nil || len(titles) != 1 {
+		t.Errorf("SearchTitles(yellow) after the re-import = %q, %v, want Banana", titles, err)
+	}
+}
+
+// Compressed imports into a database whose full-text index reads articles
+// directly are rejected until the index is rebuilt
+func TestCompressionNeedsFTSRebuild(t *testing.T) {
+	w := newTestWiki(t, compressedPages...)
+	if w.ftsVersion == "none" {
+		t.Skip("full-text search is not available")
+	}
+
+	// The index and triggers of older versions
+	statements := []string{
+		"DROP TRIGGER IF EXISTS articles_ai",
+		"DROP TRIGGER IF EXISTS articles_ad",
+		"DROP TRIGGER IF EXISTS articles_au",
+		"DROP TRIGGER IF EXISTS articles_bd",
+		"DROP TRIGGER IF EXISTS articles_bu",
+		"DROP TABLE articles_fts",
+		"DROP VIEW " + ftsContentView,
+		"CREATE VIRTUAL TABLE articles_fts USING " + w.ftsVersion + "(title, content, content='articles')",
+		"INSERT INTO articles_fts(articles_fts) VALUES('rebuild')",
+	}
+	if err := w.execAll(statements); err != nil {
+		t.Fatal(err)
+	}
+	w.existingFTSVersion()
+
+	w.WithCompression(CompressionGzip)
+	if err := w.ProcessArticlesSequential(-1); !errors.Is(err, ErrCompressionNeedsFTSRebuild) {
+		t.Fatalf("compressed import with the old index: got %v, want ErrCompressionNeedsFTSRebuild", err)
+	}
+
+	if err := w.RebuildFTS(); err != nil {
+		t.Fatalf("RebuildFTS: %v", err)
+	}
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		t.Fatalf("compressed import after RebuildFTS: %v", err)
+	}
+	articles, err := w.SearchContent("elongated", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchContent: %v", err)
+	}
+	if len(articles) != 1 || articles[0].Title != "Banana" {
+		t.Errorf("SearchContent(elongated) = %v, want Banana", articles)
+	}
+	checkFTSIntegrity(t, w)
+}
@@ -0,0 +1,91 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// DeleteArticle removes an article from the database in a single transaction.
+// The delete triggers drop its full-text entry, categories, links, external
+// links and media references; its index entries and the versions kept by
+// import runs are deleted too, so that neither a later ProcessArticles run
+// nor a rollback brings it back. Loading the index again does.
+func (w *Wiki) DeleteArticle(id int64) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.deleteArticle(id)
+}
+
+// DeleteArticleByTitle is DeleteArticle for the article with the exact title
+func (w *Wiki) DeleteArticleByTitle(title string) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var id int64
+	err := w.db.QueryRow("SELECT id FROM articles WHERE title = ?", title).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrArticleNotFound, title)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up article: %w", err)
+	}
+
+	return w.deleteArticle(id)
+}
+
+// deleteArticle is DeleteArticle for callers that already hold w.mu
+func (w *Wiki) deleteArticle(id int64) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var title string
+	err = tx.QueryRow("SELECT title FROM articles WHERE id = ?", id).Scan(&title)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up article: %w", err)
+	}
+
+	statements := []string{
+		"DELETE FROM articles WHERE id = ?",
+		"DELETE FROM index_entries WHERE article_id = ?",
+		"DELETE FROM content_versions WHERE article_id = ?",
+		"DELETE FROM word_frequencies WHERE article_id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, id); err != nil {
+			return fmt.Errorf("failed to delete article %d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit article deletion: %w", err)
+	}
+
+	w.cache.purge()
+	w.invalidateAdjacencyList()
+	w.logger.Info("Deleted article", "id", id, "title", title)
+	return nil
+}
@@ -0,0 +1,88 @@
+package wikipedia
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeleteArticle(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "Physics studies matter and energy.\n[[Category:Science]]"},
+		testPage{ID: 2, Title: "Chemistry", Text: "Chemistry studies matter and its reactions.\n[[Category:Science]]"},
+		testPage{ID: 3, Title: "Biology", Text: "Biology studies living matter, see [[Chemistry]].\n[[Category:Science]]"},
+	)
+	if _, err := w.db.Exec("INSERT INTO index_entries (seek, article_id) VALUES (0, 1), (0, 2), (0, 3)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// searchIDs returns the IDs of the articles whose content matches query
+	searchIDs := func(query string) map[int64]bool {
+		t.Helper()
+		articles, err := w.SearchContent(query, 10, 0)
+		if err != nil {
+			t.Fatalf("SearchContent(%q): %v", query, err)
+		}
+		ids := make(map[int64]bool)
+		for _, article := range articles {
+			ids[article.ID] = true
+		}
+		return ids
+	}
+	// countRows returns the number of rows of table left for article id
+	countRows := func(table, column string, id int64) int {
+		t.Helper()
+		var count int
+		if err := w.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+column+" = ?", id).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	if ids := searchIDs("matter"); len(ids) != 3 {
+		t.Fatalf("SearchContent(matter) before deleting = %v, want 3 articles", ids)
+	}
+
+	if err := w.DeleteArticle(1); err != nil {
+		t.Fatalf("DeleteArticle(1): %v", err)
+	}
+	if err := w.DeleteArticleByTitle("Biology"); err != nil {
+		t.Fatalf("DeleteArticleByTitle(Biology): %v", err)
+	}
+
+	for _, id := range []int64{1, 3} {
+		if _, err := w.GetArticleByID(id); !errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("GetArticleByID(%d) after deleting: got %v, want ErrArticleNotFound", id, err)
+		}
+		for _, row := range []struct{ table, column string }{
+			{"index_entries", "article_id"},
+			{"categories", "article_id"},
+			{"links", "source_id"},
+		} {
+			if count := countRows(row.table, row.column, id); count != 0 {
+				t.Errorf("article %d: %d rows left in %s", id, count, row.table)
+			}
+		}
+	}
+
+	// The full-text entries are removed, and the remaining article is found
+	if ids := searchIDs("matter"); len(ids) != 1 || !ids[2] {
+		t.Errorf("SearchContent(matter) after deleting = %v, want only Chemistry", ids)
+	}
+	if ids := searchIDs("energy"); len(ids) != 0 {
+		t.Errorf("SearchContent(energy) after deleting Physics = %v, want none", ids)
+	}
+	if article, err := w.GetArticleByID(2); err != nil || article.Title != "Chemistry" {
+		t.Errorf("GetArticleByID(2) = %v, %v, want Chemistry", article, err)
+	}
+
+	// Missing articles, including those just deleted
+	if err := w.DeleteArticle(1); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("DeleteArticle of a deleted article: got %v, want ErrArticleNotFound", err)
+	}
+	if err := w.DeleteArticle(99); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("DeleteArticle(99): got %v, want ErrArticleNotFound", err)
+	}
+	if err := w.DeleteArticleByTitle("Nonexistent"); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("DeleteArticleByTitle(Nonexistent): got %v, want ErrArticleNotFound", err)
+	}
+}
@@ -0,0 +1,241 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxDiffBytes caps the DiffText of an ArticleDiff
+const MaxDiffBytes = 100 * 1024
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// ArticleDiff is a line-based comparison of the plain text of two articles
+type ArticleDiff struct {
+	// Added and Removed count the lines only in the second and first article
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	// DiffText is a unified diff, cut at MaxDiffBytes when Truncated is set
+	DiffText  string `json:"diff_text"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// DiffArticles compares the plain text of two articles line by line. Articles
+// imported without -strip-text are converted with StripWikitext.
+func (w *Wiki) DiffArticles(id1, id2 int64) (*ArticleDiff, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	title1, text1, err := w.articlePlainText(id1)
+	if err == nil {
+		var title2, text2 string
+		if title2, text2, err = w.articlePlainText(id2); err == nil {
+			w.mu.RUnlock()
+			return unifiedDiff(title1, title2, splitLines(text1), splitLines(text2)), nil
+		}
+	}
+	w.mu.RUnlock()
+	return nil, err
+}
+
+// articlePlainText returns the title and plain text of an article
+func (w *Wiki) articlePlainText(id int64) (string, string, error) {
+	var title string
+	var content contentText
+	var plainText sql.NullString
+	err := w.db.QueryRow("SELECT title, COALESCE(content, content_compressed), plain_text FROM articles WHERE id = ?", id).Scan(&title, &content, &plainText)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to query article: %w", err)
+	}
+	if plainText.Valid {
+		return title, plainText.String, nil
+	}
+	return title, StripWikitext(string(content)), nil
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns an edit script turning a into b, computed with Myers'
+// O((N+M)D) algorithm
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] holds v[k] for k in [-d, d] after step d
+	var trace [][]int
+	done := false
+	for d := 0; d <= max && !done; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+			}
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+	}
+
+	// Walk the trace back from (n, m), collecting the script in reverse
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{' ', a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff formats the differences between a and b as a unified diff with
+// diffContext lines of context, capped at MaxDiffBytes
+func unifiedDiff(nameA, nameB string, a, b []string) *ArticleDiff {
+	ops := diffLines(a, b)
+	diff := &ArticleDiff{}
+
+	var sb strings.Builder
+	write := func(s string) {
+		if diff.Truncated {
+			return
+		}
+		if sb.Len()+len(s) > MaxDiffBytes {
+			diff.Truncated = true
+			return
+		}
+		sb.WriteString(s)
+	}
+
+	write("--- " + nameA + "\n+++ " + nameB + "\n")
+
+	// lineA and lineB are the 1-based line numbers of ops[i] in a and b
+	lineA, lineB := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			lineA++
+			lineB++
+			i++
+			continue
+		}
+
+		// A hunk spans the changes separated by at most 2*diffContext kept lines
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for kept := 0; end < len(ops) && kept <= 2*diffContext; end++ {
+			if ops[end].kind == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+		}
+		// Keep only diffContext trailing lines
+		for end > i && ops[end-1].kind == ' ' {
+			end--
+		}
+		end += diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hunkA, hunkB := lineA-(i-start), lineB-(i-start)
+		var countA, countB int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		write(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(hunkA, countA), hunkRange(hunkB, countB)))
+		for _, op := range ops[start:end] {
+			write(string(op.kind) + op.line + "\n")
+		}
+
+		for _, op := range ops[i:end] {
+			switch op.kind {
+			case ' ':
+				lineA++
+				lineB++
+			case '-':
+				lineA++
+				diff.Removed++
+			case '+':
+				lineB++
+				diff.Added++
+			}
+		}
+		i = end
+	}
+
+	diff.DiffText = sb.String()
+	return diff
+}
+
+// hunkRange formats the start and length of a hunk side; an empty side starts
+// at the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
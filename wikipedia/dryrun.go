@@ -0,0 +1,85 @@
+package wikipedia
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// WithDryRun makes LoadIndex and ProcessArticles decompress and parse the dump
+// files and report how many records they would insert, without opening or
+// writing the database
+func (w *Wiki) WithDryRun(enabled bool) *Wiki {
+	w.dryRun = enabled
+	return w
+}
+
+// dryRunLoadIndex counts the valid entries of the index file
+func (w *Wiki) dryRunLoadIndex(limit int, progress chan<- ProgressEvent) error {
+	start := time.Now()
+	result, err := w.ParseIndexOnly(context.Background(), limit)
+	if err != nil {
+		return err
+	}
+
+	count := int(result.ArticlesParsed)
+	w.logger.Info("Dry run: would insert index entries", "count", count)
+	sendProgress(progress, PhaseLoadIndex, count, count, start)
+	return nil
+}
+
+// dryRunProcessArticles counts the pages of the articles file in the selected
+// namespaces. The database is not read either, so pages missing from the
+// index and the -resume watermark are not taken into account.
+func (w *Wiki) dryRunProcessArticles(limit int, progress chan<- ProgressEvent) error {
+	start := time.Now()
+
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	w.logger.Info("Processing articles (dry run)", "file", w.articlesFile)
+
+	decoder := xml.NewDecoder(r)
+	processed := 0
+	skipped := 0
+	for {
+		var page Page
+		err := decoder.Decode(&page)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			w.logger.Error("XML decode error", "error", err)
+			continue
+		}
+
+		if !w.processesNamespace(page.NS) {
+			skipped++
+			continue
+		}
+
+		processed++
+		if processed%1000 == 0 {
+			sendProgress(progress, PhaseProcessArticles, processed, limit, start)
+		}
+		if limit > 0 && processed >= limit {
+			break
+		}
+	}
+
+	w.logger.Info("Dry run: would insert articles", "count", processed, "skipped_namespaces", skipped)
+	sendProgress(progress, PhaseProcessArticles, processed, processed, start)
+	return nil
+}
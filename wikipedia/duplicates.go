@@ -0,0 +1,260 @@
+package wikipedia
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// DuplicatePair is a pair of articles with identical or near-identical content
+type DuplicatePair struct {
+	ID1             int64   `json:"id1"`
+	ID2             int64   `json:"id2"`
+	SimilarityScore float64 `json:"similarity_score"`
+}
+
+const (
+	// minhashSlots is the number of hash values in a MinHash signature
+	minhashSlots = 64
+
+	// shingleSize is the number of words in each shingle hashed by MinHash
+	shingleSize = 5
+)
+
+// createDuplicateTables creates the article_minhash table filled by ComputeMinHash
+func (w *Wiki) createDuplicateTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS article_minhash (
+			article_id INTEGER NOT NULL,
+			hash_slot INTEGER NOT NULL,
+			hash_value INTEGER NOT NULL,
+			PRIMARY KEY (article_id, hash_slot)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_article_minhash_slot ON article_minhash(hash_slot, hash_value)",
+		`CREATE TRIGGER IF NOT EXISTS articles_minhash_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM article_minhash WHERE article_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create article_minhash table: %w", err)
+		}
+	}
+	return nil
+}
+
+// contentHash is the content_hash column value: the SHA-256 of the wikitext
+func contentHash(content string) sql.NullString {
+	if content == "" {
+		return sql.NullString{}
+	}
+	sum := sha256.Sum256([]byte(content))
+	return sql.NullString{String: hex.EncodeToString(sum[:]), Valid: true}
+}
+
+// splitmix64 scrambles x; it derives the per-slot hash functions
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// minhashSignature computes the MinHash signature of the word shingles of
+// content, or nil when it has fewer words than a shingle
+func minhashSignature(content string) []uint64 {
+	words := ngramWordRegex.FindAllString(strings.ToLower(htmlCommentRegex.ReplaceAllString(content, "")), -1)
+	if len(words) < shingleSize {
+		return nil
+	}
+
+	signature := make([]uint64, minhashSlots)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+
+	h := fnv.New64a()
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h.Reset()
+		h.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		shingle := h.Sum64()
+		for slot := range signature {
+			if v := splitmix64(shingle ^ uint64(slot)*0x9e3779b97f4a7c15); v < signature[slot] {
+				signature[slot] = v
+			}
+		}
+	}
+	return signature
+}
+
+// ComputeMinHash replaces the MinHash signatures of all main namespace
+// articles, used by GetDuplicateContent to find near-duplicates. progress, if
+// not nil, is called with the number of articles processed so far.
+func (w *Wiki) ComputeMinHash(ctx context.Context, progress func(int64)) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.db.ExecContext(ctx, "DELETE FROM article_minhash"); err != nil {
+		return fmt.Errorf("failed to clear article_minhash: %w", err)
+	}
+
+	var processed int64
+	var lastID int64
+	for {
+		rows, err := w.db.QueryContext(ctx, `
+			SELECT id, COALESCE(content, content_compressed) FROM articles
+			WHERE id > ? AND namespace = 0 AND (redirect IS NULL OR redirect = '')
+			ORDER BY id
+			LIMIT 1000
+		`, lastID)
+		if err != nil {
+			return fmt.Errorf("failed to read articles: %w", err)
+		}
+
+		signatures := make(map[int64][]uint64)
+		batch := 0
+		for rows.Next() {
+			var content contentText
+			if err := rows.Scan(&lastID, &content); err != nil {
+				rows.Close()
+				return err
+			}
+			if signature := minhashSignature(string(content)); signature != nil {
+				signatures[lastID] = signature
+			}
+			batch++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if batch == 0 {
+			break
+		}
+
+		if err := w.storeMinHashes(ctx, signatures); err != nil {
+			return err
+		}
+
+		processed += int64(batch)
+		if progress != nil {
+			progress(processed)
+		}
+	}
+
+	return nil
+}
+
+// storeMinHashes inserts a batch of MinHash signatures
+func (w *Wiki) storeMinHashes(ctx context.Context, signatures map[int64][]uint64) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO article_minhash (article_id, hash_slot, hash_value) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for id, signature := range signatures {
+		for slot, value := range signature {
+			// SQLite integers are signed: store the bits as int64
+			if _, err := stmt.ExecContext(ctx, id, slot, int64(value)); err != nil {
+				return fmt.Errorf("failed to store MinHash of article %d: %w", id, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDuplicateContent returns pairs of main namespace articles whose content
+// is identical (score 1) or whose estimated Jaccard similarity of word
+// shingles is at least threshold. Near-duplicates require ComputeMinHash.
+func (w *Wiki) GetDuplicateContent(ctx context.Context, threshold float64, limit int) ([]DuplicatePair, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT a1.id, a2.id
+		FROM articles a1
+		JOIN articles a2 ON a1.content_hash = a2.content_hash AND a1.id < a2.id
+		WHERE a1.namespace = 0 AND a2.namespace = 0
+			AND (a1.redirect IS NULL OR a1.redirect = '')
+			AND (a2.redirect IS NULL OR a2.redirect = '')
+		ORDER BY a1.id, a2.id
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exact duplicates: %w", err)
+	}
+
+	pairs := []DuplicatePair{}
+	exact := make(map[[2]int64]bool)
+	for rows.Next() {
+		pair := DuplicatePair{SimilarityScore: 1}
+		if err := rows.Scan(&pair.ID1, &pair.ID2); err != nil {
+			continue
+		}
+		exact[[2]int64{pair.ID1, pair.ID2}] = true
+		pairs = append(pairs, pair)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(pairs) >= limit || threshold > 1 {
+		return pairs, nil
+	}
+
+	// The fraction of matching MinHash slots estimates the Jaccard similarity
+	rows, err = w.db.QueryContext(ctx, `
+		SELECT m1.article_id, m2.article_id, COUNT(*) * 1.0 / ? AS similarity
+		FROM article_minhash m1
+		JOIN article_minhash m2 ON m1.hash_slot = m2.hash_slot
+			AND m1.hash_value = m2.hash_value
+			AND m1.article_id < m2.article_id
+		GROUP BY m1.article_id, m2.article_id
+		HAVING similarity >= ?
+		ORDER BY similarity DESC, m1.article_id, m2.article_id
+		LIMIT ?
+	`, minhashSlots, threshold, limit+len(exact))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query near duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() && len(pairs) < limit {
+		var pair DuplicatePair
+		if err := rows.Scan(&pair.ID1, &pair.ID2, &pair.SimilarityScore); err != nil {
+			continue
+		}
+		if exact[[2]int64{pair.ID1, pair.ID2}] {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, rows.Err()
+}
@@ -0,0 +1,44 @@
+package wikipedia
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetDuplicateContent(t *testing.T) {
+	const text = "The quick brown fox jumps over the lazy dog near the river bank every morning."
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Fox", Text: text},
+		testPage{ID: 2, Title: "Fox (copy)", Text: text},
+		testPage{ID: 3, Title: "Stars", Text: "Distant suns burn with nuclear fusion across galaxies spanning billions of light years."},
+	)
+	ctx := context.Background()
+	if err := w.ComputeMinHash(ctx, nil); err != nil {
+		t.Fatalf("ComputeMinHash: %v", err)
+	}
+
+	// Identical articles score 1.0, and completely different ones share no
+	// MinHash slot, so even a threshold of 0 does not pair them
+	pairs, err := w.GetDuplicateContent(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("GetDuplicateContent: %v", err)
+	}
+	if len(pairs) != 1 || pairs[0] != (DuplicatePair{ID1: 1, ID2: 2, SimilarityScore: 1}) {
+		t.Errorf("GetDuplicateContent = %+v, want only articles 1 and 2 with a score of 1", pairs)
+	}
+
+	// The MinHash estimate of identical content is 1.0 too
+	first, second, other := minhashSignature(text), minhashSignature(text), minhashSignature("Distant suns burn with nuclear fusion across galaxies spanning billions of light years.")
+	same, shared := 0, 0
+	for i := range first {
+		if first[i] == second[i] {
+			same++
+		}
+		if first[i] == other[i] {
+			shared++
+		}
+	}
+	if same != minhashSlots || shared != 0 {
+		t.Errorf("got %d/%d matching slots for identical content and %d for different content, want %d and 0", same, minhashSlots, shared, minhashSlots)
+	}
+}
@@ -0,0 +1,133 @@
+package wikipedia
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DumpSizeEstimate is the expected disk usage of importing the dump files
+type DumpSizeEstimate struct {
+	IndexFileSizeBytes      int64 `json:"index_file_size_bytes"`
+	ArticlesFileSizeBytes   int64 `json:"articles_file_size_bytes"`
+	CompressedSizeBytes     int64 `json:"compressed_size_bytes"`
+	EstimatedUncompressedMB int64 `json:"estimated_uncompressed_mb"`
+	EstimatedDBSizeMB       int64 `json:"estimated_db_size_mb"`
+}
+
+const (
+	// estimateSampleStreams is the number of bzip2 streams decompressed to
+	// measure the compression ratio
+	estimateSampleStreams = 10
+
+	// dbSizeFactor is the database size relative to the uncompressed XML: the
+	// stored content plus its full-text index
+	dbSizeFactor = 1.5
+)
+
+// EstimateDumpSize estimates the uncompressed and imported size of the dump
+// without importing it. The compression ratio is measured on the first bzip2
+// streams of the multistream articles file, located through the index file.
+func (w *Wiki) EstimateDumpSize(ctx context.Context) (*DumpSizeEstimate, error) {
+	indexInfo, err := os.Stat(w.indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat index file: %w", err)
+	}
+	articlesInfo, err := os.Stat(w.articlesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat articles file: %w", err)
+	}
+
+	estimate := &DumpSizeEstimate{
+		IndexFileSizeBytes:    indexInfo.Size(),
+		ArticlesFileSizeBytes: articlesInfo.Size(),
+		CompressedSizeBytes:   indexInfo.Size() + articlesInfo.Size(),
+	}
+
+	offsets, err := w.streamOffsets(ctx, estimateSampleStreams+1)
+	if err != nil {
+		return nil, err
+	}
+	// The first stream starts at 0 and the last sampled one may end at EOF
+	if len(offsets) == 0 || offsets[0] != 0 {
+		offsets = append([]int64{0}, offsets...)
+	}
+	if len(offsets) <= estimateSampleStreams {
+		offsets = append(offsets, articlesInfo.Size())
+	}
+
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer f.Close()
+
+	var compressed, uncompressed int64
+	for i := 0; i+1 < len(offsets); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		section := io.NewSectionReader(f, offsets[i], offsets[i+1]-offsets[i])
+		n, err := io.Copy(io.Discard, bzip2.NewReader(section))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress stream at offset %d: %w", offsets[i], err)
+		}
+		compressed += offsets[i+1] - offsets[i]
+		uncompressed += n
+	}
+	if compressed == 0 {
+		return nil, fmt.Errorf("no bzip2 streams to sample in %s", w.articlesFile)
+	}
+
+	ratio := float64(uncompressed) / float64(compressed)
+	uncompressedBytes := float64(articlesInfo.Size()) * ratio
+	estimate.EstimatedUncompressedMB = int64(uncompressedBytes / (1024 * 1024))
+	estimate.EstimatedDBSizeMB = int64(uncompressedBytes * dbSizeFactor / (1024 * 1024))
+
+	return estimate, nil
+}
+
+// streamOffsets reads the first n distinct stream offsets from the index file
+func (w *Wiki) streamOffsets(ctx context.Context, n int) ([]int64, error) {
+	f, err := os.Open(w.indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 16*1024)
+	scanner.Buffer(buf, 16*1024)
+
+	var offsets []int64
+	for scanner.Scan() && len(offsets) < n {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		seek, err := strconv.ParseInt(strings.SplitN(scanner.Text(), ":", 2)[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(offsets) == 0 || seek > offsets[len(offsets)-1] {
+			offsets = append(offsets, seek)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return offsets, nil
+}
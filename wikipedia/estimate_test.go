@@ -0,0 +1,106 @@
+package wikipedia
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMultistreamDump writes a bzip2 multistream articles file of streams
+// streams of 100 pages each, with an uncompressed index file pointing to
+// them, using the bzip2 command. Page lengths vary at random so that sampled
+// streams differ from the others.
+func writeMultistreamDump(tb testing.TB, dir string, streams int) {
+	tb.Helper()
+
+	bzip2Path, err := exec.LookPath("bzip2")
+	if err != nil {
+		tb.Skip("bzip2 command not found")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"river", "mountain", "history", "music", "science", "city", "football", "novel", "battle", "island"}
+	var articles bytes.Buffer
+	var index strings.Builder
+	for stream := 0; stream < streams; stream++ {
+		var xmlText strings.Builder
+		if stream == 0 {
+			xmlText.WriteString("<mediawiki>\n")
+		}
+		offset := articles.Len()
+		for i := 0; i < 100; i++ {
+			id := stream*100 + i + 1
+			var text strings.Builder
+			for n := 200 + rng.Intn(400); n > 0; n-- {
+				text.WriteString(words[rng.Intn(len(words))])
+				text.WriteByte(' ')
+			}
+			fmt.Fprintf(&xmlText, "<page><title>Page %d</title><ns>0</ns><id>%d</id><revision><text>%s</text></revision></page>\n", id, id, text.String())
+			fmt.Fprintf(&index, "%d:%d:Page %d\n", offset, id, id)
+		}
+		if stream == streams-1 {
+			xmlText.WriteString("</mediawiki>\n")
+		}
+
+		cmd := exec.Command(bzip2Path, "-c")
+		cmd.Stdin = strings.NewReader(xmlText.String())
+		cmd.Stdout = &articles
+		if err := cmd.Run(); err != nil {
+			tb.Fatalf("bzip2: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "articles.xml.bz2"), articles.Bytes(), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.txt"), []byte(index.String()), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestEstimateDumpSize(t *testing.T) {
+	dir := t.TempDir()
+	writeMultistreamDump(t, dir, 24)
+	w := NewWiki(dir, "index.txt", "articles.xml.bz2")
+	w.SetLogger(testLogger)
+
+	estimate, err := w.EstimateDumpSize(context.Background())
+	if err != nil {
+		t.Fatalf("EstimateDumpSize: %v", err)
+	}
+
+	// Only the first 10 of the 24 streams are sampled
+	f, err := os.Open(filepath.Join(dir, "articles.xml.bz2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	exact, err := io.Copy(io.Discard, bzip2.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exactMB := float64(exact) / (1024 * 1024)
+	if exactMB < 5 {
+		t.Fatalf("the test dump holds %.1f MB, too little to compare estimates in MB", exactMB)
+	}
+
+	if got := float64(estimate.EstimatedUncompressedMB); math.Abs(got-exactMB) > 0.2*exactMB {
+		t.Errorf("estimated %v MB uncompressed, want within 20%% of %.1f MB", got, exactMB)
+	}
+	if got, want := float64(estimate.EstimatedDBSizeMB), exactMB*dbSizeFactor; math.Abs(got-want) > 0.2*want {
+		t.Errorf("estimated a database of %v MB, want within 20%% of %.1f MB", got, want)
+	}
+	if estimate.CompressedSizeBytes != estimate.IndexFileSizeBytes+estimate.ArticlesFileSizeBytes {
+		t.Errorf("compressed size %d is not the sum of the file sizes %d and %d",
+			estimate.CompressedSizeBytes, estimate.IndexFileSizeBytes, estimate.ArticlesFileSizeBytes)
+	}
+}
@@ -0,0 +1,152 @@
+package wikipedia
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// exportFlushBytes is how much output is buffered between explicit flushes
+const exportFlushBytes = 10 * 1024 * 1024
+
+// exportArticle serializes an article for exports, leaving out the content
+// when it was not requested
+type exportArticle struct {
+	*Article
+	Content string `json:"content,omitempty"`
+}
+
+// forEachExportedArticle calls fn for every article in ID order. The content
+// is only read from the database when includeContent is set.
+func (w *Wiki) forEachExportedArticle(ctx context.Context, includeContent bool, fn func(*Article) error) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT id, title, namespace, CASE WHEN ? THEN COALESCE(content, content_compressed) ELSE '' END, redirect,
+			COALESCE(revision_id, ''), COALESCE(revision_timestamp, ''),
+			COALESCE(contributor_name, ''), COALESCE(contributor_id, ''), COALESCE(word_count, 0),
+			COALESCE(truncated, 0)
+		FROM articles
+		ORDER BY id
+	`, includeContent)
+	if err != nil {
+		return fmt.Errorf("failed to query articles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var article Article
+		if err := rows.Scan(article.scanFields()...); err != nil {
+			return fmt.Errorf("failed to read article: %w", err)
+		}
+		if err := fn(&article); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportNDJSON writes every article to out as one JSON object per line and
+// returns the number of articles written. Content is only included when
+// includeContent is set.
+func (w *Wiki) ExportNDJSON(ctx context.Context, out io.Writer, includeContent bool) (int64, error) {
+	buf := bufio.NewWriter(out)
+	var count, unflushed int64
+
+	err := w.forEachExportedArticle(ctx, includeContent, func(article *Article) error {
+		line, err := json.Marshal(exportArticle{Article: article, Content: article.Content})
+		if err != nil {
+			return err
+		}
+		if _, err := buf.Write(append(line, '\n')); err != nil {
+			return err
+		}
+
+		count++
+		unflushed += int64(len(line)) + 1
+		if unflushed >= exportFlushBytes {
+			unflushed = 0
+			return buf.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, buf.Flush()
+}
+
+// csvMaxContentBytes keeps exported content within typical spreadsheet cell
+// limits
+const csvMaxContentBytes = 64 * 1024
+
+// csvContent HTML-escapes content and truncates it to csvMaxContentBytes,
+// without cutting an entity or a UTF-8 sequence in half
+func csvContent(content string) string {
+	escaped := html.EscapeString(content)
+	if len(escaped) <= csvMaxContentBytes {
+		return escaped
+	}
+
+	cut := escaped[:csvMaxContentBytes]
+	if i := strings.LastIndexByte(cut, '&'); i >= 0 && !strings.Contains(cut[i:], ";") {
+		cut = cut[:i]
+	}
+	// Drop the bytes of a trailing incomplete UTF-8 sequence
+	for len(cut) > 0 {
+		r, size := utf8.DecodeLastRuneInString(cut)
+		if r != utf8.RuneError || size != 1 {
+			break
+		}
+		cut = cut[:len(cut)-1]
+	}
+	return cut
+}
+
+// ExportCSV writes the id, title, namespace and redirect of every article to
+// out as CSV, after a header row. The content is added as a last column when
+// includeContent is set.
+func (w *Wiki) ExportCSV(out io.Writer, includeContent bool) error {
+	writer := csv.NewWriter(out)
+
+	header := []string{"id", "title", "namespace", "redirect"}
+	if includeContent {
+		header = append(header, "content")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := w.forEachExportedArticle(context.Background(), includeContent, func(article *Article) error {
+		record := []string{
+			strconv.FormatInt(article.ID, 10),
+			article.Title,
+			strconv.Itoa(article.Namespace),
+			article.Redirect,
+		}
+		if includeContent {
+			record = append(record, csvContent(article.Content))
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
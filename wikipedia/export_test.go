@@ -0,0 +1,80 @@
+package wikipedia
+
+import (
+	"bytes"
+	"encoding/csv"
+	"html"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestExportCSV(t *testing.T) {
+	pages := []testPage{
+		{ID: 1, Title: "Physics", Text: "The science of matter."},
+		{ID: 2, Title: `Hello, "World"`, Text: "Line one,\n\"quoted\" line two & <b>bold</b>."},
+		{ID: 3, Title: "Talk:Physics", NS: 1, Text: "Discussion."},
+		{ID: 4, Title: "Matter", Redirect: "Physics", Text: "#REDIRECT [[Physics]]"},
+	}
+	w := newTestWiki(t, pages...)
+
+	var buf bytes.Buffer
+	if err := w.ExportCSV(&buf, true); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing the exported CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"id", "title", "namespace", "redirect", "content"},
+		{"1", "Physics", "0", "", pages[0].Text},
+		{"2", `Hello, "World"`, "0", "", pages[1].Text},
+		{"3", "Talk:Physics", "1", "", pages[2].Text},
+		{"4", "Matter", "0", "Physics", pages[3].Text},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("ExportCSV wrote %d records, want %d: %q", len(records), len(want), records)
+	}
+	for i, record := range records {
+		// The content is HTML-escaped
+		if i > 0 && len(record) == 5 {
+			record[4] = html.UnescapeString(record[4])
+		}
+		if !slices.Equal(record, want[i]) {
+			t.Errorf("record %d = %q, want %q", i, record, want[i])
+		}
+	}
+
+	buf.Reset()
+	if err := w.ExportCSV(&buf, false); err != nil {
+		t.Fatalf("ExportCSV without content: %v", err)
+	}
+	records, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing the exported CSV: %v", err)
+	}
+	for i, record := range records {
+		if !slices.Equal(record, want[i][:4]) {
+			t.Errorf("record %d without content = %q, want %q", i, record, want[i][:4])
+		}
+	}
+}
+
+func TestCSVContent(t *testing.T) {
+	// An entity and a 2-byte rune straddle the limit
+	for _, content := range []string{
+		strings.Repeat("a", csvMaxContentBytes-2) + "&b",
+		strings.Repeat("a", csvMaxContentBytes-1) + "é",
+	} {
+		got := csvContent(content)
+		if len(got) > csvMaxContentBytes {
+			t.Errorf("csvContent returned %d bytes, want at most %d", len(got), csvMaxContentBytes)
+		}
+		if !utf8.ValidString(got) || got != strings.Repeat("a", len(got)) {
+			t.Errorf("csvContent kept a partial entity or rune: ends with %q", got[len(got)-8:])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ExternalLink is a link from an article to a page outside the wiki
+type ExternalLink struct {
+	URL    string `json:"url"`
+	Anchor string `json:"anchor,omitempty"`
+}
+
+var (
+	// [https://example.com Anchor text], [//example.com] or [mailto:x@example.com]
+	bracketedURLRegex = regexp.MustCompile(`(?i)\[((?:https?:|ftp:)?//[^\s\[\]]+|mailto:[^\s\[\]]+)(?:\s+([^\]]*))?\]`)
+	// Bare URLs are only linked for these schemes, as in MediaWiki
+	bareURLRegex   = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s\[\]<>{}|"]+`)
+	urlSchemeRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// createExternalLinkTables creates the external_links table and a trigger
+// dropping the links of deleted articles
+func (w *Wiki) createExternalLinkTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS external_links (
+			article_id INTEGER NOT NULL,
+			url TEXT NOT NULL,
+			anchor TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (article_id, url)
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS articles_external_links_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM external_links WHERE article_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create external_links table: %w", err)
+		}
+	}
+	return nil
+}
+
+// WithMailtoLinks makes ProcessArticles also store mailto: links in the
+// external_links table; they are skipped by default
+func (w *Wiki) WithMailtoLinks(enabled bool) *Wiki {
+	w.mailtoLinks = enabled
+	return w
+}
+
+// normalizeURL trims an external link and lowercases its scheme
+func normalizeURL(url string) string {
+	url = strings.TrimSpace(url)
+	if scheme := urlSchemeRegex.FindString(url); scheme != "" {
+		url = strings.ToLower(scheme) + url[len(scheme):]
+	}
+	return url
+}
+
+// extractExternalLinks returns the distinct external links of an article, in
+// bracketed form ([https://example.com Anchor]) or as bare URLs. Trailing
+// punctuation is not part of a bare URL, and mailto: links are skipped
+// unless includeMailto is set.
+func extractExternalLinks(content string, includeMailto bool) []ExternalLink {
+	seen := make(map[string]bool)
+	links := []ExternalLink{}
+	add := func(url, anchor string) {
+		url = normalizeURL(url)
+		if url == "" || seen[url] || (!includeMailto && strings.HasPrefix(url, "mailto:")) {
+			return
+		}
+		seen[url] = true
+		links = append(links, ExternalLink{URL: url, Anchor: strings.TrimSpace(anchor)})
+	}
+
+	// Blank the bracketed links so their URLs are not found again as bare URLs
+	masked := []byte(maskNowiki(content))
+	for _, loc := range bracketedURLRegex.FindAllSubmatchIndex(masked, -1) {
+		anchor := ""
+		if loc[4] >= 0 {
+			anchor = string(masked[loc[4]:loc[5]])
+		}
+		add(string(masked[loc[2]:loc[3]]), anchor)
+		for i := loc[0]; i < loc[1]; i++ {
+			masked[i] = ' '
+		}
+	}
+
+	for _, url := range bareURLRegex.FindAll(masked, -1) {
+		add(strings.TrimRight(string(url), ".,;:!?)'"), "")
+	}
+	return links
+}
+
+// storeExternalLinks replaces the stored external links of an article
+func storeExternalLinks(ctx context.Context, db execer, id int64, content string, includeMailto bool) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM external_links WHERE article_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear external links: %w", err)
+	}
+	for _, link := range extractExternalLinks(content, includeMailto) {
+		if _, err := db.ExecContext(ctx, "INSERT OR IGNORE INTO external_links (article_id, url, anchor) VALUES (?, ?, ?)", id, link.URL, link.Anchor); err != nil {
+			return fmt.Errorf("failed to store external link: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetArticleExternalLinks returns the external links of an article: its
+// bracketed links, then its bare URLs, each in wikitext order
+func (w *Wiki) GetArticleExternalLinks(id int64) ([]ExternalLink, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.Query("SELECT url, anchor FROM external_links WHERE article_id = ? ORDER BY rowid", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query external links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []ExternalLink{}
+	for rows.Next() {
+		var link ExternalLink
+		if err := rows.Scan(&link.URL, &link.Anchor); err != nil {
+			continue
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
@@ -0,0 +1,157 @@
+package wikipedia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FederatedWiki searches several wikipedia_sqlite servers, each holding a
+// shard of the database, and merges their results
+type FederatedWiki struct {
+	peers  []string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewFederatedWiki creates a FederatedWiki querying the given peer base URLs
+// (e.g. "http://host1:9096"). Each peer request is bounded by timeout.
+func NewFederatedWiki(peers []string, timeout time.Duration) *FederatedWiki {
+	cleaned := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		peer = strings.TrimRight(strings.TrimSpace(peer), "/")
+		if peer != "" {
+			cleaned = append(cleaned, peer)
+		}
+	}
+
+	return &FederatedWiki{
+		peers:  cleaned,
+		client: &http.Client{Timeout: timeout},
+		logger: slog.Default(),
+	}
+}
+
+// SetLogger sets the logger of the peer failures, the default slog logger
+// when l is nil. It must be called before the FederatedWiki is used.
+func (f *FederatedWiki) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.Default()
+	}
+	f.logger = l
+}
+
+// Peers returns the configured peer base URLs
+func (f *FederatedWiki) Peers() []string {
+	return f.peers
+}
+
+// FederatedSearch sends the query to every peer concurrently, deduplicates the
+// hits by article ID and returns the best-scoring limit results. Peers that fail
+// are logged and skipped; an error is returned only when every peer fails.
+func (f *FederatedWiki) FederatedSearch(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if len(f.peers) == 0 {
+		return nil, fmt.Errorf("no peers configured")
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	type peerResponse struct {
+		peer    string
+		results []SearchResult
+		err     error
+	}
+
+	responses := make(chan peerResponse, len(f.peers))
+	var wg sync.WaitGroup
+	for _, peer := range f.peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			results, err := f.searchPeer(ctx, peer, query, limit)
+			responses <- peerResponse{peer: peer, results: results, err: err}
+		}(peer)
+	}
+	wg.Wait()
+	close(responses)
+
+	best := make(map[int64]SearchResult)
+	var lastErr error
+	failed := 0
+	for resp := range responses {
+		if resp.err != nil {
+			f.logger.Warn("Federated search: peer failed", "peer", resp.peer, "error", resp.err)
+			lastErr = resp.err
+			failed++
+			continue
+		}
+		for _, result := range resp.results {
+			if existing, ok := best[result.ID]; !ok || result.Score > existing.Score {
+				best[result.ID] = result
+			}
+		}
+	}
+
+	if failed == len(f.peers) {
+		return nil, fmt.Errorf("all peers failed: %w", lastErr)
+	}
+
+	merged := make([]SearchResult, 0, len(best))
+	for _, result := range best {
+		merged = append(merged, result)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].Score != merged[j].Score {
+			return merged[i].Score > merged[j].Score
+		}
+		return merged[i].Title < merged[j].Title
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// searchPeer queries a single peer's /api/search endpoint with ranked=true,
+// whose hits carry the BM25 score the results are merged by
+func (f *FederatedWiki) searchPeer(ctx context.Context, peer, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("ranked", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var body struct {
+		Articles []SearchResult `json:"articles"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return body.Articles, nil
+}
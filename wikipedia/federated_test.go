@@ -0,0 +1,89 @@
+package wikipedia
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestPeer serves articles as the ranked /api/search response of a peer
+func newTestPeer(t *testing.T, articles []RankedArticle) *httptest.Server {
+	t.Helper()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search" || r.URL.Query().Get("ranked") != "true" {
+			http.Error(w, "want a ranked /api/search request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"query":    r.URL.Query().Get("q"),
+			"articles": articles,
+		})
+	}))
+	t.Cleanup(peer.Close)
+	return peer
+}
+
+func rankedArticle(id int64, title string, score float64) RankedArticle {
+	return RankedArticle{Article: Article{ID: id, Title: title}, Score: score}
+}
+
+func TestFederatedSearch(t *testing.T) {
+	first := newTestPeer(t, []RankedArticle{
+		rankedArticle(1, "Physics", 9.5),
+		rankedArticle(2, "Chemistry", 2),
+		rankedArticle(3, "Biology", 4),
+	})
+	second := newTestPeer(t, []RankedArticle{
+		rankedArticle(3, "Biology", 7),
+		rankedArticle(4, "Astronomy", 8),
+	})
+
+	f := NewFederatedWiki([]string{first.URL, second.URL + "/"}, time.Second)
+	f.SetLogger(testLogger)
+
+	results, err := f.FederatedSearch(context.Background(), "science", 3)
+	if err != nil {
+		t.Fatalf("FederatedSearch: %v", err)
+	}
+	want := []SearchResult{
+		{ID: 1, Title: "Physics", Score: 9.5},
+		{ID: 4, Title: "Astronomy", Score: 8},
+		{ID: 3, Title: "Biology", Score: 7},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %v, want %v", results, want)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("result %d: got %v, want %v", i, results[i], want[i])
+		}
+	}
+}
+
+func TestFederatedSearchFailingPeers(t *testing.T) {
+	peer := newTestPeer(t, []RankedArticle{rankedArticle(1, "Physics", 1)})
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	f := NewFederatedWiki([]string{peer.URL, down.URL}, time.Second)
+	f.SetLogger(testLogger)
+	results, err := f.FederatedSearch(context.Background(), "physics", 10)
+	if err != nil {
+		t.Fatalf("FederatedSearch with one failing peer: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Physics" {
+		t.Errorf("got %v, want the Physics hit of the working peer", results)
+	}
+
+	f = NewFederatedWiki([]string{down.URL}, time.Second)
+	f.SetLogger(testLogger)
+	if _, err := f.FederatedSearch(context.Background(), "physics", 10); err == nil {
+		t.Error("FederatedSearch succeeded with every peer failing")
+	}
+}
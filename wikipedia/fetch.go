@@ -0,0 +1,117 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FetchArticleFromDump reads an article straight from the articles file,
+// without writing it to the database: the index gives the offset of the
+// bzip2 stream holding the page, and only that stream is decompressed. This
+// serves articles missing from the database, e.g. outside the processed
+// namespaces, and needs the index to be loaded.
+func (w *Wiki) FetchArticleFromDump(id int64) (*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	// The stream ends where the next one starts, or at the end of the file
+	w.mu.RLock()
+	var seek int64
+	var next sql.NullInt64
+	err := w.db.QueryRow(`
+		SELECT seek, (SELECT MIN(seek) FROM index_entries WHERE seek > e.seek)
+		FROM index_entries e
+		WHERE article_id = ?
+	`, id).Scan(&seek, &next)
+	w.mu.RUnlock()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no index entry for article %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up index entry: %w", err)
+	}
+
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer f.Close()
+
+	end := next.Int64
+	if !next.Valid {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat articles file: %w", err)
+		}
+		end = info.Size()
+	}
+
+	var article *Article
+	err = decodeStreamPages(f, seek, end, func(page *Page) bool {
+		if int64(page.ID) != id {
+			return true
+		}
+		article = &Article{
+			ID:                int64(page.ID),
+			Title:             page.Title,
+			Namespace:         page.NS,
+			Content:           page.Text,
+			RevisionID:        page.RevisionID,
+			RevisionTimestamp: page.Timestamp,
+			ContributorName:   page.Username,
+			ContributorID:     page.UserID,
+			WordCount:         len(strings.Fields(page.Text)),
+		}
+		if len(page.Redirect) > 0 {
+			article.Redirect = page.Redirect[0].Title
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if article != nil {
+		return article, nil
+	}
+
+	return nil, fmt.Errorf("%w: article %d is not in the stream at offset %d", ErrArticleNotFound, id, seek)
+}
+
+// decodeStreamPages decompresses the bzip2 stream of f between seek and end
+// and calls fn for each of its pages until fn returns false
+func decodeStreamPages(f *os.File, seek, end int64, fn func(*Page) bool) error {
+	r, err := newStreamReader(io.NewSectionReader(f, seek, end-seek))
+	if err != nil {
+		return fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	// The first stream also holds the unclosed <mediawiki> root and siteinfo,
+	// so pages are decoded one by one from the token stream
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// The end of the stream cuts the document short
+			return nil
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "page" {
+			continue
+		}
+
+		var page Page
+		if err := decoder.DecodeElement(&page, &start); err != nil {
+			return fmt.Errorf("failed to decode page: %w", err)
+		}
+		if !fn(&page) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ping checks that the database answers a trivial query
+func (w *Wiki) Ping(ctx context.Context) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var one int
+	if err := w.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("database is not responding: %w", err)
+	}
+	return nil
+}
+
+// FTSVersion returns the full-text search engine in use: "fts5", "fts4" or
+// "none"
+func (w *Wiki) FTSVersion() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.ftsVersion
+}
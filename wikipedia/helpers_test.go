@@ -0,0 +1,118 @@
+package wikipedia
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testLogger discards the import and maintenance messages of test wikis
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// testPage is a page of the dumps written by writeTestDump
+type testPage struct {
+	ID        int
+	Title     string
+	NS        int
+	Redirect  string
+	Text      string
+	Timestamp string // 2024-01-01T00:00:00Z when empty
+}
+
+// xmlEscape escapes s for use in XML text and attributes
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeTestDump writes pages as an uncompressed XML dump to path
+func writeTestDump(tb testing.TB, path string, pages []testPage) {
+	tb.Helper()
+
+	var b strings.Builder
+	b.WriteString("<mediawiki>\n")
+	for _, page := range pages {
+		timestamp := page.Timestamp
+		if timestamp == "" {
+			timestamp = "2024-01-01T00:00:00Z"
+		}
+		fmt.Fprintf(&b, "<page><title>%s</title><ns>%d</ns><id>%d</id>", xmlEscape(page.Title), page.NS, page.ID)
+		if page.Redirect != "" {
+			fmt.Fprintf(&b, `<redirect title="%s" />`, xmlEscape(page.Redirect))
+		}
+		fmt.Fprintf(&b, "<revision><id>%d</id><timestamp>%s</timestamp><contributor><username>Tester</username><id>1</id></contributor>",
+			1000+page.ID, timestamp)
+		fmt.Fprintf(&b, "<text>%s</text></revision></page>\n", xmlEscape(page.Text))
+	}
+	b.WriteString("</mediawiki>\n")
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// newTestDump returns a Wiki on an empty temporary database whose articles
+// file holds pages, to be configured before importing them with
+// ProcessArticlesSequential. Every namespace of pages is processed, and the
+// database is closed at the end of the test.
+func newTestDump(tb testing.TB, pages ...testPage) *Wiki {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	writeTestDump(tb, filepath.Join(dir, "articles.xml"), pages)
+
+	namespaces := []int{0}
+	for _, page := range pages {
+		namespaces = append(namespaces, page.NS)
+	}
+	w := NewWiki(dir, "index.txt", "articles.xml").WithNamespaces(namespaces...)
+	w.SetLogger(testLogger)
+	tb.Cleanup(func() { w.Close() })
+	return w
+}
+
+// newTestWiki returns a Wiki on a temporary database holding pages
+func newTestWiki(tb testing.TB, pages ...testPage) *Wiki {
+	tb.Helper()
+
+	w := newTestDump(tb, pages...)
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		tb.Fatalf("failed to import test pages: %v", err)
+	}
+	return w
+}
+
+// Files of the fixture dump of testdata, see testdata/generate_fixture.go
+const (
+	fixtureIndexFile    = "fixture-index.txt.bz2"
+	fixtureArticlesFile = "fixture-articles.xml.bz2"
+)
+
+// newFixtureWiki returns a Wiki on an empty temporary database reading the
+// fixture dump, which has 90 main namespace pages (5 of them redirects), 5
+// templates and 5 categories
+func newFixtureWiki(tb testing.TB) *Wiki {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	for _, name := range []string{fixtureIndexFile, fixtureArticlesFile} {
+		data, err := os.ReadFile(filepath.Join("..", "testdata", name))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	w := NewWiki(dir, fixtureIndexFile, fixtureArticlesFile)
+	w.SetLogger(testLogger)
+	tb.Cleanup(func() { w.Close() })
+	return w
+}
@@ -0,0 +1,52 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Revision is the metadata of one revision of an article
+type Revision struct {
+	RevisionID      string `json:"revision_id"`
+	Timestamp       string `json:"timestamp"`
+	ContributorName string `json:"contributor_name,omitempty"`
+	ContributorID   string `json:"contributor_id,omitempty"`
+}
+
+// GetArticleHistory lists the known revisions of an article. Dumps only hold
+// the latest revision, so at most one is returned; the list is empty for
+// articles imported before revision metadata was stored.
+func (w *Wiki) GetArticleHistory(ctx context.Context, id int64) ([]Revision, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var revisionID, timestamp, contributorName, contributorID sql.NullString
+	err := w.db.QueryRowContext(ctx, `
+		SELECT revision_id, revision_timestamp, contributor_name, contributor_id
+		FROM articles
+		WHERE id = ?
+	`, id).Scan(&revisionID, &timestamp, &contributorName, &contributorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get article history: %w", err)
+	}
+
+	revisions := []Revision{}
+	if revisionID.Valid || timestamp.Valid {
+		revisions = append(revisions, Revision{
+			RevisionID:      revisionID.String,
+			Timestamp:       timestamp.String,
+			ContributorName: contributorName.String,
+			ContributorID:   contributorID.String,
+		})
+	}
+	return revisions, nil
+}
@@ -0,0 +1,369 @@
+package wikipedia
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrImportRunNotFound is returned when rolling back an unknown import run
+var ErrImportRunNotFound = errors.New("import run not found")
+
+// ImportRun describes one ProcessArticles run
+type ImportRun struct {
+	RunID           string `json:"run_id"`
+	StartedAt       string `json:"started_at"`
+	FinishedAt      string `json:"finished_at,omitempty"`
+	ArticlesAdded   int    `json:"articles_added"`
+	ArticlesUpdated int    `json:"articles_updated"`
+	DumpDate        string `json:"dump_date,omitempty"`
+}
+
+// RollbackResult reports what RollbackImportRun undid
+type RollbackResult struct {
+	RunID            string `json:"run_id"`
+	ArticlesDeleted  int    `json:"articles_deleted"`
+	ArticlesRestored int    `json:"articles_restored"`
+}
+
+// dumpDateRegex extracts the YYYYMMDD date from dump file names such as
+// enwiki-20240101-pages-articles-multistream.xml.bz2
+var dumpDateRegex = regexp.MustCompile(`\d{8}`)
+
+// versionColumns are the articles columns saved in content_versions besides
+// the title, namespace, content and redirect, restored as they were by
+// RollbackImportRun; the derived columns are recomputed from the content
+var versionColumns = []string{"content_compressed", "plain_text", "revision_id", "revision_timestamp", "contributor_name", "contributor_id", "truncated"}
+
+// createImportTables creates the import run bookkeeping tables: every article
+// written by a run gets an import_audit row, and articles that already existed
+// get their previous state saved in content_versions
+func (w *Wiki) createImportTables() error {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS import_runs (
+			run_id TEXT PRIMARY KEY,
+			started_at DATETIME,
+			finished_at DATETIME,
+			articles_added INTEGER DEFAULT 0,
+			articles_updated INTEGER DEFAULT 0,
+			dump_date TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS import_audit (
+			run_id TEXT NOT NULL,
+			article_id INTEGER NOT NULL,
+			action TEXT NOT NULL,
+			change_score REAL,
+			PRIMARY KEY (run_id, article_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS content_versions (
+			run_id TEXT NOT NULL,
+			article_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			namespace INTEGER NOT NULL,
+			content TEXT,
+			redirect TEXT,
+			PRIMARY KEY (run_id, article_id)
+		)`,
+	}
+
+	for _, table := range tables {
+		if _, err := w.db.Exec(table); err != nil {
+			return fmt.Errorf("failed to create import tables: %w", err)
+		}
+	}
+
+	// Columns added after the initial schema
+	columns := []struct{ table, name, definition string }{
+		{"import_audit", "change_score", "REAL"},
+		{"content_versions", "content_compressed", "BLOB"},
+		{"content_versions", "plain_text", "TEXT"},
+		{"content_versions", "revision_id", "TEXT"},
+		{"content_versions", "revision_timestamp", "TEXT"},
+		{"content_versions", "contributor_name", "TEXT"},
+		{"content_versions", "contributor_id", "TEXT"},
+		{"content_versions", "truncated", "BOOLEAN DEFAULT 0"},
+	}
+	for _, column := range columns {
+		if err := w.ensureColumn(column.table, column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importRun tracks the articles written by a single ProcessArticles call
+type importRun struct {
+	id          string
+	added       int
+	updated     int
+	currentStmt *sql.Stmt
+	versionStmt *sql.Stmt
+	auditStmt   *sql.Stmt
+}
+
+// beginImportRun registers a new import run
+func (w *Wiki) beginImportRun() (*importRun, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate run ID: %w", err)
+	}
+	run := &importRun{
+		id: time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix),
+	}
+
+	dumpDate := dumpDateRegex.FindString(filepath.Base(w.articlesFile))
+	_, err := w.db.Exec(`
+		INSERT INTO import_runs (run_id, started_at, dump_date)
+		VALUES (?, CURRENT_TIMESTAMP, ?)
+	`, run.id, dumpDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register import run: %w", err)
+	}
+
+	return run, nil
+}
+
+// prepare prepares the audit statements on a new transaction
+func (run *importRun) prepare(tx *sql.Tx) error {
+	var err error
+	run.currentStmt, err = tx.Prepare("SELECT title, namespace, COALESCE(content, content_compressed), redirect FROM articles WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare lookup statement: %w", err)
+	}
+
+	// The content is saved decompressed to recompute the derived columns on
+	// rollback, and content_compressed as is to restore the stored form
+	run.versionStmt, err = tx.Prepare(`
+		INSERT OR IGNORE INTO content_versions (run_id, article_id, title, namespace, content, redirect, ` + strings.Join(versionColumns, ", ") + `)
+		SELECT ?, id, title, namespace, ?, redirect, ` + strings.Join(versionColumns, ", ") + `
+		FROM articles WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare version statement: %w", err)
+	}
+
+	run.auditStmt, err = tx.Prepare("INSERT OR IGNORE INTO import_audit (run_id, article_id, action, change_score) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare audit statement: %w", err)
+	}
+	return nil
+}
+
+// record saves the pre-run state of an article and how much content changes;
+// it must be called before the article is written
+func (run *importRun) record(id int64, content string) error {
+	var title, redirect string
+	var namespace int
+	var oldContent contentText
+	err := run.currentStmt.QueryRow(id).Scan(&title, &namespace, &oldContent, &redirect)
+
+	action := "added"
+	var changeScore sql.NullFloat64
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	default:
+		action = "updated"
+		changeScore = sql.NullFloat64{Float64: ComputeChangeScore(string(oldContent), content), Valid: true}
+		if _, err := run.versionStmt.Exec(run.id, string(oldContent), id); err != nil {
+			return err
+		}
+	}
+
+	res, err := run.auditStmt.Exec(run.id, id, action, changeScore)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		if action == "added" {
+			run.added++
+		} else {
+			run.updated++
+		}
+	}
+	return nil
+}
+
+// restoreDerivedColumns recomputes the wikitext-derived columns and links of
+// the articles restored from content_versions
+func (w *Wiki) restoreDerivedColumns(ctx context.Context, tx *sql.Tx, runID string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT article_id, content FROM content_versions WHERE run_id = ?", runID)
+	if err != nil {
+		return fmt.Errorf("failed to read restored articles: %w", err)
+	}
+
+	type restored struct {
+		id      int64
+		content string
+	}
+	var articles []restored
+	for rows.Next() {
+		var a restored
+		var content sql.NullString
+		if err := rows.Scan(&a.id, &content); err != nil {
+			continue
+		}
+		a.content = content.String
+		articles = append(articles, a)
+	}
+	rows.Close()
+
+	update := "UPDATE articles SET " + strings.Join(derivedColumns, " = ?, ") + " = ? WHERE id = ?"
+	for _, a := range articles {
+		if _, err := tx.ExecContext(ctx, update, append(derivedValues(a.content), a.id)...); err != nil {
+			return fmt.Errorf("failed to restore derived columns of article %d: %w", a.id, err)
+		}
+		if err := w.storeDerivedRows(ctx, tx, a.id, a.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishImportRun stores the final counts of an import run
+func (w *Wiki) finishImportRun(run *importRun) error {
+	_, err := w.db.Exec(`
+		UPDATE import_runs
+		SET finished_at = CURRENT_TIMESTAMP, articles_added = ?, articles_updated = ?
+		WHERE run_id = ?
+	`, run.added, run.updated, run.id)
+	if err != nil {
+		return fmt.Errorf("failed to finish import run: %w", err)
+	}
+	return nil
+}
+
+// ListImportRuns returns all recorded import runs, newest first
+func (w *Wiki) ListImportRuns(ctx context.Context) ([]ImportRun, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT run_id, started_at, finished_at, articles_added, articles_updated, dump_date
+		FROM import_runs
+		ORDER BY rowid DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query import runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []ImportRun{}
+	for rows.Next() {
+		var run ImportRun
+		var startedAt, finishedAt, dumpDate sql.NullString
+		if err := rows.Scan(&run.RunID, &startedAt, &finishedAt, &run.ArticlesAdded, &run.ArticlesUpdated, &dumpDate); err != nil {
+			continue
+		}
+		run.StartedAt = startedAt.String
+		run.FinishedAt = finishedAt.String
+		run.DumpDate = dumpDate.String
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// RollbackImportRun undoes a ProcessArticles run: articles it added are
+// deleted and articles it updated are restored from content_versions. Runs
+// that touched the same articles should be rolled back newest first.
+func (w *Wiki) RollbackImportRun(ctx context.Context, runID string) (*RollbackResult, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM import_runs WHERE run_id = ?", runID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up import run: %w", err)
+	}
+	if exists == 0 {
+		return nil, ErrImportRunNotFound
+	}
+
+	result := &RollbackResult{RunID: runID}
+
+	// The delete triggers drop the links, categories and other rows extracted
+	// from the added articles; their word frequencies have no trigger
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM word_frequencies
+		WHERE article_id IN (SELECT article_id FROM import_audit WHERE run_id = ? AND action = 'added')
+	`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete word frequencies of added articles: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM articles
+		WHERE id IN (SELECT article_id FROM import_audit WHERE run_id = ? AND action = 'added')
+	`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete added articles: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		result.ArticlesDeleted = int(n)
+	}
+
+	// The content column stays NULL for articles stored compressed
+	assignments := make([]string, 0, len(versionColumns))
+	for _, column := range versionColumns {
+		assignments = append(assignments, column+" = v."+column)
+	}
+	res, err = tx.ExecContext(ctx, `
+		UPDATE articles
+		SET title = v.title, namespace = v.namespace, redirect = v.redirect,
+			content = CASE WHEN v.content_compressed IS NULL THEN v.content END,
+			`+strings.Join(assignments, ", ")+`
+		FROM content_versions v
+		WHERE v.run_id = ? AND v.article_id = articles.id
+	`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore updated articles: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		result.ArticlesRestored = int(n)
+	}
+
+	if err := w.restoreDerivedColumns(ctx, tx, runID); err != nil {
+		return nil, err
+	}
+
+	cleanup := []string{
+		"DELETE FROM content_versions WHERE run_id = ?",
+		"DELETE FROM import_audit WHERE run_id = ?",
+		"DELETE FROM import_runs WHERE run_id = ?",
+	}
+	for _, query := range cleanup {
+		if _, err := tx.ExecContext(ctx, query, runID); err != nil {
+			return nil, fmt.Errorf("failed to remove import run records: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback: %w", err)
+	}
+
+	w.cache.purge()
+	w.invalidateAdjacencyList()
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// queryRows returns the rows of query formatted as strings, to compare the
+// contents of tables
+func queryRows(tb testing.TB, db *retryDB, query string) []string {
+	tb.Helper()
+
+	rows, err := db.Query(query)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var result []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			tb.Fatal(err)
+		}
+		result = append(result, fmt.Sprintf("%q", values))
+	}
+	if err := rows.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return result
+}
+
+// articleRows lists every stored column of the articles
+const articleRows = `
+	SELECT id, title, namespace, content, content_compressed, redirect, plain_text,
+		revision_id, revision_timestamp, contributor_name, contributor_id, truncated,
+		infobox_data, birth_year, death_year, is_living, content_hash, word_count
+	FROM articles ORDER BY id`
+
+func TestRollbackImportRun(t *testing.T) {
+	for _, compression := range []string{"", CompressionGzip} {
+		t.Run("compression="+compression, func(t *testing.T) {
+			var pages []testPage
+			for id := 1; id <= 5; id++ {
+				pages = append(pages, testPage{ID: id, Title: fmt.Sprintf("Article %d", id),
+					Text: fmt.Sprintf("'''Article %d''' links to [[Article %d]].\n[[Category:Old]]", id, id%5+1)})
+			}
+			w := newTestDump(t, pages...).WithCompression(compression).WithStripText(true)
+			if err := w.ProcessArticlesSequential(-1); err != nil {
+				t.Fatalf("first import: %v", err)
+			}
+
+			before := map[string][]string{
+				"articles":   queryRows(t, w.db, articleRows),
+				"links":      queryRows(t, w.db, "SELECT source_id, target_title FROM links ORDER BY 1, 2"),
+				"categories": queryRows(t, w.db, "SELECT article_id, category_name FROM categories ORDER BY 1, 2"),
+			}
+
+			// The second import updates the 5 articles and adds 5 more
+			pages = pages[:0]
+			for id := 1; id <= 10; id++ {
+				pages = append(pages, testPage{ID: id, Title: fmt.Sprintf("Article %d", id), Timestamp: "2024-06-01T00:00:00Z",
+					Text: fmt.Sprintf("Article %d now links to [[Article 10]] and [[Article %d]].\n[[Category:New]]", id, id%10+1)})
+			}
+			writeTestDump(t, w.articlesFile, pages)
+			if err := w.ProcessArticlesSequential(-1); err != nil {
+				t.Fatalf("second import: %v", err)
+			}
+			if err := w.BuildWordFrequencyIndex(); err != nil {
+				t.Fatalf("BuildWordFrequencyIndex: %v", err)
+			}
+
+			runs, err := w.ListImportRuns(context.Background())
+			if err != nil {
+				t.Fatalf("ListImportRuns: %v", err)
+			}
+			if len(runs) != 2 || runs[0].ArticlesAdded != 5 || runs[0].ArticlesUpdated != 5 {
+				t.Fatalf("got import runs %+v, want the second one adding and updating 5 articles", runs)
+			}
+
+			result, err := w.RollbackImportRun(context.Background(), runs[0].RunID)
+			if err != nil {
+				t.Fatalf("RollbackImportRun: %v", err)
+			}
+			if result.ArticlesDeleted != 5 || result.ArticlesRestored != 5 {
+				t.Errorf("got %d articles deleted and %d restored, want 5 and 5", result.ArticlesDeleted, result.ArticlesRestored)
+			}
+
+			after := map[string][]string{
+				"articles":   queryRows(t, w.db, articleRows),
+				"links":      queryRows(t, w.db, "SELECT source_id, target_title FROM links ORDER BY 1, 2"),
+				"categories": queryRows(t, w.db, "SELECT article_id, category_name FROM categories ORDER BY 1, 2"),
+			}
+			for table := range before {
+				if !reflect.DeepEqual(after[table], before[table]) {
+					t.Errorf("%s after the rollback:\n%s\nwant:\n%s", table, after[table], before[table])
+				}
+			}
+
+			var frequencies int
+			if err := w.db.QueryRow("SELECT COUNT(*) FROM word_frequencies WHERE article_id > 5").Scan(&frequencies); err != nil {
+				t.Fatal(err)
+			}
+			if frequencies != 0 {
+				t.Errorf("%d word frequencies left for the deleted articles", frequencies)
+			}
+
+			article, err := w.GetArticleByID(3)
+			if err != nil {
+				t.Fatalf("GetArticleByID(3): %v", err)
+			}
+			if article.Content != "'''Article 3''' links to [[Article 4]].\n[[Category:Old]]" || article.RevisionTimestamp != "2024-01-01T00:00:00Z" {
+				t.Errorf("got restored article %q at %s", article.Content, article.RevisionTimestamp)
+			}
+			if _, err := w.GetArticleByID(7); err == nil {
+				t.Error("the added article 7 is still stored")
+			}
+
+			if _, err := w.RollbackImportRun(context.Background(), runs[0].RunID); err != ErrImportRunNotFound {
+				t.Errorf("rolling back again: got %v, want ErrImportRunNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package wikipedia
+
+import (
+	"database/sql"
+)
+
+// WithIncremental makes ProcessArticles skip the articles already stored with
+// the revision timestamp they have in the dump, so that importing a newer dump
+// over the database only rewrites the articles changed since. Skipped
+// articles are not recorded in the import run.
+func (w *Wiki) WithIncremental(enabled bool) *Wiki {
+	w.incremental = enabled
+	return w
+}
+
+// unchanged reports whether page is stored with the same revision timestamp.
+// Pages without a timestamp are always written.
+func (b *articleBatch) unchanged(page *Page) bool {
+	if page.Timestamp == "" {
+		return false
+	}
+	var stored sql.NullString
+	if err := b.tx.QueryRow("SELECT revision_timestamp FROM articles WHERE id = ?", page.ID).Scan(&stored); err != nil {
+		return false
+	}
+	return stored.String == page.Timestamp
+}
@@ -0,0 +1,278 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// InfoboxTypeCount is the number of articles using an infobox template
+type InfoboxTypeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// ErrInvalidInfoboxQuery is returned for unsupported infobox field queries
+var ErrInvalidInfoboxQuery = errors.New("invalid infobox query")
+
+// infoboxOperators maps the supported comparison operators, by symbol or by
+// URL-friendly name, to their SQL operator. Only these are ever spliced into SQL.
+var infoboxOperators = map[string]string{
+	"=": "=", "eq": "=",
+	"<": "<", "lt": "<",
+	"<=": "<=", "lte": "<=",
+	">": ">", "gt": ">",
+	">=": ">=", "gte": ">=",
+	"LIKE": "LIKE", "like": "LIKE",
+}
+
+var (
+	infoboxStartRegex = regexp.MustCompile(`(?i)\{\{\s*infobox`)
+	htmlCommentRegex  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	multiSpaceRegex   = regexp.MustCompile(`\s+`)
+)
+
+// extractInfobox finds the first {{Infobox ...}} template in wikitext and
+// returns its template name and named parameters. Positional parameters are
+// ignored and nested templates and links are kept verbatim in the values.
+func extractInfobox(content string) (string, map[string]string, bool) {
+	loc := infoboxStartRegex.FindStringIndex(content)
+	if loc == nil {
+		return "", nil, false
+	}
+
+	// Find the matching closing braces, splitting on top-level pipes
+	body := content[loc[0]+2:]
+	var parts []string
+	depth := 0
+	start := 0
+	end := -1
+	for i := 0; i < len(body) && end < 0; i++ {
+		switch {
+		case strings.HasPrefix(body[i:], "{{") || strings.HasPrefix(body[i:], "[["):
+			depth++
+			i++
+		case strings.HasPrefix(body[i:], "}}") && depth == 0:
+			parts = append(parts, body[start:i])
+			end = i
+		case strings.HasPrefix(body[i:], "}}") || strings.HasPrefix(body[i:], "]]"):
+			if depth > 0 {
+				depth--
+			}
+			i++
+		case body[i] == '|' && depth == 0:
+			parts = append(parts, body[start:i])
+			start = i + 1
+		}
+	}
+	if end < 0 {
+		// Unterminated template: keep what was parsed
+		parts = append(parts, body[start:])
+	}
+
+	name := multiSpaceRegex.ReplaceAllString(strings.TrimSpace(htmlCommentRegex.ReplaceAllString(parts[0], "")), " ")
+	fields := make(map[string]string)
+	for _, part := range parts[1:] {
+		part = htmlCommentRegex.ReplaceAllString(part, "")
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key == "" || value == "" {
+			continue
+		}
+		fields[key] = value
+	}
+
+	return name, fields, true
+}
+
+// ParseInfobox returns the named parameters of the first {{Infobox ...}}
+// template in wikitext, with the markup of the values stripped by
+// StripWikitext. Parameters left empty, such as those holding only a nested
+// template, are dropped, and the map is empty when there is no infobox.
+func ParseInfobox(content string) map[string]string {
+	fields := make(map[string]string)
+	_, raw, ok := extractInfobox(content)
+	if !ok {
+		return fields
+	}
+	for key, value := range raw {
+		value = multiSpaceRegex.ReplaceAllString(strings.TrimSpace(StripWikitext(value)), " ")
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// GetArticleInfobox returns the first infobox of an article parsed by
+// ParseInfobox
+func (w *Wiki) GetArticleInfobox(id int64) (map[string]string, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var content contentText
+	err := w.db.QueryRow("SELECT COALESCE(content, content_compressed) FROM articles WHERE id = ?", id).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query article: %w", err)
+	}
+
+	return ParseInfobox(string(content)), nil
+}
+
+// infoboxJSON serializes the first infobox of an article for the infobox_data
+// column: the template name is stored under "type" next to the parameters
+func infoboxJSON(content string) sql.NullString {
+	name, fields, ok := extractInfobox(content)
+	if !ok {
+		return sql.NullString{}
+	}
+
+	data := make(map[string]string, len(fields)+1)
+	for key, value := range fields {
+		data[key] = value
+	}
+	data["type"] = name
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(encoded), Valid: true}
+}
+
+// GetArticlesByInfoboxType lists articles whose first infobox uses the given
+// template name (e.g. "Infobox settlement")
+func (w *Wiki) GetArticlesByInfoboxType(ctx context.Context, infoboxType string, limit, offset int) ([]*ArticleMeta, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT id, title, namespace, redirect
+		FROM articles
+		WHERE JSON_EXTRACT(infobox_data, '$.type') = ?
+		ORDER BY id
+		LIMIT ? OFFSET ?
+	`, infoboxType, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query articles by infobox type: %w", err)
+	}
+	defer rows.Close()
+
+	return scanArticleMetas(rows)
+}
+
+// ListInfoboxTypes returns the topN most used infobox templates
+func (w *Wiki) ListInfoboxTypes(ctx context.Context, topN int) ([]InfoboxTypeCount, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if topN <= 0 {
+		topN = 20
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT JSON_EXTRACT(infobox_data, '$.type') AS type, COUNT(*) AS count
+		FROM articles
+		WHERE infobox_data IS NOT NULL
+		GROUP BY type
+		ORDER BY count DESC, type
+		LIMIT ?
+	`, topN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list infobox types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []InfoboxTypeCount{}
+	for rows.Next() {
+		var t InfoboxTypeCount
+		var name sql.NullString
+		if err := rows.Scan(&name, &t.Count); err != nil {
+			continue
+		}
+		t.Type = name.String
+		types = append(types, t)
+	}
+
+	return types, rows.Err()
+}
+
+// SearchByInfoboxField lists articles whose infobox field compares to value
+// with op (=, <, <=, >, >=, LIKE or eq, lt, lte, gt, gte, like). When value is
+// a number, the ordering operators compare numerically, ignoring thousands
+// separators and skipping fields that do not start with a number.
+func (w *Wiki) SearchByInfoboxField(ctx context.Context, field string, op string, value string, limit int) ([]*ArticleMeta, error) {
+	sqlOp, ok := infoboxOperators[op]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported operator %q", ErrInvalidInfoboxQuery, op)
+	}
+	if field == "" || strings.ContainsAny(field, `"\`) {
+		return nil, fmt.Errorf("%w: invalid field %q", ErrInvalidInfoboxQuery, field)
+	}
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	path := `$."` + field + `"`
+	fieldExpr := "JSON_EXTRACT(infobox_data, ?)"
+	condition := fieldExpr + " " + sqlOp + " ?"
+	args := []interface{}{path, value}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && sqlOp != "LIKE" && sqlOp != "=" {
+		number := "REPLACE(" + fieldExpr + ", ',', '')"
+		condition = number + " GLOB '[0-9-]*' AND CAST(" + number + " AS REAL) " + sqlOp + " CAST(? AS REAL)"
+		args = []interface{}{path, path, value}
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT id, title, namespace, redirect
+		FROM articles
+		WHERE infobox_data IS NOT NULL AND `+condition+`
+		ORDER BY id
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search infobox field: %w", err)
+	}
+	defer rows.Close()
+
+	return scanArticleMetas(rows)
+}
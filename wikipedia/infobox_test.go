@@ -0,0 +1,177 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseInfobox(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			name: "single-line values",
+			content: `{{Short description|Country in Europe}}
+{{Infobox country | name = France | population = 68M }}
+'''France''' is a country.`,
+			want: map[string]string{"name": "France", "population": "68M"},
+		},
+		{
+			name: "multi-line values",
+			content: `{{infobox person
+| name        = Marie Curie
+| known_for   = Discovery of
+  [[polonium]] and
+  [[radium]]
+| spouse      = [[Pierre Curie]]<br />(m. 1895)
+}}`,
+			want: map[string]string{
+				"name":      "Marie Curie",
+				"known_for": "Discovery of polonium and radium",
+				"spouse":    "Pierre Curie (m. 1895)",
+			},
+		},
+		{
+			name: "nested templates",
+			content: `{{Infobox settlement
+| name       = Paris
+| area_km2   = {{convert|105.4|km2|sqmi|abbr=on}}
+| population = 2,102,650{{efn|As of 2023}}
+| mayor      = [[Anne Hidalgo]] | website = {{URL|paris.fr}}
+}}
+Text after the {{infobox}}.`,
+			want: map[string]string{
+				"name":       "Paris",
+				"population": "2,102,650",
+				"mayor":      "Anne Hidalgo",
+			},
+		},
+		{
+			name: "comments and positional parameters",
+			content: `{{Infobox river <!-- see the documentation -->
+| positional
+| name = Seine <!-- French name -->
+| length =
+}}`,
+			want: map[string]string{"name": "Seine"},
+		},
+		{
+			name:    "no infobox",
+			content: "{{Other uses}}\n'''Physics''' is a natural science. {{Reflist}}",
+			want:    map[string]string{},
+		},
+		{
+			name:    "empty content",
+			content: "",
+			want:    map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseInfobox(tt.content)
+			if got == nil || !maps.Equal(got, tt.want) {
+				t.Errorf("ParseInfobox() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfoboxTypes(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Paris", Text: "{{Infobox settlement|name=Paris}}"},
+		testPage{ID: 2, Title: "Lyon", Text: "{{Infobox settlement|name=Lyon}}"},
+		testPage{ID: 3, Title: "Marseille", Text: "{{Infobox settlement|name=Marseille}}"},
+		testPage{ID: 4, Title: "Marie Curie", Text: "{{Infobox person|name=Marie Curie}}"},
+		testPage{ID: 5, Title: "Pierre Curie", Text: "{{Infobox person|name=Pierre Curie}}"},
+		testPage{ID: 6, Title: "Seine", Text: "{{Infobox river|name=Seine}}"},
+		testPage{ID: 7, Title: "Physics", Text: "No infobox."},
+	)
+	ctx := context.Background()
+
+	types, err := w.ListInfoboxTypes(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListInfoboxTypes: %v", err)
+	}
+	want := []InfoboxTypeCount{{"Infobox settlement", 3}, {"Infobox person", 2}, {"Infobox river", 1}}
+	if !slices.Equal(types, want) {
+		t.Errorf("ListInfoboxTypes = %v, want %v", types, want)
+	}
+	if types, _ := w.ListInfoboxTypes(ctx, 2); !slices.Equal(types, want[:2]) {
+		t.Errorf("ListInfoboxTypes(2) = %v, want %v", types, want[:2])
+	}
+
+	tests := []struct {
+		infoboxType string
+		want        []string
+	}{
+		{"Infobox settlement", []string{"Paris", "Lyon", "Marseille"}},
+		{"Infobox person", []string{"Marie Curie", "Pierre Curie"}},
+		{"Infobox river", []string{"Seine"}},
+		{"Infobox planet", nil},
+	}
+	for _, tt := range tests {
+		articles, err := w.GetArticlesByInfoboxType(ctx, tt.infoboxType, 10, 0)
+		if err != nil {
+			t.Fatalf("GetArticlesByInfoboxType(%q): %v", tt.infoboxType, err)
+		}
+		var titles []string
+		for _, article := range articles {
+			titles = append(titles, article.Title)
+		}
+		if !slices.Equal(titles, tt.want) {
+			t.Errorf("GetArticlesByInfoboxType(%q) = %q, want %q", tt.infoboxType, titles, tt.want)
+		}
+	}
+	if articles, _ := w.GetArticlesByInfoboxType(ctx, "Infobox settlement", 1, 1); len(articles) != 1 || articles[0].Title != "Lyon" {
+		t.Errorf("second page of Infobox settlement = %v, want Lyon", articles)
+	}
+}
+
+func TestSearchByInfoboxField(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Paris", Text: "{{Infobox settlement|name=Paris|population_total=2,102,650}}"},
+		testPage{ID: 2, Title: "Lyon", Text: "{{Infobox settlement|name=Lyon|population_total=522,250}}"},
+		testPage{ID: 3, Title: "Marseille", Text: "{{Infobox settlement|name=Marseille|population_total=873076}}"},
+		testPage{ID: 4, Title: "Atlantis", Text: "{{Infobox settlement|name=Atlantis|population_total=unknown}}"},
+		testPage{ID: 5, Title: "Avalon", Text: "{{Infobox settlement|name=Avalon}}"},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		op, value string
+		want      []string
+	}{
+		// Thousands separators are ignored and non-numeric values skipped
+		{"gt", "600000", []string{"Paris", "Marseille"}},
+		{">", "600000", []string{"Paris", "Marseille"}},
+		{"gte", "522250", []string{"Paris", "Lyon", "Marseille"}},
+		{"lt", "1000000", []string{"Lyon", "Marseille"}},
+		{"gt", "5000000", nil},
+		{"eq", "873076", []string{"Marseille"}},
+		{"like", "%unknown%", []string{"Atlantis"}},
+	}
+	for _, tt := range tests {
+		articles, err := w.SearchByInfoboxField(ctx, "population_total", tt.op, tt.value, 10)
+		if err != nil {
+			t.Fatalf("SearchByInfoboxField(%s %s): %v", tt.op, tt.value, err)
+		}
+		var titles []string
+		for _, article := range articles {
+			titles = append(titles, article.Title)
+		}
+		if !slices.Equal(titles, tt.want) {
+			t.Errorf("SearchByInfoboxField(population_total %s %s) = %q, want %q", tt.op, tt.value, titles, tt.want)
+		}
+	}
+
+	for _, query := range []struct{ field, op string }{{"population_total", "between"}, {`bad"field`, "gt"}, {"", "gt"}} {
+		if _, err := w.SearchByInfoboxField(ctx, query.field, query.op, "1", 10); !errors.Is(err, ErrInvalidInfoboxQuery) {
+			t.Errorf("SearchByInfoboxField(%q, %q): got %v, want ErrInvalidInfoboxQuery", query.field, query.op, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrLanguageLinkNotFound is returned when an article has no link to a language
+var ErrLanguageLinkNotFound = errors.New("language link not found")
+
+var languageLinkRegex = regexp.MustCompile(`\[\[([a-z]{2,3}(?:-[a-z]{2,8})*|simple):([^\[\]|]+)\]\]`)
+
+// insertLanguageLinkSQL stores one interlanguage link of an article
+const insertLanguageLinkSQL = "INSERT OR IGNORE INTO interwiki_links (article_id, lang, title) VALUES (?, ?, ?)"
+
+// createLanguageLinkTables creates the interwiki_links table and a trigger
+// dropping the links of deleted articles
+func (w *Wiki) createLanguageLinkTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS interwiki_links (
+			article_id INTEGER NOT NULL,
+			lang TEXT NOT NULL,
+			title TEXT NOT NULL,
+			PRIMARY KEY (article_id, lang)
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS articles_interwiki_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM interwiki_links WHERE article_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create interwiki_links table: %w", err)
+		}
+	}
+	return nil
+}
+
+// extractLanguageLinks returns the interlanguage links of an article such as
+// [[de:Albert Einstein]], keyed by language code. Prefixes that are namespace
+// names (e.g. "wp") are not languages, and links with a leading colon are
+// inline links rather than interlanguage links.
+func extractLanguageLinks(content string) map[string]string {
+	links := make(map[string]string)
+	for _, match := range languageLinkRegex.FindAllStringSubmatch(content, -1) {
+		lang := match[1]
+		if _, isNamespace := GetNamespaceByName(lang); isNamespace {
+			continue
+		}
+		if _, seen := links[lang]; seen {
+			continue
+		}
+		if title := strings.TrimSpace(match[2]); title != "" {
+			links[lang] = title
+		}
+	}
+	return links
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// storeLanguageLinks replaces the stored interlanguage links of an article
+func storeLanguageLinks(ctx context.Context, db execer, id int64, content string) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM interwiki_links WHERE article_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear language links: %w", err)
+	}
+	for lang, title := range extractLanguageLinks(content) {
+		if _, err := db.ExecContext(ctx, insertLanguageLinkSQL, id, lang, title); err != nil {
+			return fmt.Errorf("failed to store language link: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetLanguageLinks returns the titles of an article in other languages, keyed
+// by language code
+func (w *Wiki) GetLanguageLinks(ctx context.Context, id int64) (map[string]string, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.QueryContext(ctx, "SELECT lang, title FROM interwiki_links WHERE article_id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query language links: %w", err)
+	}
+	defer rows.Close()
+
+	links := make(map[string]string)
+	for rows.Next() {
+		var lang, title string
+		if err := rows.Scan(&lang, &title); err != nil {
+			continue
+		}
+		links[lang] = title
+	}
+
+	return links, rows.Err()
+}
+
+// GetArticleInLanguage returns the title of an article in the given language
+func (w *Wiki) GetArticleInLanguage(ctx context.Context, id int64, lang string) (string, error) {
+	if err := w.Open(); err != nil {
+		return "", err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var title string
+	err := w.db.QueryRowContext(ctx, "SELECT title FROM interwiki_links WHERE article_id = ? AND lang = ?", id, strings.ToLower(lang)).Scan(&title)
+	if err == sql.ErrNoRows {
+		return "", ErrLanguageLinkNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query language link: %w", err)
+	}
+	return title, nil
+}
@@ -0,0 +1,45 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestLanguageLinks(t *testing.T) {
+	w := newTestWiki(t, testPage{ID: 1, Title: "Albert Einstein", Text: `'''Albert Einstein''' was a physicist, see [[:fr:Albert Einstein|French]]
+and [[wp:Notability]].
+[[Category:Physicists]]
+[[de:Albert Einstein]]
+[[fr:Albert Einstein]]
+[[ja:アルベルト・アインシュタイン]]
+[[zh-yue:愛因斯坦]]
+[[simple:Albert Einstein]]
+[[de:Einstein]]`})
+	ctx := context.Background()
+
+	// The inline [[:fr:...]] link, the wp: project link and the second de: link
+	// are not stored
+	want := map[string]string{
+		"de":     "Albert Einstein",
+		"fr":     "Albert Einstein",
+		"ja":     "アルベルト・アインシュタイン",
+		"zh-yue": "愛因斯坦",
+		"simple": "Albert Einstein",
+	}
+	links, err := w.GetLanguageLinks(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetLanguageLinks: %v", err)
+	}
+	if !maps.Equal(links, want) {
+		t.Errorf("GetLanguageLinks = %v, want %v", links, want)
+	}
+
+	if title, err := w.GetArticleInLanguage(ctx, 1, "JA"); err != nil || title != want["ja"] {
+		t.Errorf("GetArticleInLanguage(JA) = %q, %v, want %q", title, err, want["ja"])
+	}
+	if _, err := w.GetArticleInLanguage(ctx, 1, "es"); !errors.Is(err, ErrLanguageLinkNotFound) {
+		t.Errorf("GetArticleInLanguage(es): got %v, want ErrLanguageLinkNotFound", err)
+	}
+}
@@ -0,0 +1,229 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var wikiLinkRegex = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|[^\[\]]*)?\]\]`)
+
+// createLinkTables creates the links table and a trigger dropping the links
+// of deleted articles
+func (w *Wiki) createLinkTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS links (
+			source_id INTEGER NOT NULL,
+			target_title TEXT NOT NULL,
+			PRIMARY KEY (source_id, target_title)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_links_target ON links(target_title)",
+		`CREATE TRIGGER IF NOT EXISTS articles_links_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM links WHERE source_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create links table: %w", err)
+		}
+	}
+	return nil
+}
+
+// normalizeTitle converts a link target to title form: underscores become
+// spaces and the first letter is capitalized, as MediaWiki does
+func normalizeTitle(title string) string {
+	title = strings.Join(strings.Fields(strings.ReplaceAll(title, "_", " ")), " ")
+	r, size := utf8.DecodeRuneInString(title)
+	if r == utf8.RuneError {
+		return title
+	}
+	return string(unicode.ToUpper(r)) + title[size:]
+}
+
+// extractLinks returns the distinct targets of the internal links of an
+// article, without section anchors. Category and file links, which
+// categorize or embed rather than link, and interlanguage links are skipped;
+// a leading colon ([[:Category:Physics]]) makes them ordinary links.
+func extractLinks(content string) []string {
+	seen := make(map[string]bool)
+	targets := []string{}
+	for _, match := range wikiLinkRegex.FindAllStringSubmatch(maskNowiki(content), -1) {
+		target, _, _ := strings.Cut(match[1], "#")
+		if strings.HasPrefix(target, ":") {
+			target = target[1:]
+		} else if prefix, _, ok := strings.Cut(target, ":"); ok {
+			if ns, isNamespace := GetNamespaceByName(prefix); isNamespace && (ns == 6 || ns == 14) {
+				continue
+			}
+			if languageLinkRegex.MatchString("[[" + prefix + ":x]]") {
+				continue
+			}
+		}
+
+		target = normalizeTitle(target)
+		if target == "" || seen[target] {
+			continue
+		}
+		seen[target] = true
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// storeLinks replaces the stored internal links of an article
+func storeLinks(ctx context.Context, db execer, id int64, content string) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM links WHERE source_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear links: %w", err)
+	}
+	for _, target := range extractLinks(content) {
+		if _, err := db.ExecContext(ctx, "INSERT OR IGNORE INTO links (source_id, target_title) VALUES (?, ?)", id, target); err != nil {
+			return fmt.Errorf("failed to store link: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetArticleLinks returns the titles an article links to
+func (w *Wiki) GetArticleLinks(id int64) ([]string, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.Query("SELECT target_title FROM links WHERE source_id = ? ORDER BY target_title", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	targets := []string{}
+	for rows.Next() {
+		var target string
+		if err := rows.Scan(&target); err != nil {
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+// GetBacklinks returns the articles linking to title, without their content
+func (w *Wiki) GetBacklinks(title string, limit, offset int) ([]*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := w.db.Query(`
+		SELECT a.id, a.title, a.namespace, a.redirect
+		FROM links l
+		JOIN articles a ON a.id = l.source_id
+		WHERE l.target_title = ?
+		ORDER BY a.id
+		LIMIT ? OFFSET ?
+	`, normalizeTitle(title), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backlinks: %w", err)
+	}
+	defer rows.Close()
+
+	articles := []*Article{}
+	for rows.Next() {
+		var article Article
+		var redirect sql.NullString
+		if err := rows.Scan(&article.ID, &article.Title, &article.Namespace, &redirect); err != nil {
+			continue
+		}
+		article.Redirect = redirect.String
+		articles = append(articles, &article)
+	}
+
+	return articles, rows.Err()
+}
+
+// GetBacklinksBatch is GetBacklinks for several titles with a single query,
+// returning up to limit articles per title keyed by the given titles. Titles
+// without backlinks map to an empty slice.
+func (w *Wiki) GetBacklinksBatch(titles []string, limit int) (map[string][]*Article, error) {
+	if len(titles) > MaxBatchIDs {
+		return nil, ErrTooManyIDs
+	}
+	backlinks := make(map[string][]*Article, len(titles))
+	if len(titles) == 0 {
+		return backlinks, nil
+	}
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// Queried titles are normalized, so several given titles may share one
+	byTarget := make(map[string][]string, len(titles))
+	args := make([]interface{}, 0, len(titles)+1)
+	for _, title := range titles {
+		backlinks[title] = []*Article{}
+		target := normalizeTitle(title)
+		if _, ok := byTarget[target]; !ok {
+			args = append(args, target)
+		}
+		byTarget[target] = append(byTarget[target], title)
+	}
+	args = append(args, limit)
+
+	rows, err := w.db.Query(`
+		SELECT target_title, id, title, namespace, redirect
+		FROM (
+			SELECT l.target_title, a.id, a.title, a.namespace, a.redirect,
+				ROW_NUMBER() OVER (PARTITION BY l.target_title ORDER BY a.id) AS n
+			FROM links l
+			JOIN articles a ON a.id = l.source_id
+			WHERE l.target_title IN (?`+strings.Repeat(", ?", len(args)-2)+`)
+		)
+		WHERE n <= ?
+		ORDER BY target_title, id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backlinks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var target string
+		var article Article
+		var redirect sql.NullString
+		if err := rows.Scan(&target, &article.ID, &article.Title, &article.Namespace, &redirect); err != nil {
+			continue
+		}
+		article.Redirect = redirect.String
+		for _, title := range byTarget[target] {
+			backlinks[title] = append(backlinks[title], &article)
+		}
+	}
+
+	return backlinks, rows.Err()
+}
@@ -0,0 +1,246 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// orphanedIndexEntriesSQL counts the index entries whose article is not in the
+// articles table
+const orphanedIndexEntriesSQL = "SELECT COUNT(*) FROM index_entries WHERE article_id NOT IN (SELECT id FROM articles)"
+
+// CleanupOrphanedIndexEntries deletes index entries whose article is not in
+// the articles table and returns how many rows were removed. Run it only after
+// ProcessArticles: before that, every index entry is orphaned, and a later
+// ProcessArticles run skips articles that no longer have an index entry.
+func (w *Wiki) CleanupOrphanedIndexEntries(ctx context.Context) (int, error) {
+	if err := w.Open(); err != nil {
+		return 0, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.cleanupOrphanedIndexEntries(ctx)
+}
+
+// cleanupOrphanedIndexEntries is CleanupOrphanedIndexEntries for callers that
+// already hold w.mu
+func (w *Wiki) cleanupOrphanedIndexEntries(ctx context.Context) (int, error) {
+	res, err := w.db.ExecContext(ctx, "DELETE FROM index_entries WHERE article_id NOT IN (SELECT id FROM articles)")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned index entries: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// CountOrphanedIndexEntries returns the number of index entries whose article
+// is not in the articles table
+func (w *Wiki) CountOrphanedIndexEntries(ctx context.Context) (int, error) {
+	if err := w.Open(); err != nil {
+		return 0, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var count int
+	err := w.db.QueryRowContext(ctx, orphanedIndexEntriesSQL).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orphaned index entries: %w", err)
+	}
+	return count, nil
+}
+
+// OptimizeFTS merges the segments the full-text index accumulates during large
+// imports, which otherwise slow queries down: "optimize" for FTS5, "rebuild"
+// for FTS4. It does nothing when full-text search is not available.
+func (w *Wiki) OptimizeFTS() error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var command string
+	switch w.ftsVersion {
+	case "fts5":
+		command = "optimize"
+	case "fts4":
+		command = "rebuild"
+	default:
+		return nil
+	}
+
+	start := time.Now()
+	if _, err := w.db.Exec("INSERT INTO articles_fts(articles_fts) VALUES(?)", command); err != nil {
+		return fmt.Errorf("failed to optimize full-text index: %w", err)
+	}
+	w.logger.Info("Optimized full-text index", "fts", w.ftsVersion, "elapsed", time.Since(start))
+	return nil
+}
+
+// RebuildFTS rebuilds the full-text index from the articles table, for
+// databases whose index went stale, e.g. after articles were inserted without
+// the triggers or after switching FTS version. An FTS5 table reading its
+// content from ftsContentView is rebuilt in place; otherwise the table, its
+// view and its triggers are dropped and created again, with FTS5 when
+// available, and filled from articles. This also lets indexes created by
+// older versions read compressed content.
+func (w *Wiki) RebuildFTS() error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	start := time.Now()
+
+	var existingSQL string
+	err := w.db.QueryRow("SELECT sql FROM sqlite_master WHERE name = 'articles_fts' AND type = 'table'").Scan(&existingSQL)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to inspect full-text table: %w", err)
+	}
+	existingSQL = strings.ToLower(existingSQL)
+
+	if w.ftsVersion == "fts5" && strings.Contains(existingSQL, ftsContentView) {
+		if _, err := w.db.Exec("INSERT INTO articles_fts(articles_fts) VALUES('rebuild')"); err != nil {
+			return fmt.Errorf("failed to rebuild full-text index: %w", err)
+		}
+	} else {
+		statements := []string{
+			"DROP TRIGGER IF EXISTS articles_ai",
+			"DROP TRIGGER IF EXISTS articles_ad",
+			"DROP TRIGGER IF EXISTS articles_au",
+			"DROP TRIGGER IF EXISTS articles_bd",
+			"DROP TRIGGER IF EXISTS articles_bu",
+			"DROP TABLE IF EXISTS articles_fts",
+			"DROP VIEW IF EXISTS " + ftsContentView,
+		}
+		for _, statement := range statements {
+			if _, err := w.db.Exec(statement); err != nil {
+				return fmt.Errorf("failed to drop full-text index: %w", err)
+			}
+		}
+
+		w.ftsVersion = w.createFTS()
+		w.legacyFTS = false
+		if w.ftsVersion == "none" {
+			return errors.New("full-text search is not available in this SQLite build")
+		}
+		if _, err := w.db.Exec("INSERT INTO articles_fts(articles_fts) VALUES('rebuild')"); err != nil {
+			return fmt.Errorf("failed to populate full-text index: %w", err)
+		}
+	}
+
+	var rows int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&rows); err != nil {
+		return fmt.Errorf("failed to count articles: %w", err)
+	}
+	w.cache.purge()
+	w.logger.Info("Rebuilt full-text index", "fts", w.ftsVersion, "rows", rows, "elapsed", time.Since(start))
+	return nil
+}
+
+// pageCount returns the number of pages of the database and their size
+func (w *Wiki) pageCount() (pages, pageSize int64, err error) {
+	if err := w.db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := w.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pages, pageSize, nil
+}
+
+// Vacuum rebuilds the database file to reclaim the pages freed by deleted and
+// replaced articles, and logs the page counts before and after. It needs up to
+// twice the size of the database in free disk space.
+func (w *Wiki) Vacuum() error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	before, pageSize, err := w.pageCount()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if _, err := w.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	after, _, err := w.pageCount()
+	if err != nil {
+		return err
+	}
+	w.logger.Info("Vacuumed database", "elapsed", time.Since(start),
+		"pages_before", before, "pages_after", after, "bytes_reclaimed", (before-after)*pageSize)
+	return nil
+}
+
+// VacuumInto writes a compacted copy of the database to path, which must not
+// exist, leaving the live database untouched. The copy is a self-contained
+// snapshot suitable for distribution.
+func (w *Wiki) VacuumInto(path string) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	pages, pageSize, err := w.pageCount()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if _, err := w.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to vacuum database into %s: %w", path, err)
+	}
+	w.logger.Info("Wrote compacted copy of the database", "path", path,
+		"pages", pages, "bytes", pages*pageSize, "elapsed", time.Since(start))
+	return nil
+}
+
+// Snapshot writes a consistent, compacted copy of the database to a new file
+// next to it with VacuumInto and returns its path, to be removed by the caller.
+// Writes through this Wiki wait only while the copy is written, and the copy
+// is consistent even if another process imports at the same time.
+func (w *Wiki) Snapshot() (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(w.dbPath), "snapshot-*.db")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+
+	// VACUUM INTO accepts an existing file only when it is empty
+	if err := w.VacuumInto(path); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
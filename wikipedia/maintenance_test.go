@@ -0,0 +1,42 @@
+package wikipedia
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCleanupOrphanedIndexEntries(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "A natural science."},
+		testPage{ID: 2, Title: "Biology", Text: "The science of life."},
+	)
+	ctx := context.Background()
+
+	// DeleteArticle removes the index entries of the article itself, so
+	// orphans are entries of pages that were never imported
+	if _, err := w.db.Exec("INSERT INTO index_entries (seek, article_id) VALUES (0, 1), (0, 2), (0, 3), (100, 4), (100, 5)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := w.CountOrphanedIndexEntries(ctx); err != nil || count != 3 {
+		t.Fatalf("CountOrphanedIndexEntries = %d, %v, want 3", count, err)
+	}
+	deleted, err := w.CleanupOrphanedIndexEntries(ctx)
+	if err != nil {
+		t.Fatalf("CleanupOrphanedIndexEntries: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("CleanupOrphanedIndexEntries deleted %d entries, want 3", deleted)
+	}
+
+	var remaining int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM index_entries WHERE article_id IN (1, 2)").Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 2 {
+		t.Errorf("got %d index entries of imported articles, want 2", remaining)
+	}
+	if deleted, err := w.CleanupOrphanedIndexEntries(ctx); err != nil || deleted != 0 {
+		t.Errorf("second CleanupOrphanedIndexEntries = %d, %v, want 0", deleted, err)
+	}
+}
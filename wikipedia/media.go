@@ -0,0 +1,140 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MediaReference is a file embedded in an article with [[File:...]]
+type MediaReference struct {
+	FileName string `json:"file_name"`
+	Caption  string `json:"caption,omitempty"`
+}
+
+var (
+	mediaLinkRegex = regexp.MustCompile(`(?i)\[\[\s*(?:file|image)\s*:`)
+	// Image options are the parameters of a file link that are not its caption
+	mediaOptionRegex = regexp.MustCompile(`(?i)^(?:thumb|thumbnail|frame|framed|frameless|border|left|right|center|centre|none|upright|baseline|middle|sub|super|text-top|text-bottom|top|bottom|\d*(?:x\d+)?px|(?:upright|thumb|thumbnail|alt|link|page|lang|class)\s*=.*)$`)
+)
+
+// createMediaTables creates the media_references table and a trigger dropping
+// the references of deleted articles
+func (w *Wiki) createMediaTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS media_references (
+			article_id INTEGER NOT NULL,
+			file_name TEXT NOT NULL,
+			caption TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (article_id, file_name)
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_media_references_file ON media_references(file_name)",
+		`CREATE TRIGGER IF NOT EXISTS articles_media_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM media_references WHERE article_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create media_references table: %w", err)
+		}
+	}
+	return nil
+}
+
+// splitLinkParams splits s, the text following the opening brackets of a
+// link, on its top-level pipes up to the matching closing brackets
+func splitLinkParams(s string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "[[") || strings.HasPrefix(s[i:], "{{"):
+			depth++
+			i++
+		case strings.HasPrefix(s[i:], "]]") && depth == 0:
+			return append(parts, s[start:i])
+		case strings.HasPrefix(s[i:], "]]") || strings.HasPrefix(s[i:], "}}"):
+			if depth > 0 {
+				depth--
+			}
+			i++
+		case s[i] == '|' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	// Unterminated link: keep what was parsed
+	return append(parts, s[start:])
+}
+
+// extractMedia returns the distinct files embedded in an article with
+// [[File:Name|options|Caption]] or [[Image:Name]] links. The caption is the
+// last parameter that is not an image option, converted to plain text.
+// Links with a leading colon ([[:File:Example.png]]) link to the file page
+// and are not references.
+func extractMedia(content string) []MediaReference {
+	content = maskNowiki(content)
+	seen := make(map[string]bool)
+	media := []MediaReference{}
+	for _, loc := range mediaLinkRegex.FindAllStringIndex(content, -1) {
+		parts := splitLinkParams(content[loc[1]:])
+		name := normalizeTitle(parts[0])
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		caption := ""
+		for _, part := range parts[1:] {
+			if part = strings.TrimSpace(part); part != "" && !mediaOptionRegex.MatchString(part) {
+				caption = part
+			}
+		}
+		media = append(media, MediaReference{FileName: name, Caption: multiSpaceRegex.ReplaceAllString(StripWikitext(caption), " ")})
+	}
+	return media
+}
+
+// storeMedia replaces the stored media references of an article
+func storeMedia(ctx context.Context, db execer, id int64, content string) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM media_references WHERE article_id = ?", id); err != nil {
+		return fmt.Errorf("failed to clear media references: %w", err)
+	}
+	for _, ref := range extractMedia(content) {
+		if _, err := db.ExecContext(ctx, "INSERT OR IGNORE INTO media_references (article_id, file_name, caption) VALUES (?, ?, ?)", id, ref.FileName, ref.Caption); err != nil {
+			return fmt.Errorf("failed to store media reference: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetArticleMedia returns the files embedded in an article, in wikitext order.
+// File names are returned without their "File:" prefix.
+func (w *Wiki) GetArticleMedia(id int64) ([]MediaReference, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.Query("SELECT file_name, caption FROM media_references WHERE article_id = ? ORDER BY rowid", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media references: %w", err)
+	}
+	defer rows.Close()
+
+	media := []MediaReference{}
+	for rows.Next() {
+		var ref MediaReference
+		if err := rows.Scan(&ref.FileName, &ref.Caption); err != nil {
+			continue
+		}
+		media = append(media, ref)
+	}
+
+	return media, rows.Err()
+}
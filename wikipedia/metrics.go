@@ -0,0 +1,77 @@
+package wikipedia
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsQueryTimeout bounds the count queries run on each metrics scrape
+const metricsQueryTimeout = 5 * time.Second
+
+var (
+	searchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "wikipedia_search_duration_seconds",
+		Help:    "Latency of title searches",
+		Buckets: prometheus.DefBuckets,
+	})
+	articleFetchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "wikipedia_article_fetch_duration_seconds",
+		Help:    "Latency of article lookups by ID",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// observeSince records the time elapsed since start in a latency histogram
+func observeSince(h prometheus.Histogram, start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// RegisterMetrics registers the search and article fetch latency histograms,
+// the article cache hit and miss counters and gauges of the article and index
+// entry counts with reg. The latencies and cache counts are recorded whether or
+// not they are registered.
+func (w *Wiki) RegisterMetrics(reg prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		searchDuration,
+		articleFetchDuration,
+		articleCacheHits,
+		articleCacheMisses,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wikipedia_articles_total",
+			Help: "Number of articles in the database",
+		}, func() float64 { return w.countRows("articles") }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wikipedia_index_entries_total",
+			Help: "Number of index entries in the database",
+		}, func() float64 { return w.countRows("index_entries") }),
+	}
+
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countRows counts the rows of a table for the metrics gauges, reporting 0
+// when the database cannot be queried
+func (w *Wiki) countRows(table string) float64 {
+	if err := w.Open(); err != nil {
+		return 0
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsQueryTimeout)
+	defer cancel()
+
+	var count int64
+	if err := w.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+		return 0
+	}
+	return float64(count)
+}
@@ -0,0 +1,380 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// NamespaceInfo describes a MediaWiki namespace
+type NamespaceInfo struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	CanonicalName string `json:"canonical_name"`
+	IsTalk        bool   `json:"is_talk"`
+}
+
+// NamespaceStat is the number of stored articles in a namespace
+type NamespaceStat struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NamespaceRegistry holds the standard English Wikipedia namespaces. Name is the
+// local name used in titles, CanonicalName the language-independent MediaWiki name.
+var NamespaceRegistry = map[int]NamespaceInfo{
+	-2: {ID: -2, Name: "Media", CanonicalName: "Media"},
+	-1: {ID: -1, Name: "Special", CanonicalName: "Special"},
+	0:  {ID: 0, Name: "(Main)", CanonicalName: ""},
+	1:  {ID: 1, Name: "Talk", CanonicalName: "Talk", IsTalk: true},
+	2:  {ID: 2, Name: "User", CanonicalName: "User"},
+	3:  {ID: 3, Name: "User talk", CanonicalName: "User talk", IsTalk: true},
+	4:  {ID: 4, Name: "Wikipedia", CanonicalName: "Project"},
+	5:  {ID: 5, Name: "Wikipedia talk", CanonicalName: "Project talk", IsTalk: true},
+	6:  {ID: 6, Name: "File", CanonicalName: "File"},
+	7:  {ID: 7, Name: "File talk", CanonicalName: "File talk", IsTalk: true},
+	8:  {ID: 8, Name: "MediaWiki", CanonicalName: "MediaWiki"},
+	9:  {ID: 9, Name: "MediaWiki talk", CanonicalName: "MediaWiki talk", IsTalk: true},
+	10: {ID: 10, Name: "Template", CanonicalName: "Template"},
+	11: {ID: 11, Name: "Template talk", CanonicalName: "Template talk", IsTalk: true},
+	12: {ID: 12, Name: "Help", CanonicalName: "Help"},
+	13: {ID: 13, Name: "Help talk", CanonicalName: "Help talk", IsTalk: true},
+	14: {ID: 14, Name: "Category", CanonicalName: "Category"},
+	15: {ID: 15, Name: "Category talk", CanonicalName: "Category talk", IsTalk: true},
+}
+
+// siteInfo is the <siteinfo> header of a dump, which lists the namespaces of
+// the wiki with their local names
+type siteInfo struct {
+	Namespaces []struct {
+		Key  int    `xml:"key,attr"`
+		Name string `xml:",chardata"`
+	} `xml:"namespaces>namespace"`
+}
+
+// namespaceAliases maps legacy names to namespace IDs
+var namespaceAliases = map[string]int{
+	"image":      6,
+	"image talk": 7,
+	"wp":         4,
+	"wt":         5,
+}
+
+// GetNamespaceName returns the local name of a namespace, or "Namespace N" for
+// namespaces missing from the registry
+func GetNamespaceName(ns int) string {
+	if info, ok := NamespaceRegistry[ns]; ok {
+		return info.Name
+	}
+	return fmt.Sprintf("Namespace %d", ns)
+}
+
+// GetNamespaceByName looks up a namespace ID by local, canonical or legacy
+// name. The match ignores case and treats underscores as spaces.
+func GetNamespaceByName(name string) (int, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(name, "_", " ")))
+
+	for id, info := range NamespaceRegistry {
+		if normalized == strings.ToLower(info.Name) || (info.CanonicalName != "" && normalized == strings.ToLower(info.CanonicalName)) {
+			return id, true
+		}
+	}
+	if normalized == "" || normalized == "main" || normalized == "article" {
+		return 0, true
+	}
+
+	id, ok := namespaceAliases[normalized]
+	return id, ok
+}
+
+// WithNamespaces sets the namespaces whose pages ProcessArticles stores, e.g.
+// 0, 10 and 14 for articles, templates and categories. Without namespaces
+// only the main namespace (0) is stored.
+func (w *Wiki) WithNamespaces(ns ...int) *Wiki {
+	w.namespaces = make(map[int]bool, len(ns))
+	for _, n := range ns {
+		w.namespaces[n] = true
+	}
+	return w
+}
+
+// processesNamespace reports whether ProcessArticles stores pages of ns
+func (w *Wiki) processesNamespace(ns int) bool {
+	if len(w.namespaces) == 0 {
+		return ns == 0
+	}
+	return w.namespaces[ns]
+}
+
+// ScanNamespaces counts the pages of each namespace in the articles file,
+// reading at most limit pages when limit is positive. The database is not used,
+// so this can run before an import to choose the namespaces to process.
+func (w *Wiki) ScanNamespaces(limit int) (map[int]int, error) {
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	decoder := xml.NewDecoder(r)
+	counts := make(map[int]int)
+	scanned := 0
+	for limit <= 0 || scanned < limit {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read articles file: %w", err)
+		}
+
+		// Only the namespace of each page is kept
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "page" {
+			continue
+		}
+		var page struct {
+			NS int `xml:"ns"`
+		}
+		if err := decoder.DecodeElement(&page, &start); err != nil {
+			return nil, fmt.Errorf("failed to decode page: %w", err)
+		}
+		counts[page.NS]++
+		scanned++
+	}
+
+	return counts, nil
+}
+
+// importSiteNamespaces stores the namespaces listed in the <siteinfo> header
+// of the articles file in the namespaces table, replacing those of a previous
+// import; the canonical names come from NamespaceRegistry, dumps only giving
+// local names. Articles files without a header leave the table unchanged.
+func (w *Wiki) importSiteNamespaces() error {
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	// The header comes before the first page
+	var info siteInfo
+	decoder := xml.NewDecoder(r)
+	for len(info.Namespaces) == 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "page" {
+			return nil
+		}
+		if start.Name.Local == "siteinfo" {
+			if err := decoder.DecodeElement(&info, &start); err != nil {
+				return fmt.Errorf("failed to decode siteinfo: %w", err)
+			}
+			if len(info.Namespaces) == 0 {
+				return nil
+			}
+		}
+	}
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM namespaces"); err != nil {
+		return fmt.Errorf("failed to clear namespaces: %w", err)
+	}
+	for _, ns := range info.Namespaces {
+		canonical := ns.Name
+		if registered, ok := NamespaceRegistry[ns.Key]; ok {
+			canonical = registered.CanonicalName
+		}
+		if _, err := tx.Exec("INSERT INTO namespaces (id, name, canonical) VALUES (?, ?, ?)", ns.Key, ns.Name, canonical); err != nil {
+			return fmt.Errorf("failed to store namespace %d: %w", ns.Key, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit namespaces: %w", err)
+	}
+
+	w.logger.Info("Stored namespaces of the dump", "count", len(info.Namespaces))
+	return nil
+}
+
+// GetNamespaces returns the namespaces of the wiki keyed by ID, with the local
+// names read from the dump by ProcessArticles, or NamespaceRegistry when no
+// dump header has been imported
+func (w *Wiki) GetNamespaces() (map[int]NamespaceInfo, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.namespaceInfos()
+}
+
+// namespaceInfos is GetNamespaces for callers that already hold w.mu
+func (w *Wiki) namespaceInfos() (map[int]NamespaceInfo, error) {
+	rows, err := w.db.Query("SELECT id, name, canonical FROM namespaces")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	namespaces := make(map[int]NamespaceInfo)
+	for rows.Next() {
+		var info NamespaceInfo
+		var name, canonical sql.NullString
+		if err := rows.Scan(&info.ID, &name, &canonical); err != nil {
+			continue
+		}
+		info.Name, info.CanonicalName = name.String, canonical.String
+		info.IsTalk = info.ID > 0 && info.ID%2 == 1
+		namespaces[info.ID] = info
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(namespaces) == 0 {
+		for id, info := range NamespaceRegistry {
+			namespaces[id] = info
+		}
+	}
+	return namespaces, nil
+}
+
+// ListNamespaces returns the number of stored articles in each namespace
+func (w *Wiki) ListNamespaces(ctx context.Context) ([]NamespaceStat, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.namespaceStats(ctx)
+}
+
+// namespaceStats is ListNamespaces for callers that already hold w.mu. The
+// names are those of GetNamespaces, or of GetNamespaceName for namespaces
+// without a name, such as the main one.
+func (w *Wiki) namespaceStats(ctx context.Context) ([]NamespaceStat, error) {
+	namespaces, err := w.namespaceInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT namespace, COUNT(*)
+		FROM articles
+		GROUP BY namespace
+		ORDER BY namespace
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []NamespaceStat{}
+	for rows.Next() {
+		var stat NamespaceStat
+		if err := rows.Scan(&stat.ID, &stat.Count); err != nil {
+			continue
+		}
+		stat.Name = namespaces[stat.ID].Name
+		if stat.Name == "" {
+			stat.Name = GetNamespaceName(stat.ID)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetArticlesByNamespace lists the articles of a namespace in ID order. Only
+// the ID, title, namespace and redirect are loaded, not the content. The
+// idx_articles_namespace index serves this query: SQLite index entries end
+// with the rowid, so it is already ordered by (namespace, id).
+func (w *Wiki) GetArticlesByNamespace(ns int, limit, offset int) ([]*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := w.db.Query(`
+		SELECT id, title, namespace, redirect
+		FROM articles
+		WHERE namespace = ?
+		ORDER BY id
+		LIMIT ? OFFSET ?
+	`, ns, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list articles in namespace %d: %w", ns, err)
+	}
+	defer rows.Close()
+
+	articles := []*Article{}
+	for rows.Next() {
+		var article Article
+		var redirect sql.NullString
+		if err := rows.Scan(&article.ID, &article.Title, &article.Namespace, &redirect); err != nil {
+			continue
+		}
+		article.Redirect = redirect.String
+		articles = append(articles, &article)
+	}
+
+	return articles, rows.Err()
+}
+
+// CountArticlesByNamespace returns the number of articles in a namespace
+func (w *Wiki) CountArticlesByNamespace(ns int) (int, error) {
+	if err := w.Open(); err != nil {
+		return 0, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var count int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM articles WHERE namespace = ?", ns).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count articles in namespace %d: %w", ns, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,152 @@
+package wikipedia
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestGetArticlesByNamespace(t *testing.T) {
+	w := newFixtureWiki(t).WithNamespaces(0, 10, 14)
+	importFixture(t, w)
+
+	// The categories are the pages 96 to 100
+	categories, err := w.GetArticlesByNamespace(14, 50, 0)
+	if err != nil {
+		t.Fatalf("GetArticlesByNamespace(14): %v", err)
+	}
+	if len(categories) != 5 {
+		t.Fatalf("got %d categories, want 5", len(categories))
+	}
+	for i, article := range categories {
+		if want := fmt.Sprintf("Category:Group %d", i); article.ID != int64(96+i) || article.Title != want || article.Namespace != 14 {
+			t.Errorf("category %d: got %d %q in namespace %d, want %d %q in namespace 14", i, article.ID, article.Title, article.Namespace, 96+i, want)
+		}
+		if article.Content != "" {
+			t.Errorf("category %d: the content was loaded", i)
+		}
+	}
+
+	// The templates are the pages 91 to 95
+	page, err := w.GetArticlesByNamespace(10, 2, 3)
+	if err != nil {
+		t.Fatalf("GetArticlesByNamespace(10) at offset 3: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != 94 || page[1].ID != 95 {
+		t.Errorf("got templates %v at offset 3, want 94 and 95", page)
+	}
+
+	count, err := w.CountArticlesByNamespace(10)
+	if err != nil {
+		t.Fatalf("CountArticlesByNamespace(10): %v", err)
+	}
+	if count != 5 {
+		t.Errorf("CountArticlesByNamespace(10) = %d, want 5", count)
+	}
+
+	talk, err := w.GetArticlesByNamespace(1, 50, 0)
+	if err != nil {
+		t.Fatalf("GetArticlesByNamespace(1): %v", err)
+	}
+	if talk == nil || len(talk) != 0 {
+		t.Errorf("GetArticlesByNamespace(1) = %v, want an empty list", talk)
+	}
+}
+
+func TestWithNamespaces(t *testing.T) {
+	pages := []testPage{
+		{ID: 1, Title: "Physics", Text: "Physics is a natural science."},
+		{ID: 2, Title: "Talk:Physics", NS: 1, Text: "Is this article neutral?"},
+		{ID: 3, Title: "Template:Infobox science", NS: 10, Text: "{{{name}}}"},
+		{ID: 4, Title: "Help:Editing", NS: 12, Text: "How to edit a page."},
+		{ID: 5, Title: "Category:Physics", NS: 14, Text: "[[Category:Science]]"},
+		{ID: 6, Title: "Chemistry", Text: "Chemistry is a physical science."},
+	}
+
+	tests := []struct {
+		name       string
+		namespaces []int
+		want       []int64
+	}{
+		{"main namespace by default", nil, []int64{1, 6}},
+		{"articles and categories", []int{0, 14}, []int64{1, 5, 6}},
+		{"templates and help only", []int{10, 12}, []int64{3, 4}},
+		{"every namespace", []int{0, 1, 10, 12, 14}, []int64{1, 2, 3, 4, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestDump(t, pages...).WithNamespaces(tt.namespaces...)
+			if err := w.ProcessArticlesSequential(-1); err != nil {
+				t.Fatalf("ProcessArticlesSequential: %v", err)
+			}
+
+			var got []int64
+			rows, err := w.db.Query("SELECT id FROM articles ORDER BY id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var id int64
+				if err := rows.Scan(&id); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, id)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got articles %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceRegistry(t *testing.T) {
+	// The canonical names differ from the local ones for the project namespace
+	canonical := map[int]string{
+		0:  "",
+		1:  "Talk",
+		4:  "Project",
+		5:  "Project talk",
+		6:  "File",
+		10: "Template",
+		14: "Category",
+		15: "Category talk",
+	}
+	for id, want := range canonical {
+		info, ok := NamespaceRegistry[id]
+		if !ok {
+			t.Errorf("namespace %d is not registered", id)
+			continue
+		}
+		if info.ID != id || info.CanonicalName != want {
+			t.Errorf("NamespaceRegistry[%d] = %+v, want ID %d and canonical name %q", id, info, id, want)
+		}
+	}
+	for id, info := range NamespaceRegistry {
+		if wantTalk := id > 0 && id%2 == 1; info.IsTalk != wantTalk {
+			t.Errorf("NamespaceRegistry[%d].IsTalk = %v, want %v", id, info.IsTalk, wantTalk)
+		}
+	}
+
+	// Local, canonical and legacy names all resolve to the namespace
+	names := map[string]int{
+		"Wikipedia":    4,
+		"Project":      4,
+		"project_talk": 5,
+		"WP":           4,
+		"Image":        6,
+		"category":     14,
+		"Main":         0,
+	}
+	for name, want := range names {
+		if id, ok := GetNamespaceByName(name); !ok || id != want {
+			t.Errorf("GetNamespaceByName(%q) = %d, %v, want %d", name, id, ok, want)
+		}
+	}
+	if _, ok := GetNamespaceByName("Portal"); ok {
+		t.Error("GetNamespaceByName(Portal) found an unregistered namespace")
+	}
+	if got := GetNamespaceName(100); got != "Namespace 100" {
+		t.Errorf("GetNamespaceName(100) = %q, want Namespace 100", got)
+	}
+}
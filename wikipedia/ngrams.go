@@ -0,0 +1,208 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NgramFreq is the number of occurrences of an n-gram across all articles
+type NgramFreq struct {
+	Ngram     string `json:"ngram"`
+	Frequency int    `json:"frequency"`
+}
+
+// ngramFlushSize is the number of distinct n-grams counted in memory before
+// the counts are merged into corpus_ngrams
+const ngramFlushSize = 1000000
+
+var ngramWordRegex = regexp.MustCompile(`[\p{L}\p{N}]+(?:'\p{L}+)?`)
+
+// createNgramTables creates the corpus_ngrams table filled by ComputeNgrams
+func (w *Wiki) createNgramTables() error {
+	_, err := w.db.Exec(`
+	CREATE TABLE IF NOT EXISTS corpus_ngrams (
+		ngram TEXT PRIMARY KEY,
+		frequency INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create corpus_ngrams table: %w", err)
+	}
+	return nil
+}
+
+// normalizeNgram collapses whitespace so lookups match the stored n-grams
+func normalizeNgram(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// countNgrams adds the n-grams of words in text to counts
+func countNgrams(text string, n int, counts map[string]int) {
+	words := ngramWordRegex.FindAllString(htmlCommentRegex.ReplaceAllString(text, ""), -1)
+	for i := 0; i+n <= len(words); i++ {
+		counts[strings.Join(words[i:i+n], " ")]++
+	}
+}
+
+// ComputeNgrams counts every n-gram of words in main namespace articles and
+// replaces the contents of corpus_ngrams with those seen at least minFrequency
+// times. Redirects are skipped. progress, if not nil, is called with the
+// number of articles processed so far.
+func (w *Wiki) ComputeNgrams(ctx context.Context, n int, minFrequency int, progress func(int64)) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid n-gram size %d", n)
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.db.ExecContext(ctx, "DELETE FROM corpus_ngrams"); err != nil {
+		return fmt.Errorf("failed to clear corpus_ngrams: %w", err)
+	}
+
+	counts := make(map[string]int)
+	var processed int64
+	var lastID int64
+	for {
+		rows, err := w.db.QueryContext(ctx, `
+			SELECT id, COALESCE(content, content_compressed) FROM articles
+			WHERE id > ? AND namespace = 0 AND (redirect IS NULL OR redirect = '')
+			ORDER BY id
+			LIMIT 1000
+		`, lastID)
+		if err != nil {
+			return fmt.Errorf("failed to read articles: %w", err)
+		}
+
+		batch := 0
+		for rows.Next() {
+			var content contentText
+			if err := rows.Scan(&lastID, &content); err != nil {
+				rows.Close()
+				return err
+			}
+			countNgrams(string(content), n, counts)
+			batch++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if batch == 0 {
+			break
+		}
+
+		processed += int64(batch)
+		if progress != nil {
+			progress(processed)
+		}
+
+		if len(counts) >= ngramFlushSize {
+			if err := w.flushNgrams(ctx, counts); err != nil {
+				return err
+			}
+			counts = make(map[string]int)
+		}
+	}
+
+	if err := w.flushNgrams(ctx, counts); err != nil {
+		return err
+	}
+
+	if _, err := w.db.ExecContext(ctx, "DELETE FROM corpus_ngrams WHERE frequency < ?", minFrequency); err != nil {
+		return fmt.Errorf("failed to prune n-grams: %w", err)
+	}
+
+	return nil
+}
+
+// flushNgrams adds counts to the frequencies stored in corpus_ngrams
+func (w *Wiki) flushNgrams(ctx context.Context, counts map[string]int) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO corpus_ngrams (ngram, frequency) VALUES (?, ?)
+		ON CONFLICT(ngram) DO UPDATE SET frequency = frequency + excluded.frequency
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for ngram, count := range counts {
+		if _, err := stmt.ExecContext(ctx, ngram, count); err != nil {
+			return fmt.Errorf("failed to store n-gram: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetNgramFrequency returns the number of occurrences of ngram, or 0 if it was
+// not counted by ComputeNgrams
+func (w *Wiki) GetNgramFrequency(ctx context.Context, ngram string) (int, error) {
+	if err := w.Open(); err != nil {
+		return 0, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var frequency int
+	err := w.db.QueryRowContext(ctx, "SELECT frequency FROM corpus_ngrams WHERE ngram = ?", normalizeNgram(ngram)).Scan(&frequency)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query n-gram frequency: %w", err)
+	}
+	return frequency, nil
+}
+
+// SearchNgrams returns the most frequent n-grams starting with prefix
+func (w *Wiki) SearchNgrams(ctx context.Context, prefix string, limit int) ([]NgramFreq, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(normalizeNgram(prefix))
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT ngram, frequency
+		FROM corpus_ngrams
+		WHERE ngram LIKE ? ESCAPE '\'
+		ORDER BY frequency DESC, ngram
+		LIMIT ?
+	`, escaped+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search n-grams: %w", err)
+	}
+	defer rows.Close()
+
+	ngrams := []NgramFreq{}
+	for rows.Next() {
+		var ngram NgramFreq
+		if err := rows.Scan(&ngram.Ngram, &ngram.Frequency); err != nil {
+			continue
+		}
+		ngrams = append(ngrams, ngram)
+	}
+
+	return ngrams, rows.Err()
+}
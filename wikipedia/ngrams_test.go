@@ -0,0 +1,47 @@
+package wikipedia
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestComputeNgrams(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "New York", Text: "New York is the largest city of the United States."},
+		testPage{ID: 2, Title: "Texas", Text: "Texas is a state of the United States. <!-- United States -->"},
+		testPage{ID: 3, Title: "Alaska", Text: "Alaska was bought by the United States."},
+		testPage{ID: 4, Title: "USA", Redirect: "United States", Text: "#REDIRECT [[United States]]"},
+	)
+	ctx := context.Background()
+
+	if err := w.ComputeNgrams(ctx, 2, 2, nil); err != nil {
+		t.Fatalf("ComputeNgrams: %v", err)
+	}
+
+	// Comments and redirects are not counted, and n-grams seen once are pruned
+	frequencies := map[string]int{
+		"United States":   3,
+		"United   States": 3,
+		"the United":      3,
+		"New York":        0,
+		"largest city":    0,
+	}
+	for ngram, want := range frequencies {
+		got, err := w.GetNgramFrequency(ctx, ngram)
+		if err != nil {
+			t.Fatalf("GetNgramFrequency(%q): %v", ngram, err)
+		}
+		if got != want {
+			t.Errorf("GetNgramFrequency(%q) = %d, want %d", ngram, got, want)
+		}
+	}
+
+	ngrams, err := w.SearchNgrams(ctx, "United", 10)
+	if err != nil {
+		t.Fatalf("SearchNgrams: %v", err)
+	}
+	if want := []NgramFreq{{"United States", 3}}; !slices.Equal(ngrams, want) {
+		t.Errorf("SearchNgrams(United) = %v, want %v", ngrams, want)
+	}
+}
@@ -0,0 +1,261 @@
+package wikipedia
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// articleStream is a bzip2 stream of a multistream articles file
+type articleStream struct {
+	seek, end int64
+	// maxID is the largest article ID the index places in the stream
+	maxID int64
+}
+
+// streamPages holds the pages decoded from the stream at index of the
+// streams processed by ProcessArticlesParallel
+type streamPages struct {
+	index int
+	pages []Page
+	err   error
+}
+
+// ProcessArticlesParallel is ProcessArticles decompressing the streams of a
+// bzip2 multistream articles file with several workers, each with its own file
+// descriptor. workers defaults to the number of CPUs. Pages are written by a
+// single goroutine in stream order, so -resume and limit behave as with
+// ProcessArticles. Other articles file formats cannot be split into
+// streams and are processed sequentially.
+func (w *Wiki) ProcessArticlesParallel(limit, workers int) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	if w.dryRun {
+		return w.dryRunProcessArticles(limit, nil)
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+	if err := w.checkCompression(); err != nil {
+		return err
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	start := time.Now()
+
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return fmt.Errorf("failed to open articles file: %w", err)
+	}
+	magic := make([]byte, len(bzip2Magic))
+	_, err = f.ReadAt(magic, 0)
+	f.Close()
+	if err != nil || !bytes.Equal(magic, bzip2Magic) {
+		w.logger.Warn("Articles file is not a bzip2 multistream file, processing it sequentially", "file", w.articlesFile)
+		return w.ProcessArticles(limit)
+	}
+
+	var resumeAfter int64
+	if w.resume {
+		if resumeAfter, err = w.lastProcessedArticleID(); err != nil {
+			return err
+		}
+		w.logger.Info("Resuming after article", "id", resumeAfter)
+	}
+
+	if err := w.importSiteNamespaces(); err != nil {
+		return err
+	}
+
+	streams, indexSet, err := w.articleStreams(resumeAfter)
+	if err != nil {
+		return err
+	}
+	w.logger.Info("Found articles to process from index", "count", len(indexSet), "streams", len(streams), "workers", workers)
+
+	total := len(indexSet)
+	if limit > 0 && limit < total {
+		total = limit
+	}
+
+	// Each worker reads with its own file descriptor
+	files := make([]*os.File, workers)
+	for i := range files {
+		if files[i], err = os.Open(w.articlesFile); err != nil {
+			for _, f := range files[:i] {
+				f.Close()
+			}
+			return fmt.Errorf("failed to open articles file: %w", err)
+		}
+	}
+
+	run, err := w.beginImportRun()
+	if err != nil {
+		return err
+	}
+	w.logger.Info("Starting import run", "run", run.id)
+
+	batch, err := w.newArticleBatch(run, nil, total, start)
+	if err != nil {
+		return err
+	}
+	defer batch.abort()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// inFlight bounds the streams decoded ahead of the writer, which waits for
+	// them in order
+	inFlight := make(chan struct{}, 2*workers)
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range streams {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan streamPages, workers)
+	var wg sync.WaitGroup
+	for _, f := range files {
+		wg.Add(1)
+		go func(f *os.File) {
+			defer wg.Done()
+			defer f.Close()
+			for i := range jobs {
+				result := streamPages{index: i}
+				result.err = decodeStreamPages(f, streams[i].seek, streams[i].end, func(page *Page) bool {
+					// Filtered here so that skipped pages are not kept in memory
+					id := int64(page.ID)
+					if w.processesNamespace(page.NS) && indexSet[id] && id > resumeAfter {
+						result.pages = append(result.pages, *page)
+					}
+					return true
+				})
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(f)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	w.logger.Info("Processing articles", "file", w.articlesFile, "workers", workers)
+
+	pending := make(map[int]streamPages)
+	next := 0
+	var writeErr error
+	done := false
+	for result := range results {
+		pending[result.index] = result
+		for !done && writeErr == nil {
+			stream, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-inFlight
+
+			if stream.err != nil {
+				w.logger.Error("Stream decode error", "seek", streams[stream.index].seek, "error", stream.err)
+			}
+			for i := range stream.pages {
+				if writeErr = batch.add(&stream.pages[i]); writeErr != nil {
+					break
+				}
+				if limit > 0 && batch.processed >= limit {
+					done = true
+					break
+				}
+			}
+		}
+		if done || writeErr != nil {
+			break
+		}
+	}
+
+	// Stop the workers and wait for them to close their files
+	cancel()
+	for range results {
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+	return w.finishArticleBatch(batch)
+}
+
+// articleStreams returns the streams of the articles file holding an indexed
+// article after resumeAfter, in file order, along with the IDs of all indexed
+// articles
+func (w *Wiki) articleStreams(resumeAfter int64) ([]articleStream, map[int64]bool, error) {
+	info, err := os.Stat(w.articlesFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat articles file: %w", err)
+	}
+
+	rows, err := w.db.Query("SELECT seek, article_id FROM index_entries ORDER BY seek")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query index entries: %w", err)
+	}
+	defer rows.Close()
+
+	var all []articleStream
+	indexSet := make(map[int64]bool)
+	for rows.Next() {
+		var seek, id int64
+		if err := rows.Scan(&seek, &id); err != nil {
+			continue
+		}
+		indexSet[id] = true
+		if n := len(all); n > 0 && all[n-1].seek == seek {
+			if id > all[n-1].maxID {
+				all[n-1].maxID = id
+			}
+			continue
+		}
+		all = append(all, articleStream{seek: seek, maxID: id})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// A stream ends where the next one starts, the last one at the end of the file
+	streams := make([]articleStream, 0, len(all))
+	for i, stream := range all {
+		stream.end = info.Size()
+		if i+1 < len(all) {
+			stream.end = all[i+1].seek
+		}
+		if stream.seek >= 0 && stream.seek < stream.end && stream.maxID > resumeAfter {
+			streams = append(streams, stream)
+		}
+	}
+
+	return streams, indexSet, nil
+}
@@ -0,0 +1,148 @@
+package wikipedia
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseResult reports the throughput of a parse-only pass over a dump file
+type ParseResult struct {
+	ArticlesParsed int64         `json:"articles_parsed"`
+	BytesRead      int64         `json:"bytes_read"`
+	Duration       time.Duration `json:"duration"`
+	ThroughputMBps float64       `json:"throughput_mbps"`
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// finish fills in the duration and throughput of a parse result
+func (p *ParseResult) finish(start time.Time) {
+	p.Duration = time.Since(start)
+	if seconds := p.Duration.Seconds(); seconds > 0 {
+		p.ThroughputMBps = float64(p.BytesRead) / (1024 * 1024) / seconds
+	}
+}
+
+// ParseArticlesOnly reads, decompresses and decodes the articles dump without
+// touching the database. BytesRead counts decompressed XML bytes, so the result
+// is the upper bound on ProcessArticles throughput.
+func (w *Wiki) ParseArticlesOnly(ctx context.Context, limit int) (*ParseResult, error) {
+	f, err := os.Open(w.articlesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open articles file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	counter := &countingReader{r: r}
+	decoder := xml.NewDecoder(counter)
+	result := &ParseResult{}
+	start := time.Now()
+
+	w.logger.Info("Parsing articles (parse only)", "file", w.articlesFile)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var page Page
+		err := decoder.Decode(&page)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			w.logger.Error("XML decode error", "error", err)
+			continue
+		}
+
+		result.ArticlesParsed++
+		if limit > 0 && result.ArticlesParsed >= int64(limit) {
+			break
+		}
+	}
+
+	result.BytesRead = counter.n
+	result.finish(start)
+	return result, nil
+}
+
+// ParseIndexOnly reads, decompresses and parses the index file without
+// touching the database. ArticlesParsed counts valid index lines.
+func (w *Wiki) ParseIndexOnly(ctx context.Context, limit int) (*ParseResult, error) {
+	f, err := os.Open(w.indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream reader: %w", err)
+	}
+	defer r.Close()
+
+	counter := &countingReader{r: r}
+	scanner := bufio.NewScanner(counter)
+	buf := make([]byte, 0, 16*1024)
+	scanner.Buffer(buf, 16*1024)
+
+	result := &ParseResult{}
+	start := time.Now()
+
+	w.logger.Info("Parsing index file (parse only)", "file", w.indexFile)
+
+	for scanner.Scan() {
+		if result.ArticlesParsed%10000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		if _, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
+			continue
+		}
+		if _, err := strconv.ParseInt(parts[1], 10, 64); err != nil {
+			continue
+		}
+
+		result.ArticlesParsed++
+		if limit > 0 && result.ArticlesParsed >= int64(limit) {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+
+	result.BytesRead = counter.n
+	result.finish(start)
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	refRegex           = regexp.MustCompile(`(?is)<ref[^>/]*/>|<ref[^>]*>.*?</ref\s*>`)
+	innerTemplateRegex = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	wikiTableRegex     = regexp.MustCompile(`(?s)\{\|.*?\|\}`)
+	innerLinkRegex     = regexp.MustCompile(`\[\[([^\[\]|]*)(?:\|([^\[\]]*))?\]\]`)
+	externalLinkRegex  = regexp.MustCompile(`\[(?:https?:)?//[^\s\]]+(?:\s+([^\]]*))?\]`)
+	htmlTagRegex       = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	lineBreakRegex     = regexp.MustCompile(`(?i)<br\s*/?>`)
+	headingRegex       = regexp.MustCompile(`(?m)^[ \t]*=+[ \t]*(.*?)[ \t]*=+[ \t]*$`)
+	emphasisRegex      = regexp.MustCompile(`'{2,}`)
+	blankLinesRegex    = regexp.MustCompile(`\n{3,}`)
+
+	nowikiPlaceholderRegex = regexp.MustCompile("\x00[0-9]+\x00")
+)
+
+// replaceNested applies re repeatedly so that nested constructs are removed
+// from the innermost outwards
+func replaceNested(re *regexp.Regexp, s string, repl func(string) string) string {
+	for {
+		next := re.ReplaceAllStringFunc(s, repl)
+		if next == s {
+			return s
+		}
+		s = next
+	}
+}
+
+// StripWikitext converts wikitext to plain text: comments, references,
+// templates, tables, file and category links and HTML tags are removed,
+// <br> tags become line breaks, internal and external links are replaced by
+// their display text, and heading and bold/italic markup is dropped
+func StripWikitext(content string) string {
+	// <nowiki> blocks are kept verbatim: set them aside until the end
+	var nowiki []string
+	text := nowikiRegex.ReplaceAllStringFunc(content, func(block string) string {
+		nowiki = append(nowiki, htmlTagRegex.ReplaceAllString(block, ""))
+		return "\x00" + strconv.Itoa(len(nowiki)-1) + "\x00"
+	})
+	text = htmlCommentRegex.ReplaceAllString(text, "")
+	text = refRegex.ReplaceAllString(text, "")
+	text = replaceNested(innerTemplateRegex, text, func(string) string { return "" })
+	text = wikiTableRegex.ReplaceAllString(text, "")
+
+	text = replaceNested(innerLinkRegex, text, func(link string) string {
+		match := innerLinkRegex.FindStringSubmatch(link)
+		target := match[1]
+		if prefix, _, ok := strings.Cut(target, ":"); ok && !strings.HasPrefix(target, ":") {
+			if ns, isNamespace := GetNamespaceByName(prefix); isNamespace && (ns == 6 || ns == 14) {
+				return ""
+			}
+			if languageLinkRegex.MatchString("[[" + prefix + ":x]]") {
+				return ""
+			}
+		}
+		if match[2] != "" {
+			return match[2]
+		}
+		return strings.TrimPrefix(target, ":")
+	})
+	text = externalLinkRegex.ReplaceAllString(text, "$1")
+
+	text = lineBreakRegex.ReplaceAllString(text, "\n")
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	text = headingRegex.ReplaceAllString(text, "$1")
+	text = emphasisRegex.ReplaceAllString(text, "")
+	text = nowikiPlaceholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		i, _ := strconv.Atoi(strings.Trim(placeholder, "\x00"))
+		return nowiki[i]
+	})
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, " ", " ")
+	text = blankLinesRegex.ReplaceAllString(text, "\n\n")
+
+	return strings.TrimSpace(text)
+}
+
+// WithStripText makes ProcessArticles store the StripWikitext version of each
+// article in the plain_text column
+func (w *Wiki) WithStripText(enabled bool) *Wiki {
+	w.stripText = enabled
+	return w
+}
+
+// plainTextValue returns the plain_text column value of an article
+func (w *Wiki) plainTextValue(content string) sql.NullString {
+	if !w.stripText {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: StripWikitext(content), Valid: true}
+}
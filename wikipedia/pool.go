@@ -0,0 +1,59 @@
+package wikipedia
+
+import (
+	"errors"
+	"sort"
+)
+
+// WikiConfig locates the dump files and database of one language edition
+type WikiConfig struct {
+	DumpPath     string
+	IndexFile    string
+	ArticlesFile string
+	// CacheSize is the number of articles cached in memory; 0 disables the cache
+	CacheSize int
+}
+
+// WikiPool holds one Wiki per language edition, keyed by language code (e.g.
+// "en", "de"). Each Wiki has its own database file and lock, so queries to
+// different languages run concurrently.
+type WikiPool struct {
+	wikis map[string]*Wiki
+}
+
+// NewWikiPool creates a Wiki for each configured language. Databases are
+// opened on first use.
+func NewWikiPool(langs map[string]WikiConfig) *WikiPool {
+	wikis := make(map[string]*Wiki, len(langs))
+	for lang, config := range langs {
+		wikis[lang] = NewWiki(config.DumpPath, config.IndexFile, config.ArticlesFile).WithCacheSize(config.CacheSize)
+	}
+	return &WikiPool{wikis: wikis}
+}
+
+// Get returns the Wiki of a language
+func (p *WikiPool) Get(lang string) (*Wiki, bool) {
+	w, ok := p.wikis[lang]
+	return w, ok
+}
+
+// Languages returns the language codes of the pool, sorted
+func (p *WikiPool) Languages() []string {
+	langs := make([]string, 0, len(p.wikis))
+	for lang := range p.wikis {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Close closes the databases of every language
+func (p *WikiPool) Close() error {
+	var errs []error
+	for _, w := range p.wikis {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
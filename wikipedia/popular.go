@@ -0,0 +1,143 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PopularCategoriesTTL is how long GetPopularCategories results are cached
+const PopularCategoriesTTL = time.Hour
+
+// ErrSearchLoggingDisabled is returned by GetPopularCategories when searches
+// are not recorded (see WithSearchLogging)
+var ErrSearchLoggingDisabled = errors.New("search logging is disabled")
+
+// PopularCategory is a category with the number of searches that hit one of
+// its articles
+type PopularCategory struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// popularCategories is a cached GetPopularCategories result
+type popularCategories struct {
+	categories []PopularCategory
+	expires    time.Time
+}
+
+// createSearchLogTables creates the table of the searches recorded by
+// RecordSearch
+func (w *Wiki) createSearchLogTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS search_queries (
+			id INTEGER PRIMARY KEY,
+			query TEXT NOT NULL,
+			article_id INTEGER,
+			searched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_search_queries_searched_at ON search_queries(searched_at)",
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create search_queries table: %w", err)
+		}
+	}
+	return nil
+}
+
+// WithSearchLogging makes RecordSearch store the searches in the database, so
+// that GetPopularCategories can rank the categories of their results
+func (w *Wiki) WithSearchLogging(enabled bool) *Wiki {
+	w.logSearches = enabled
+	return w
+}
+
+// RecordSearch stores a search and the ID of its first result, 0 when it had
+// none. It does nothing unless search logging is enabled and the database is
+// writable.
+func (w *Wiki) RecordSearch(ctx context.Context, query string, articleID int64) error {
+	if !w.logSearches || w.readOnly {
+		return nil
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var id any
+	if articleID != 0 {
+		id = articleID
+	}
+	_, err := w.db.ExecContext(ctx, "INSERT INTO search_queries (query, article_id, searched_at) VALUES (?, ?, ?)",
+		query, id, time.Now().UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("failed to record search: %w", err)
+	}
+	return nil
+}
+
+// GetPopularCategories returns the categories whose articles were hit most
+// often by the searches recorded since the given time, the most searched
+// first. Results are cached for PopularCategoriesTTL.
+func (w *Wiki) GetPopularCategories(ctx context.Context, since time.Time, limit int) ([]PopularCategory, error) {
+	if !w.logSearches {
+		return nil, ErrSearchLoggingDisabled
+	}
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	sinceStr := since.UTC().Format(time.DateTime)
+	key := sinceStr + ":" + strconv.Itoa(limit)
+	w.popularMu.Lock()
+	cached, ok := w.popularCache[key]
+	w.popularMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.categories, nil
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT c.category_name, COUNT(*)
+		FROM search_queries s
+		JOIN categories c ON c.article_id = s.article_id
+		WHERE s.searched_at >= ?
+		GROUP BY c.category_name
+		ORDER BY COUNT(*) DESC, c.category_name
+		LIMIT ?
+	`, sinceStr, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popular categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []PopularCategory{}
+	for rows.Next() {
+		var category PopularCategory
+		if err := rows.Scan(&category.Category, &category.Count); err != nil {
+			return nil, fmt.Errorf("failed to read popular categories: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read popular categories: %w", err)
+	}
+
+	w.popularMu.Lock()
+	if w.popularCache == nil {
+		w.popularCache = make(map[string]popularCategories)
+	}
+	w.popularCache[key] = popularCategories{categories: categories, expires: time.Now().Add(PopularCategoriesTTL)}
+	w.popularMu.Unlock()
+	return categories, nil
+}
@@ -0,0 +1,74 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetPopularCategories(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Quantum mechanics", Text: "Waves.\n[[Category:Physics]]"},
+		testPage{ID: 2, Title: "Relativity", Text: "Spacetime.\n[[Category:Physics]]"},
+		testPage{ID: 3, Title: "Cell", Text: "Life.\n[[Category:Biology]]"},
+		testPage{ID: 4, Title: "Biophysics", Text: "Both.\n[[Category:Biology]]\n[[Category:Physics]]"},
+	)
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+
+	if _, err := w.GetPopularCategories(ctx, since, 10); !errors.Is(err, ErrSearchLoggingDisabled) {
+		t.Fatalf("GetPopularCategories without search logging: got %v, want ErrSearchLoggingDisabled", err)
+	}
+	if err := w.RecordSearch(ctx, "waves", 1); err != nil {
+		t.Fatalf("RecordSearch without search logging: %v", err)
+	}
+
+	w.WithSearchLogging(true)
+	// 5 searches hit Physics articles and 2 hit Biology ones, one of them
+	// Biophysics which is in both; searches without results are not counted
+	searches := []struct {
+		query     string
+		articleID int64
+	}{
+		{"quantum", 1}, {"waves", 1}, {"relativity", 2}, {"spacetime", 2},
+		{"cell", 3}, {"biophysics", 4}, {"nothing", 0},
+	}
+	for _, s := range searches {
+		if err := w.RecordSearch(ctx, s.query, s.articleID); err != nil {
+			t.Fatalf("RecordSearch(%q): %v", s.query, err)
+		}
+	}
+
+	want := []PopularCategory{{"Physics", 5}, {"Biology", 2}}
+	got, err := w.GetPopularCategories(ctx, since, 10)
+	if err != nil {
+		t.Fatalf("GetPopularCategories: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetPopularCategories = %v, want %v", got, want)
+	}
+
+	if got, _ := w.GetPopularCategories(ctx, since, 1); !slices.Equal(got, want[:1]) {
+		t.Errorf("GetPopularCategories with limit 1 = %v, want %v", got, want[:1])
+	}
+	if got, _ := w.GetPopularCategories(ctx, time.Now().Add(time.Hour), 10); len(got) != 0 {
+		t.Errorf("GetPopularCategories since a later time = %v, want none", got)
+	}
+
+	// Results are cached, so a new search is only counted once they expire
+	if err := w.RecordSearch(ctx, "cell", 3); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := w.GetPopularCategories(ctx, since, 10); !slices.Equal(got, want) {
+		t.Errorf("cached GetPopularCategories = %v, want %v", got, want)
+	}
+	w.popularMu.Lock()
+	clear(w.popularCache)
+	w.popularMu.Unlock()
+	want = []PopularCategory{{"Physics", 5}, {"Biology", 3}}
+	if got, _ := w.GetPopularCategories(ctx, since, 10); !slices.Equal(got, want) {
+		t.Errorf("GetPopularCategories after the cache expired = %v, want %v", got, want)
+	}
+}
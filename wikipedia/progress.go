@@ -0,0 +1,31 @@
+package wikipedia
+
+import "time"
+
+// ProgressEvent reports the progress of a long-running import phase
+type ProgressEvent struct {
+	Phase          string  `json:"phase"`
+	Count          int     `json:"count"`
+	Total          int     `json:"total"` // 0 when unknown
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// Import phases reported in ProgressEvent.Phase
+const (
+	PhaseLoadIndex       = "load-index"
+	PhaseProcessArticles = "process-articles"
+)
+
+// sendProgress sends a progress event unless progress is nil. The send blocks
+// until the event is received, so callers should drain the channel.
+func sendProgress(progress chan<- ProgressEvent, phase string, count, total int, start time.Time) {
+	if progress == nil {
+		return
+	}
+	progress <- ProgressEvent{
+		Phase:          phase,
+		Count:          count,
+		Total:          total,
+		ElapsedSeconds: time.Since(start).Seconds(),
+	}
+}
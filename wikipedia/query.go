@@ -0,0 +1,99 @@
+package wikipedia
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchOperators are the boolean operators understood by FTS4 and FTS5; like
+// FTS itself, they are only recognised in uppercase
+var searchOperators = map[string]bool{"AND": true, "OR": true, "NOT": true}
+
+// ftsSyntaxReplacer removes the FTS syntax of the tokens of ParseSearchQuery
+var ftsSyntaxReplacer = strings.NewReplacer(`"`, " ", "*", " ")
+
+// ftsTokens splits s into the runs of letters and digits indexed by the FTS
+// tokenizers, dropping the punctuation around and between them, which FTS5
+// rejects in bare words (as in "C++" or "AT&T") or reads as syntax (the
+// column filter of "Jean-Paul" or a leading - for NOT)
+func ftsTokens(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// ParseSearchQuery turns a title search typed by a user into an FTS MATCH
+// expression (see SEARCH_SYNTAX.md). Quoted phrases are kept as phrases, the
+// uppercase operators AND, OR and NOT are kept between terms and every bare
+// word becomes a prefix query; words and phrases are reduced to their letters
+// and digits, so "Jean-Paul" is searched as Jean* Paul*. Operators that are
+// not between two terms are dropped so that the result is always a valid
+// query, empty when the search has no letter or digit.
+func ParseSearchQuery(raw string) string {
+	var tokens []string
+	isOperator := func(token string) bool { return searchOperators[token] }
+
+	rest := raw
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			break
+		}
+
+		if rest[0] == '"' {
+			// An unterminated phrase runs to the end of the query
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			if phrase = strings.Join(ftsTokens(phrase), " "); phrase != "" {
+				tokens = append(tokens, `"`+phrase+`"`)
+			}
+			rest = after
+			continue
+		}
+
+		end := strings.IndexAny(rest, " \t\r\n\"")
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+
+		if isOperator(word) {
+			tokens = append(tokens, word)
+			continue
+		}
+		for _, part := range ftsTokens(word) {
+			tokens = append(tokens, part+"*")
+		}
+	}
+
+	// Keep operators only when they join two terms
+	var query []string
+	for i, token := range tokens {
+		if isOperator(token) {
+			if len(query) == 0 || isOperator(query[len(query)-1]) || i == len(tokens)-1 {
+				continue
+			}
+		}
+		query = append(query, token)
+	}
+	if len(query) > 0 && isOperator(query[len(query)-1]) {
+		query = query[:len(query)-1]
+	}
+
+	return strings.Join(query, " ")
+}
+
+// searchTerms returns the phrases and words of a title search typed by a user,
+// without operators and FTS syntax, for highlighting them outside FTS
+func searchTerms(raw string) []string {
+	var terms []string
+	for _, token := range strings.Fields(ParseSearchQuery(raw)) {
+		if searchOperators[token] {
+			continue
+		}
+		if term := strings.TrimSpace(ftsSyntaxReplacer.Replace(token)); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,136 @@
+package wikipedia
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"bare word", "pyth", "pyth*"},
+		{"bare words", "new york", "new* york*"},
+		{"phrase", `"New York"`, `"New York"`},
+		{"phrase and word", `"New York" city`, `"New York" city*`},
+		{"unterminated phrase", `"New York`, `"New York"`},
+		{"empty phrase", `"" york`, "york*"},
+		{"OR", "cats OR dogs", "cats* OR dogs*"},
+		{"NOT", "python NOT monty", "python* NOT monty*"},
+		{"AND with phrase", `"United States" AND army`, `"United States" AND army*`},
+		{"lowercase operators are words", "to be or not to be", "to* be* or* not* to* be*"},
+		{"leading operator", "AND cats", "cats*"},
+		{"trailing operator", "cats OR", "cats*"},
+		{"repeated operators", "cats OR AND dogs", "cats* OR dogs*"},
+		{"operators only", "AND OR NOT", ""},
+		{"mixed", `"Albert Einstein" OR relativity NOT "special theory"`, `"Albert Einstein" OR relativity* NOT "special theory"`},
+		{"hyphen", "Jean-Paul", "Jean* Paul*"},
+		{"hyphen in phrase", `"Jean-Paul Sartre"`, `"Jean Paul Sartre"`},
+		{"plus signs", "C++", "C*"},
+		{"dots", "U.S.", "U* S*"},
+		{"ampersand", "AT&T", "AT* T*"},
+		{"column filter", "title:Paris", "title* Paris*"},
+		{"leading minus", "-war", "war*"},
+		{"FTS syntax", "(a* OR ^b)", "a* OR b*"},
+		{"punctuation only", "++ -- ...", ""},
+		{"empty", "  ", ""},
+		{"non-ASCII", "Zürich Ω", "Zürich* Ω*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSearchQuery(tt.raw); got != tt.want {
+				t.Errorf("ParseSearchQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+// Every MATCH expression of ParseSearchQuery is accepted by the full-text
+// index of this SQLite build
+func TestParseSearchQueryIsValidFTS(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Jean-Paul Sartre", Text: "French philosopher"},
+		testPage{ID: 2, Title: "AT&T", Text: "Telephone firm"},
+		testPage{ID: 3, Title: "C++", Text: "Programming language"},
+	)
+	if w.ftsVersion == "none" {
+		t.Skip("full-text search is not available")
+	}
+
+	queries := []string{
+		"Jean-Paul", "C++", "U.S.", "AT&T", "title:Paris", "-war", "(a* OR ^b)", `"New York" OR`,
+		"NEAR(a b)", `a "b`, "x AND NOT y", "{title}: z", "a + b", "'quoted'",
+	}
+	for _, query := range queries {
+		match := ParseSearchQuery(query)
+		if match == "" {
+			continue
+		}
+		rows, err := w.db.Query("SELECT rowid FROM articles_fts WHERE articles_fts MATCH ?", match)
+		if err != nil {
+			t.Errorf("MATCH %q for %q: %v", match, query, err)
+			continue
+		}
+		for rows.Next() {
+		}
+		if err := rows.Err(); err != nil {
+			t.Errorf("MATCH %q for %q: %v", match, query, err)
+		}
+		rows.Close()
+	}
+}
+
+// Searches that cannot use the full-text index fall back to LIKE for
+// themselves only
+func TestSearchTitlesPunctuation(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Jean-Paul Sartre", Text: "French philosopher"},
+		testPage{ID: 2, Title: "AT&T", Text: "Telephone firm"},
+		testPage{ID: 3, Title: "C++", Text: "Programming language"},
+		testPage{ID: 4, Title: "U.S. Route 66", Text: "Highway"},
+	)
+	fts := w.ftsVersion
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"Jean-Paul", []string{"Jean-Paul Sartre"}},
+		{"AT&T", []string{"AT&T"}},
+		{"U.S.", []string{"U.S. Route 66"}},
+		{"C++", []string{"C++"}},
+		// No letter or digit: matched as a substring of titles
+		{"++", []string{"C++"}},
+		{"&", []string{"AT&T"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			titles, err := w.SearchTitles(tt.query, 10, 0)
+			if err != nil {
+				t.Fatalf("SearchTitles(%q): %v", tt.query, err)
+			}
+			if len(titles) != len(tt.want) {
+				t.Fatalf("SearchTitles(%q) = %q, want %q", tt.query, titles, tt.want)
+			}
+			for i := range titles {
+				if titles[i] != tt.want[i] {
+					t.Errorf("SearchTitles(%q) = %q, want %q", tt.query, titles, tt.want)
+				}
+			}
+
+			count, err := w.CountSearchResults(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("CountSearchResults(%q): %v", tt.query, err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("CountSearchResults(%q) = %d, want %d", tt.query, count, len(tt.want))
+			}
+		})
+	}
+
+	if w.ftsVersion != fts {
+		t.Errorf("full-text search changed from %q to %q", fts, w.ftsVersion)
+	}
+}
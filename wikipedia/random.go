@@ -0,0 +1,129 @@
+package wikipedia
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxRandomArticles is the largest batch GetRandomArticles returns
+	MaxRandomArticles = 100
+
+	// randomSummaryLength is the number of characters of content kept by
+	// GetRandomArticles unless the full content is requested
+	randomSummaryLength = 500
+)
+
+// ErrTooManyRandomArticles is returned when more than MaxRandomArticles
+// random articles are requested
+var ErrTooManyRandomArticles = errors.New("too many random articles requested")
+
+// RandomArticle returns a random non-redirect article from namespace ns
+func (w *Wiki) RandomArticle(ns int) (*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var article Article
+	err := w.db.QueryRow(`
+		SELECT `+articleColumns+`
+		FROM articles
+		WHERE namespace = ? AND (redirect IS NULL OR redirect = '')
+		ORDER BY RANDOM()
+		LIMIT 1
+	`, ns).Scan(article.scanFields()...)
+	if err != nil {
+		return nil, fmt.Errorf("no article found in namespace %d", ns)
+	}
+
+	return &article, nil
+}
+
+// RandomArticleSeeded picks a non-redirect article from namespace ns
+// deterministically from seed: the same seed returns the same article as long
+// as the namespace does not change
+func (w *Wiki) RandomArticleSeeded(ns int, seed int64) (*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var article Article
+	err := w.db.QueryRow(`
+		SELECT `+articleColumns+`
+		FROM articles
+		WHERE namespace = ? AND (redirect IS NULL OR redirect = '')
+		ORDER BY id
+		LIMIT 1 OFFSET ABS(CAST(? AS INTEGER)) % MAX((
+			SELECT COUNT(*) FROM articles
+			WHERE namespace = ? AND (redirect IS NULL OR redirect = '')
+		), 1)
+	`, ns, seed, ns).Scan(article.scanFields()...)
+	if err != nil {
+		return nil, fmt.Errorf("no article found in namespace %d", ns)
+	}
+
+	return &article, nil
+}
+
+// GetRandomArticles returns n distinct random non-redirect articles from
+// namespace ns. Their content is cut to its first 500 characters unless
+// includeFullContent is set. n may not exceed MaxRandomArticles.
+func (w *Wiki) GetRandomArticles(n int, ns int, includeFullContent bool) ([]*Article, error) {
+	if n > MaxRandomArticles {
+		return nil, ErrTooManyRandomArticles
+	}
+	if n <= 0 {
+		n = 1
+	}
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.Query(`
+		SELECT `+articleColumns+`
+		FROM articles
+		WHERE namespace = ? AND (redirect IS NULL OR redirect = '')
+		ORDER BY RANDOM()
+		LIMIT ?
+	`, ns, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query random articles: %w", err)
+	}
+	defer rows.Close()
+
+	articles := []*Article{}
+	for rows.Next() {
+		var article Article
+		if err := rows.Scan(article.scanFields()...); err != nil {
+			continue
+		}
+		if !includeFullContent {
+			article.Content = truncateChars(article.Content, randomSummaryLength)
+		}
+		articles = append(articles, &article)
+	}
+
+	return articles, rows.Err()
+}
+
+// truncateChars returns the first n characters of s
+func truncateChars(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
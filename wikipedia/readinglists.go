@@ -0,0 +1,229 @@
+package wikipedia
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrReadingListNotFound is returned for operations on an unknown reading list
+	ErrReadingListNotFound = errors.New("reading list not found")
+
+	// ErrArticleNotFound is returned when an article ID is not in the database
+	ErrArticleNotFound = errors.New("article not found")
+)
+
+// ReadingList is a named collection of articles saved by a client
+type ReadingList struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	ArticleCount int       `json:"article_count"`
+}
+
+// createReadingListTables creates the reading list tables. Items are kept when
+// their article is deleted and reappear if it is imported again.
+func (w *Wiki) createReadingListTables() error {
+	tables := []string{
+		`CREATE TABLE IF NOT EXISTS reading_lists (
+			id TEXT PRIMARY KEY,
+			name TEXT,
+			created_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS reading_list_items (
+			list_id TEXT NOT NULL,
+			article_id INTEGER NOT NULL,
+			added_at DATETIME,
+			PRIMARY KEY (list_id, article_id)
+		)`,
+	}
+
+	for _, table := range tables {
+		if _, err := w.db.Exec(table); err != nil {
+			return fmt.Errorf("failed to create reading list tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateList creates an empty reading list
+func (w *Wiki) CreateList(ctx context.Context, name string) (*ReadingList, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate list ID: %w", err)
+	}
+
+	list := &ReadingList{ID: hex.EncodeToString(id), Name: name, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	_, err := w.db.ExecContext(ctx, "INSERT INTO reading_lists (id, name, created_at) VALUES (?, ?, ?)", list.ID, list.Name, list.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reading list: %w", err)
+	}
+	return list, nil
+}
+
+// listExists reports whether a reading list exists; the caller must hold w.mu
+func (w *Wiki) listExists(ctx context.Context, listID string) (bool, error) {
+	var count int
+	if err := w.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM reading_lists WHERE id = ?", listID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to look up reading list: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AddToList adds an article to a reading list; adding it twice has no effect
+func (w *Wiki) AddToList(ctx context.Context, listID string, articleID int64) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if exists, err := w.listExists(ctx, listID); err != nil {
+		return err
+	} else if !exists {
+		return ErrReadingListNotFound
+	}
+
+	var count int
+	if err := w.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM articles WHERE id = ?", articleID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to look up article: %w", err)
+	}
+	if count == 0 {
+		return ErrArticleNotFound
+	}
+
+	_, err := w.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO reading_list_items (list_id, article_id, added_at)
+		VALUES (?, ?, ?)
+	`, listID, articleID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add article to reading list: %w", err)
+	}
+	return nil
+}
+
+// RemoveFromList removes an article from a reading list
+func (w *Wiki) RemoveFromList(ctx context.Context, listID string, articleID int64) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if exists, err := w.listExists(ctx, listID); err != nil {
+		return err
+	} else if !exists {
+		return ErrReadingListNotFound
+	}
+
+	if _, err := w.db.ExecContext(ctx, "DELETE FROM reading_list_items WHERE list_id = ? AND article_id = ?", listID, articleID); err != nil {
+		return fmt.Errorf("failed to remove article from reading list: %w", err)
+	}
+	return nil
+}
+
+// DeleteList deletes a reading list and its items
+func (w *Wiki) DeleteList(ctx context.Context, listID string) error {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM reading_lists WHERE id = ?", listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reading list: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrReadingListNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM reading_list_items WHERE list_id = ?", listID); err != nil {
+		return fmt.Errorf("failed to delete reading list items: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetList returns the articles of a reading list in the order they were added
+func (w *Wiki) GetList(ctx context.Context, listID string) ([]*ArticleMeta, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if exists, err := w.listExists(ctx, listID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrReadingListNotFound
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT a.id, a.title, a.namespace, a.redirect
+		FROM reading_list_items i
+		JOIN articles a ON a.id = i.article_id
+		WHERE i.list_id = ?
+		ORDER BY i.added_at, a.id
+	`, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reading list: %w", err)
+	}
+	defer rows.Close()
+
+	return scanArticleMetas(rows)
+}
+
+// ListLists returns all reading lists, newest first
+func (w *Wiki) ListLists(ctx context.Context) ([]ReadingList, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT l.id, l.name, l.created_at, COUNT(i.article_id)
+		FROM reading_lists l
+		LEFT JOIN reading_list_items i ON i.list_id = l.id
+		GROUP BY l.id
+		ORDER BY l.created_at DESC, l.rowid DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reading lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []ReadingList{}
+	for rows.Next() {
+		var list ReadingList
+		if err := rows.Scan(&list.ID, &list.Name, &list.CreatedAt, &list.ArticleCount); err != nil {
+			continue
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
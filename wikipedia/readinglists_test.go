@@ -0,0 +1,86 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReadingLists(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "The science of matter."},
+		testPage{ID: 2, Title: "Chemistry", Text: "The science of substances."},
+		testPage{ID: 3, Title: "Biology", Text: "The science of life."},
+	)
+	ctx := context.Background()
+
+	list, err := w.CreateList(ctx, "Sciences")
+	if err != nil {
+		t.Fatalf("CreateList: %v", err)
+	}
+	if list.ID == "" || list.Name != "Sciences" {
+		t.Fatalf("CreateList = %+v, want a list named Sciences with an ID", list)
+	}
+
+	// Adding an article twice has no effect
+	for _, id := range []int64{1, 3, 2, 1} {
+		if err := w.AddToList(ctx, list.ID, id); err != nil {
+			t.Fatalf("AddToList(%d): %v", id, err)
+		}
+	}
+	if err := w.AddToList(ctx, list.ID, 99); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("AddToList of a missing article: got %v, want ErrArticleNotFound", err)
+	}
+	if err := w.AddToList(ctx, "missing", 1); !errors.Is(err, ErrReadingListNotFound) {
+		t.Errorf("AddToList to a missing list: got %v, want ErrReadingListNotFound", err)
+	}
+
+	listIDs := func() []int64 {
+		t.Helper()
+		articles, err := w.GetList(ctx, list.ID)
+		if err != nil {
+			t.Fatalf("GetList: %v", err)
+		}
+		var ids []int64
+		for _, article := range articles {
+			ids = append(ids, article.ID)
+		}
+		return ids
+	}
+	if got, want := listIDs(), []int64{1, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("GetList = %v, want %v", got, want)
+	}
+
+	lists, err := w.ListLists(ctx)
+	if err != nil {
+		t.Fatalf("ListLists: %v", err)
+	}
+	if len(lists) != 1 || lists[0].ID != list.ID || lists[0].ArticleCount != 3 {
+		t.Errorf("ListLists = %+v, want the list with 3 articles", lists)
+	}
+
+	if err := w.RemoveFromList(ctx, list.ID, 3); err != nil {
+		t.Fatalf("RemoveFromList: %v", err)
+	}
+	if got, want := listIDs(), []int64{1, 2}; !slices.Equal(got, want) {
+		t.Errorf("GetList after RemoveFromList = %v, want %v", got, want)
+	}
+
+	if err := w.DeleteList(ctx, list.ID); err != nil {
+		t.Fatalf("DeleteList: %v", err)
+	}
+	if _, err := w.GetList(ctx, list.ID); !errors.Is(err, ErrReadingListNotFound) {
+		t.Errorf("GetList of a deleted list: got %v, want ErrReadingListNotFound", err)
+	}
+	if err := w.DeleteList(ctx, list.ID); !errors.Is(err, ErrReadingListNotFound) {
+		t.Errorf("DeleteList of a deleted list: got %v, want ErrReadingListNotFound", err)
+	}
+	if lists, _ := w.ListLists(ctx); len(lists) != 0 {
+		t.Errorf("ListLists after DeleteList = %+v, want none", lists)
+	}
+	var items int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM reading_list_items").Scan(&items); err != nil || items != 0 {
+		t.Errorf("%d reading list items left after DeleteList (%v), want 0", items, err)
+	}
+}
@@ -0,0 +1,225 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// RecoveryResult reports what RecoverDatabase copied to the new database
+type RecoveryResult struct {
+	TablesRecovered int `json:"tables_recovered"`
+	RowsRecovered   int `json:"rows_recovered"`
+	RowsLost        int `json:"rows_lost"`
+}
+
+// recoverBatchSize is the number of rows read from the damaged database per query
+const recoverBatchSize = 1000
+
+// schemaObject is a row of sqlite_master
+type schemaObject struct {
+	kind, name, sql string
+}
+
+// quoteIdent quotes an SQLite identifier
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// RecoverDatabase copies everything still readable from a corrupt database at
+// srcPath into a new database at dstPath. Tables are copied row by row in rowid
+// order; rows on damaged pages are skipped and counted in RowsLost (a damaged
+// range counts once, as its size is unknown). Indexes, triggers and views are
+// recreated afterwards and full-text indexes are rebuilt from the copied rows.
+func RecoverDatabase(ctx context.Context, srcPath, dstPath string) (*RecoveryResult, error) {
+	if _, err := os.Stat(dstPath); err == nil {
+		return nil, fmt.Errorf("recovery output %s already exists", dstPath)
+	}
+
+	src, err := sql.Open(sqliteDriver, "file:"+srcPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open source database: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := sql.Open(sqliteDriver, dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recovery database: %w", err)
+	}
+	defer dst.Close()
+
+	rows, err := src.QueryContext(ctx, `
+		SELECT type, name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	var objects []schemaObject
+	for rows.Next() {
+		var obj schemaObject
+		if err := rows.Scan(&obj.kind, &obj.name, &obj.sql); err != nil {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	// Virtual table shadow tables are recreated along with their virtual table
+	var virtual []string
+	for _, obj := range objects {
+		if obj.kind == "table" && strings.HasPrefix(strings.ToUpper(obj.sql), "CREATE VIRTUAL") {
+			virtual = append(virtual, obj.name)
+		}
+	}
+	isShadow := func(name string) bool {
+		for _, v := range virtual {
+			if strings.HasPrefix(name, v+"_") {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := &RecoveryResult{}
+	for _, obj := range objects {
+		if obj.kind != "table" || isShadow(obj.name) || strings.HasPrefix(strings.ToUpper(obj.sql), "CREATE VIRTUAL") {
+			continue
+		}
+		if _, err := dst.ExecContext(ctx, obj.sql); err != nil {
+			slog.Error("Failed to create table", "table", obj.name, "error", err)
+			continue
+		}
+
+		recovered, lost, err := recoverTable(ctx, src, dst, obj.name)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Recovered table", "table", obj.name, "rows", recovered, "lost", lost)
+		result.TablesRecovered++
+		result.RowsRecovered += recovered
+		result.RowsLost += lost
+	}
+
+	// Indexes and triggers go last so they do not slow down the copy
+	for _, kind := range []string{"index", "table", "view", "trigger"} {
+		for _, obj := range objects {
+			if obj.kind != kind || (kind == "table" && !strings.HasPrefix(strings.ToUpper(obj.sql), "CREATE VIRTUAL")) {
+				continue
+			}
+			if _, err := dst.ExecContext(ctx, obj.sql); err != nil {
+				slog.Error("Failed to recreate schema object", "kind", obj.kind, "name", obj.name, "error", err)
+			}
+		}
+	}
+
+	for _, name := range virtual {
+		if _, err := dst.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s(%s) VALUES('rebuild')", quoteIdent(name), quoteIdent(name))); err != nil {
+			slog.Error("Failed to rebuild full-text table", "table", name, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// recoverTable copies the readable rows of a table in rowid order. After a
+// read error it skips ahead, doubling the gap until rows can be read again.
+func recoverTable(ctx context.Context, src, dst *sql.DB, table string) (recovered, lost int, err error) {
+	var maxID sql.NullInt64
+	if err := src.QueryRowContext(ctx, "SELECT MAX(rowid) FROM "+quoteIdent(table)).Scan(&maxID); err != nil {
+		// Without an upper bound, stop at the first damaged range
+		maxID = sql.NullInt64{}
+	}
+
+	lastID := int64(-1 << 63)
+	skip := int64(1)
+	for {
+		if err := ctx.Err(); err != nil {
+			return recovered, lost, err
+		}
+
+		read, copied, next, readErr := copyRows(ctx, src, dst, table, lastID)
+		recovered += copied
+		lost += read - copied
+		lastID = next
+		if readErr == nil {
+			if read < recoverBatchSize {
+				return recovered, lost, nil
+			}
+			skip = 1
+			continue
+		}
+
+		lost++
+		if !maxID.Valid || lastID >= maxID.Int64 {
+			return recovered, lost, nil
+		}
+		if read > 0 {
+			skip = 1
+		}
+		lastID += skip
+		skip *= 2
+	}
+}
+
+// copyRows copies up to recoverBatchSize rows with rowid > after and returns
+// the number of rows read and inserted, the last rowid read and any read error
+func copyRows(ctx context.Context, src, dst *sql.DB, table string, after int64) (int, int, int64, error) {
+	rows, err := src.QueryContext(ctx, fmt.Sprintf("SELECT rowid, * FROM %s WHERE rowid > ? ORDER BY rowid LIMIT %d", quoteIdent(table), recoverBatchSize), after)
+	if err != nil {
+		return 0, 0, after, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, 0, after, err
+	}
+	quoted := make([]string, len(columns))
+	quoted[0] = "rowid"
+	for i, column := range columns[1:] {
+		quoted[i+1] = quoteIdent(column)
+	}
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (?%s)",
+		quoteIdent(table), strings.Join(quoted, ", "), strings.Repeat(", ?", len(columns)-1))
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, after, err
+	}
+	defer tx.Rollback()
+
+	read, copied := 0, 0
+	var readErr error
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			readErr = err
+			break
+		}
+		read++
+		after = values[0].(int64)
+		if _, err := tx.ExecContext(ctx, insert, values...); err != nil {
+			continue
+		}
+		copied++
+	}
+
+	if readErr == nil {
+		readErr = rows.Err()
+	}
+	if err := tx.Commit(); err != nil {
+		return read, 0, after, err
+	}
+	return read, copied, after, readErr
+}
@@ -0,0 +1,114 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// relatedTerms is the number of top terms of an article matched against the
+// full-text index by GetRelatedArticles
+const relatedTerms = 10
+
+// GetRelatedArticles returns up to limit non-redirect articles sharing the
+// most frequent terms of the article with the given ID, best BM25 score first
+// with FTS5 (FTS4 has no ranking). Without its terms, see
+// BuildWordFrequencyIndex, or without a full-text index, the articles sharing
+// the most categories with it are returned instead.
+func (w *Wiki) GetRelatedArticles(id int64, limit int) ([]*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var exists int
+	err := w.db.QueryRow("SELECT 1 FROM articles WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up article: %w", err)
+	}
+
+	terms, err := w.queryTerms(articleTermsSQL, id, relatedTerms)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 0 || w.ftsVersion == "none" {
+		return w.relatedByCategories(id, limit)
+	}
+
+	phrases := make([]string, len(terms))
+	for i, term := range terms {
+		phrases[i] = ftsPhrase(term.Term)
+	}
+	match := strings.Join(phrases, " OR ")
+
+	var rows *sql.Rows
+	if w.ftsVersion == "fts5" {
+		rows, err = w.db.Query(`
+			SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0)
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.rowid
+			WHERE articles_fts MATCH ? AND a.id != ? AND (a.redirect IS NULL OR a.redirect = '')
+			ORDER BY bm25(articles_fts), a.title
+			LIMIT ?
+		`, match, id, limit)
+	} else {
+		rows, err = w.db.Query(`
+			SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0)
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.docid
+			WHERE articles_fts MATCH ? AND a.id != ? AND (a.redirect IS NULL OR a.redirect = '')
+			LIMIT ?
+		`, match, id, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("related articles search failed: %w", err)
+	}
+	return scanRelatedArticles(rows)
+}
+
+// relatedByCategories returns the non-redirect articles sharing the most
+// categories with the article with the given ID
+func (w *Wiki) relatedByCategories(id int64, limit int) ([]*Article, error) {
+	rows, err := w.db.Query(`
+		SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0)
+		FROM categories c
+		JOIN categories s ON s.category_name = c.category_name AND s.article_id != c.article_id
+		JOIN articles a ON a.id = s.article_id
+		WHERE c.article_id = ? AND (a.redirect IS NULL OR a.redirect = '')
+		GROUP BY a.id
+		ORDER BY COUNT(*) DESC, a.title
+		LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query related articles: %w", err)
+	}
+	return scanRelatedArticles(rows)
+}
+
+// scanRelatedArticles reads the articles selected by GetRelatedArticles
+func scanRelatedArticles(rows *sql.Rows) ([]*Article, error) {
+	defer rows.Close()
+
+	articles := []*Article{}
+	for rows.Next() {
+		var article Article
+		var redirect sql.NullString
+		if err := rows.Scan(&article.ID, &article.Title, &article.Namespace, &redirect, &article.WordCount); err != nil {
+			continue
+		}
+		article.Redirect = redirect.String
+		articles = append(articles, &article)
+	}
+
+	return articles, rows.Err()
+}
@@ -0,0 +1,44 @@
+package wikipedia
+
+import "testing"
+
+func TestGetRelatedArticles(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Banana", Text: "The banana is a yellow tropical fruit. [[Category:Fruits]] [[Category:Tropical plants]]"},
+		testPage{ID: 2, Title: "Mango", Text: "The mango is a sweet tropical fruit. [[Category:Fruits]] [[Category:Tropical plants]]"},
+		testPage{ID: 3, Title: "Apple", Text: "The apple is a round fruit. [[Category:Fruits]]"},
+		testPage{ID: 4, Title: "Granite", Text: "Granite is a rock. [[Category:Rocks]]"},
+	)
+	if err := w.BuildWordFrequencyIndex(); err != nil {
+		t.Fatalf("BuildWordFrequencyIndex: %v", err)
+	}
+
+	titles := func(articles []*Article) []string {
+		var titles []string
+		for _, article := range articles {
+			titles = append(titles, article.Title)
+		}
+		return titles
+	}
+
+	related, err := w.GetRelatedArticles(1, 10)
+	if err != nil {
+		t.Fatalf("GetRelatedArticles: %v", err)
+	}
+	if len(related) == 0 || related[0].Title == "Banana" {
+		t.Errorf("GetRelatedArticles(Banana) = %q, want other fruits", titles(related))
+	}
+
+	// Without a full-text index, the articles sharing the most categories are
+	// returned
+	prev := w.ftsVersion
+	w.ftsVersion = "none"
+	defer func() { w.ftsVersion = prev }()
+	related, err = w.GetRelatedArticles(1, 10)
+	if err != nil {
+		t.Fatalf("GetRelatedArticles without a full-text index: %v", err)
+	}
+	if got := titles(related); len(got) != 2 || got[0] != "Mango" || got[1] != "Apple" {
+		t.Errorf("GetRelatedArticles(Banana) without a full-text index = %q, want Mango and Apple", got)
+	}
+}
@@ -0,0 +1,53 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// createProgressTable creates the single-row progress table holding the ID of
+// the last article committed by ProcessArticles
+func (w *Wiki) createProgressTable() error {
+	_, err := w.db.Exec(`
+	CREATE TABLE IF NOT EXISTS progress (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		last_processed_article_id INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create progress table: %w", err)
+	}
+	return nil
+}
+
+// WithResume makes ProcessArticles skip the articles up to the last one
+// committed by a previous run, so an interrupted import can continue where it
+// stopped. Dumps list pages in ID order, so everything up to that article was
+// already stored; articles before it that changed in the dump since are not
+// processed again.
+func (w *Wiki) WithResume(enabled bool) *Wiki {
+	w.resume = enabled
+	return w
+}
+
+// lastProcessedArticleID returns the progress watermark, or 0 if there is none
+func (w *Wiki) lastProcessedArticleID() (int64, error) {
+	var id int64
+	err := w.db.QueryRow("SELECT last_processed_article_id FROM progress WHERE id = 1").Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read progress: %w", err)
+	}
+	return id, nil
+}
+
+// saveProgress records the last processed article in the same transaction as
+// the articles themselves
+func saveProgress(tx *sql.Tx, articleID int64) error {
+	_, err := tx.Exec("INSERT OR REPLACE INTO progress (id, last_processed_article_id) VALUES (1, ?)", articleID)
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
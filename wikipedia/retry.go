@@ -0,0 +1,106 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRetries is the number of times a statement failing because the
+// database is locked is retried, unless set with WithMaxRetries
+const DefaultMaxRetries = 5
+
+// retryBackoff is the wait before the first retry, doubled for each next one
+const retryBackoff = 50 * time.Millisecond
+
+// WithMaxRetries sets the number of times Exec, Query and QueryRow calls
+// failing with SQLITE_BUSY are retried; 0 disables retries. It must be called
+// before Open.
+func (w *Wiki) WithMaxRetries(n int) *Wiki {
+	if n < 0 {
+		n = 0
+	}
+	w.maxRetries = n
+	return w
+}
+
+// isBusy reports whether err is a transient locking error worth retrying
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// retryDB is a *sql.DB whose Exec, Query and QueryRow calls outside of
+// transactions are retried with exponential backoff while the database is
+// locked, which can happen under heavy load even in WAL mode. Statements run
+// within a transaction are not retried, since the transaction has to be
+// started over.
+type retryDB struct {
+	*sql.DB
+	maxRetries int
+}
+
+// withRetry calls fn until it returns an error other than isBusy, up to
+// maxRetries more times, waiting retryBackoff and then twice as long before
+// each new attempt. Waiting stops early when ctx is done.
+func (db *retryDB) withRetry(ctx context.Context, fn func() error) error {
+	backoff := retryBackoff
+	err := fn()
+	for attempt := 0; attempt < db.maxRetries && isBusy(err); attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
+
+func (db *retryDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *retryDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.withRetry(ctx, func() error {
+		var err error
+		result, err = db.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (db *retryDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *retryDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.withRetry(ctx, func() error {
+		var err error
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (db *retryDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext retries on the error of the query itself, which Row.Err
+// reports before Scan
+func (db *retryDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	db.withRetry(ctx, func() error {
+		row = db.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
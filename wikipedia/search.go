@@ -0,0 +1,301 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SearchResult is a single article hit returned by content searches
+// Score is higher for more relevant hits; it is zero when FTS ranking is unavailable.
+type SearchResult struct {
+	ID    int64   `json:"id"`
+	Title string  `json:"title"`
+	Score float64 `json:"score,omitempty"`
+}
+
+// RankedArticle is a hit of SearchArticlesRanked
+type RankedArticle struct {
+	Article
+	// Score is the negated BM25 relevance of the hit, higher for more
+	// relevant ones; it is zero when FTS5 is unavailable
+	Score float64 `json:"score"`
+}
+
+// SearchArticlesRanked runs the title search ordered by the FTS5 BM25
+// relevance of the hits, returning them without content. With FTS4 or the LIKE
+// fallback of SearchTitles, the hits are ordered alphabetically.
+func (w *Wiki) SearchArticlesRanked(ctx context.Context, query string, limit, offset int) ([]*RankedArticle, error) {
+	defer observeSince(searchDuration, time.Now())
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var ftsSQL string
+	switch w.ftsVersion {
+	case "fts5":
+		ftsSQL = `
+			SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0), -bm25(articles_fts) AS score
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.rowid
+			WHERE articles_fts MATCH ?
+			ORDER BY score DESC, a.title, a.id
+			LIMIT ? OFFSET ?`
+	case "fts4":
+		ftsSQL = `
+			SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0), 0
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.docid
+			WHERE articles_fts MATCH ?
+			ORDER BY a.title, a.id
+			LIMIT ? OFFSET ?`
+	}
+	rows, err := w.searchRows(ctx, query, ftsSQL, `
+		SELECT id, title, namespace, redirect, COALESCE(word_count, 0), 0
+		FROM articles
+		WHERE title LIKE ?
+		ORDER BY title, id
+		LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ranked search failed: %w", err)
+	}
+	defer rows.Close()
+
+	articles := []*RankedArticle{}
+	for rows.Next() {
+		var article RankedArticle
+		if err := rows.Scan(&article.ID, &article.Title, &article.Namespace, &article.Redirect, &article.WordCount, &article.Score); err != nil {
+			return nil, fmt.Errorf("ranked search failed: %w", err)
+		}
+		articles = append(articles, &article)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ranked search failed: %w", err)
+	}
+
+	return articles, nil
+}
+
+// ftsPhrase quotes s as an FTS phrase so that it is matched as a whole
+func ftsPhrase(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// SearchMentions finds articles whose content contains the exact phrase title.
+// Unlike link-based lookups, this also returns plain-text mentions that are not
+// wrapped in [[brackets]]. Titles are not searched, and the article named
+// title itself is excluded.
+func (w *Wiki) SearchMentions(ctx context.Context, title string, limit int) ([]SearchResult, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var rows *sql.Rows
+	var err error
+
+	switch w.ftsVersion {
+	case "fts5":
+		rows, err = w.db.QueryContext(ctx, `
+			SELECT a.id, a.title, -articles_fts.rank
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.rowid
+			WHERE articles_fts.content MATCH ? AND a.title != ?
+			ORDER BY articles_fts.rank, a.id
+			LIMIT ?
+		`, ftsPhrase(title), title, limit)
+	case "fts4":
+		rows, err = w.db.QueryContext(ctx, `
+			SELECT a.id, a.title, 0
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.docid
+			WHERE articles_fts.content MATCH ? AND a.title != ?
+			LIMIT ?
+		`, ftsPhrase(title), title, limit)
+	default:
+		rows, err = w.db.QueryContext(ctx, `
+			SELECT id, title, 0
+			FROM articles
+			WHERE `+contentSQL("articles")+` LIKE ? AND title != ?
+			ORDER BY title
+			LIMIT ?
+		`, "%"+title+"%", title, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mention search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.ID, &r.Title, &r.Score); err != nil {
+			continue
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// snippetLength is the maximum length in characters of content search snippets
+const snippetLength = 200
+
+// ftsWordsQuery quotes each word of query as an FTS phrase so that operators
+// typed by users are matched literally; all words must match
+func ftsWordsQuery(query string) string {
+	words := strings.Fields(query)
+	for i, word := range words {
+		words[i] = ftsPhrase(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// truncateSnippet shortens s to snippetLength characters at a word boundary
+func truncateSnippet(s string) string {
+	s = multiSpaceRegex.ReplaceAllString(strings.TrimSpace(s), " ")
+	runes := []rune(s)
+	if len(runes) <= snippetLength {
+		return s
+	}
+
+	cut := string(runes[:snippetLength])
+	if i := strings.LastIndex(cut, " "); i > snippetLength/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// excerptAround returns the text around the first case-insensitive occurrence
+// of query in content, for searches that cannot use FTS snippets
+func excerptAround(content, query string) string {
+	i := strings.Index(strings.ToLower(content), strings.ToLower(query))
+	if i < 0 {
+		return truncateSnippet(content)
+	}
+
+	start := i - snippetLength/4
+	prefix := "…"
+	if start <= 0 {
+		start = 0
+		prefix = ""
+	}
+	// Do not start in the middle of a multi-byte character
+	for start > 0 && start < len(content) && content[start]&0xC0 == 0x80 {
+		start--
+	}
+	return prefix + truncateSnippet(content[start:])
+}
+
+// highlightStart and highlightEnd mark the matching terms of highlighted
+// search results
+const (
+	highlightStart = "<b>"
+	highlightEnd   = "</b>"
+)
+
+// highlightTerms wraps the case-insensitive occurrences of terms in text, up to
+// the end of the word since search words are prefixes, with highlightStart and
+// highlightEnd. It stands in for the FTS5 highlight() function.
+func highlightTerms(text string, terms []string) string {
+	if len(terms) == 0 {
+		return text
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)[\pL\pN]*`)
+	return re.ReplaceAllString(text, highlightStart+"${0}"+highlightEnd)
+}
+
+// SearchContent finds articles whose content matches all the words of query
+// and returns them without content but with a snippet of the matching text
+func (w *Wiki) SearchContent(query string, limit, offset int) ([]*Article, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var rows *sql.Rows
+	var err error
+
+	switch w.ftsVersion {
+	case "fts5":
+		rows, err = w.db.Query(`
+			SELECT a.id, a.title, a.namespace, a.redirect, snippet(articles_fts, 1, '', '', '…', 40)
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.rowid
+			WHERE articles_fts.content MATCH ?
+			ORDER BY articles_fts.rank, a.id
+			LIMIT ? OFFSET ?
+		`, ftsWordsQuery(query), limit, offset)
+	case "fts4":
+		rows, err = w.db.Query(`
+			SELECT a.id, a.title, a.namespace, a.redirect, snippet(articles_fts, '', '', '…', 1, 40)
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.docid
+			WHERE articles_fts.content MATCH ?
+			ORDER BY articles_fts.docid
+			LIMIT ? OFFSET ?
+		`, ftsWordsQuery(query), limit, offset)
+	default:
+		rows, err = w.db.Query(`
+			SELECT id, title, namespace, redirect, `+contentSQL("articles")+`
+			FROM articles
+			WHERE `+contentSQL("articles")+` LIKE ?
+			ORDER BY id
+			LIMIT ? OFFSET ?
+		`, "%"+query+"%", limit, offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("content search failed: %w", err)
+	}
+	defer rows.Close()
+
+	articles := []*Article{}
+	for rows.Next() {
+		var article Article
+		var text string
+		if err := rows.Scan(&article.ID, &article.Title, &article.Namespace, &article.Redirect, &text); err != nil {
+			continue
+		}
+		if w.ftsVersion == "fts5" || w.ftsVersion == "fts4" {
+			article.Snippet = truncateSnippet(text)
+		} else {
+			article.Snippet = excerptAround(text, query)
+		}
+		articles = append(articles, &article)
+	}
+
+	return articles, rows.Err()
+}
@@ -0,0 +1,134 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSearchArticlesRanked(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Atom", Text: "An atom has a nucleus."},
+		testPage{ID: 2, Title: "Nucleus", Text: "The nucleus of the atom holds protons. Nucleus nucleus nucleus."},
+		testPage{ID: 3, Title: "Cell nucleus", Text: "The nucleus of a cell."},
+		testPage{ID: 4, Title: "Jean-Paul Sartre", Text: "French philosopher"},
+	)
+
+	articles, err := w.SearchArticlesRanked(context.Background(), "nucleus", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchArticlesRanked: %v", err)
+	}
+	want := 3
+	if w.ftsVersion == "none" {
+		// The LIKE fallback only matches titles
+		want = 2
+	}
+	if len(articles) != want {
+		t.Fatalf("got %d articles, want %d", len(articles), want)
+	}
+	if w.ftsVersion == "fts5" {
+		if articles[0].Title != "Nucleus" {
+			t.Errorf("got %q first, want the most relevant Nucleus", articles[0].Title)
+		}
+		for i := 1; i < len(articles); i++ {
+			if articles[i].Score > articles[i-1].Score {
+				t.Errorf("hit %d scores %v above hit %d at %v", i, articles[i].Score, i-1, articles[i-1].Score)
+			}
+		}
+	}
+
+	articles, err = w.SearchArticlesRanked(context.Background(), "Jean-Paul", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchArticlesRanked(Jean-Paul): %v", err)
+	}
+	if len(articles) != 1 || articles[0].Title != "Jean-Paul Sartre" {
+		t.Errorf("got %v for Jean-Paul, want Jean-Paul Sartre", articles)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.SearchArticlesRanked(ctx, "nucleus", 10, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v with a canceled context, want context.Canceled", err)
+	}
+}
+
+func TestSearchMentions(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Marie Curie", Text: "A physicist and chemist."},
+		testPage{ID: 2, Title: "Radium", Text: "Discovered by Marie Curie in 1898."},
+		testPage{ID: 3, Title: "Polonium", Text: "Named by Marie Curie after Poland."},
+		testPage{ID: 4, Title: "Nobel Prize", Text: "Won by [[Marie Curie]] twice."},
+		// Only the title contains the phrase, and the words apart do not match
+		testPage{ID: 5, Title: "Marie Curie Avenue", Text: "A street in Paris."},
+		testPage{ID: 6, Title: "Pierre", Text: "Marie met Pierre Curie."},
+	)
+
+	mentions, err := w.SearchMentions(context.Background(), "Marie Curie", 10)
+	if err != nil {
+		t.Fatalf("SearchMentions: %v", err)
+	}
+	got := make(map[int64]bool)
+	for _, mention := range mentions {
+		got[mention.ID] = true
+	}
+	// 2 plain-text mentions and 1 link
+	if len(mentions) != 3 || !got[2] || !got[3] || !got[4] {
+		t.Errorf("SearchMentions(Marie Curie) = %v, want Radium, Polonium and Nobel Prize", mentions)
+	}
+}
+
+func TestSearchPagesWithTies(t *testing.T) {
+	// The articles share their title and content, so that every hit ties
+	var pages []testPage
+	for id := 1; id <= 30; id++ {
+		pages = append(pages, testPage{ID: id, Title: "Topic", Text: "Notes on the topic."})
+	}
+	w := newTestWiki(t, pages...)
+	ctx := context.Background()
+
+	searches := map[string]func(limit, offset int) ([]int64, error){
+		"SearchArticlesRanked": func(limit, offset int) ([]int64, error) {
+			articles, err := w.SearchArticlesRanked(ctx, "topic", limit, offset)
+			var ids []int64
+			for _, article := range articles {
+				ids = append(ids, article.ID)
+			}
+			return ids, err
+		},
+		"SearchArticlesWithOptions": func(limit, offset int) ([]int64, error) {
+			articles, err := w.SearchArticlesWithOptions(ctx, "topic", limit, offset, SearchOptions{Highlight: true})
+			return articleIDs(articles), err
+		},
+		"SearchContent": func(limit, offset int) ([]int64, error) {
+			articles, err := w.SearchContent("notes", limit, offset)
+			return articleIDs(articles), err
+		},
+	}
+	for name, search := range searches {
+		seen := make(map[int64]bool)
+		for offset := 0; offset < len(pages); offset += 7 {
+			ids, err := search(7, offset)
+			if err != nil {
+				t.Fatalf("%s at offset %d: %v", name, offset, err)
+			}
+			for _, id := range ids {
+				if seen[id] {
+					t.Errorf("%s: article %d is on more than one page", name, id)
+				}
+				seen[id] = true
+			}
+		}
+		if len(seen) != len(pages) {
+			t.Errorf("%s: the pages hold %d articles, want %d", name, len(seen), len(pages))
+		}
+	}
+}
+
+// articleIDs returns the IDs of articles
+func articleIDs(articles []*Article) []int64 {
+	var ids []int64
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	return ids
+}
@@ -0,0 +1,124 @@
+package wikipedia
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Section is a heading of an article; ByteOffset is where its heading line
+// starts in the wikitext
+type Section struct {
+	Level      int    `json:"level"`
+	Title      string `json:"title"`
+	ByteOffset int    `json:"byte_offset"`
+}
+
+// ErrSectionNotFound is returned by SectionWikitext for a section name that is
+// not a heading of the article
+var ErrSectionNotFound = errors.New("section not found")
+
+var nowikiRegex = regexp.MustCompile(`(?is)<nowiki\s*>.*?</nowiki\s*>`)
+
+// maskNowiki blanks out <nowiki> blocks so their markup is not parsed, keeping
+// byte offsets unchanged
+func maskNowiki(content string) string {
+	return nowikiRegex.ReplaceAllStringFunc(content, func(block string) string {
+		return strings.Repeat(" ", len(block))
+	})
+}
+
+// ParseSections returns the section headings of wikitext (== Heading ==,
+// === Subheading ===, ...) in document order. Headings inside <nowiki> blocks
+// are ignored.
+func ParseSections(content string) []Section {
+	sections := []Section{}
+	offset := 0
+	for _, line := range strings.SplitAfter(maskNowiki(content), "\n") {
+		start := offset
+		offset += len(line)
+
+		line = strings.TrimRight(line, " \t\r\n")
+		leading := len(line) - len(strings.TrimLeft(line, "="))
+		trailing := len(line) - len(strings.TrimRight(line, "="))
+		if leading == 0 || trailing == 0 || leading == len(line) {
+			continue
+		}
+
+		// Unbalanced markers belong to the title: "=== Title ==" is a level 2
+		// heading titled "= Title"
+		level := leading
+		if trailing < level {
+			level = trailing
+		}
+		if level > 6 {
+			level = 6
+		}
+
+		title := strings.TrimSpace(line[level : len(line)-level])
+		if title == "" {
+			continue
+		}
+		sections = append(sections, Section{Level: level, Title: title, ByteOffset: start})
+	}
+	return sections
+}
+
+// SectionWikitext returns the wikitext of the named sections joined by blank
+// lines, in the order given. "lead" is the text before the first heading;
+// other names are matched case-insensitively against the heading titles, and
+// a section spans its subsections up to the next heading of the same or a
+// higher level.
+func SectionWikitext(content string, names []string) (string, error) {
+	sections := ParseSections(content)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.EqualFold(name, "lead") {
+			end := len(content)
+			if len(sections) > 0 {
+				end = sections[0].ByteOffset
+			}
+			parts = append(parts, strings.TrimSpace(content[:end]))
+			continue
+		}
+
+		found := false
+		for i, section := range sections {
+			if !strings.EqualFold(section.Title, name) {
+				continue
+			}
+			end := len(content)
+			for _, next := range sections[i+1:] {
+				if next.Level <= section.Level {
+					end = next.ByteOffset
+					break
+				}
+			}
+			parts = append(parts, strings.TrimSpace(content[section.ByteOffset:end]))
+			found = true
+			break
+		}
+		if !found {
+			return "", fmt.Errorf("%w: %s", ErrSectionNotFound, name)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// GetArticleSections returns the section headings of an article
+func (w *Wiki) GetArticleSections(id int64) ([]Section, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var content contentText
+	if err := w.db.QueryRow("SELECT COALESCE(content, content_compressed) FROM articles WHERE id = ?", id).Scan(&content); err != nil {
+		return nil, fmt.Errorf("article not found: %d", id)
+	}
+
+	return ParseSections(string(content)), nil
+}
@@ -0,0 +1,309 @@
+package wikipedia
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrSignificanceNotComputed is returned by GetSignificance for an article
+// that has no score, because ComputeSignificanceScores was not run since it
+// was imported
+var ErrSignificanceNotComputed = errors.New("significance score not computed")
+
+// Weights of the components of the significance score
+const (
+	pagerankWeight  = 0.4
+	qualityWeight   = 0.4
+	wordCountWeight = 0.2
+)
+
+const (
+	// pagerankDamping is the probability of following a link rather than
+	// jumping to a random article
+	pagerankDamping = 0.85
+
+	// pagerankIterations bounds the power iterations of pageRank, which stops
+	// earlier once the ranks change by less than pagerankTolerance in total
+	pagerankIterations = 100
+	pagerankTolerance  = 1e-9
+)
+
+// Significance is the significance score of an article with its components,
+// each normalized to [0,1] across the main namespace articles
+type Significance struct {
+	ArticleID           int64   `json:"article_id"`
+	Score               float64 `json:"significance_score"`
+	NormalizedPageRank  float64 `json:"normalized_pagerank"`
+	QualityScore        float64 `json:"quality_score"`
+	NormalizedWordCount float64 `json:"normalized_word_count"`
+}
+
+// createSignificanceTables creates the article_significance table filled by
+// ComputeSignificanceScores. The scores are kept out of the articles table,
+// whose update trigger would reindex the full-text entry of every article.
+func (w *Wiki) createSignificanceTables() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS article_significance (
+			article_id INTEGER PRIMARY KEY,
+			normalized_pagerank REAL NOT NULL,
+			quality_score REAL NOT NULL,
+			normalized_word_count REAL NOT NULL,
+			significance_score REAL NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_article_significance_score ON article_significance(significance_score)",
+		`CREATE TRIGGER IF NOT EXISTS articles_significance_ad AFTER DELETE ON articles BEGIN
+			DELETE FROM article_significance WHERE article_id = old.id;
+		END`,
+	}
+
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create article_significance table: %w", err)
+		}
+	}
+	return nil
+}
+
+// pageRank computes the PageRank of nodes over the links of adjList, leaving
+// out links to other nodes. The ranks of the nodes without links are spread
+// over all nodes, so that the ranks sum to 1.
+func pageRank(nodes []int64, adjList map[int64][]int64) map[int64]float64 {
+	ranks := make(map[int64]float64, len(nodes))
+	if len(nodes) == 0 {
+		return ranks
+	}
+
+	index := make(map[int64]int, len(nodes))
+	for i, id := range nodes {
+		index[id] = i
+	}
+	outLinks := make([][]int, len(nodes))
+	for i, id := range nodes {
+		for _, to := range adjList[id] {
+			if j, ok := index[to]; ok {
+				outLinks[i] = append(outLinks[i], j)
+			}
+		}
+	}
+
+	n := float64(len(nodes))
+	rank := make([]float64, len(nodes))
+	for i := range rank {
+		rank[i] = 1 / n
+	}
+	next := make([]float64, len(nodes))
+	for iteration := 0; iteration < pagerankIterations; iteration++ {
+		dangling := 0.0
+		for i, links := range outLinks {
+			if len(links) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pagerankDamping)/n + pagerankDamping*dangling/n
+		for i := range next {
+			next[i] = base
+		}
+		for i, links := range outLinks {
+			share := pagerankDamping * rank[i] / float64(len(links))
+			for _, j := range links {
+				next[j] += share
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pagerankTolerance {
+			break
+		}
+	}
+
+	for i, id := range nodes {
+		ranks[id] = rank[i]
+	}
+	return ranks
+}
+
+// normalize maps values to [0,1] with (v - min) / (max - min); all values are
+// 0 when they are equal
+func normalize(values []float64) []float64 {
+	if len(values) == 0 {
+		return values
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	normalized := make([]float64, len(values))
+	if hi == lo {
+		return normalized
+	}
+	for i, v := range values {
+		normalized[i] = (v - lo) / (hi - lo)
+	}
+	return normalized
+}
+
+// ComputeSignificanceScores scores the main namespace articles that are not
+// redirects by combining their PageRank over the link graph of
+// BuildAdjacencyList, a quality score and their word count:
+//
+//	0.4 * normalized_pagerank + 0.4 * quality_score + 0.2 * normalized_word_count
+//
+// The quality score is the number of categories of the article. Each
+// component is normalized to [0,1] across the articles, and the scores of a
+// previous run are replaced.
+func (w *Wiki) ComputeSignificanceScores(ctx context.Context) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	adjList, err := w.BuildAdjacencyList(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT a.id, COALESCE(a.word_count, 0),
+			(SELECT COUNT(*) FROM categories c WHERE c.article_id = a.id)
+		FROM articles a
+		WHERE a.namespace = 0 AND (a.redirect IS NULL OR a.redirect = '')
+		ORDER BY a.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to read articles: %w", err)
+	}
+	var ids []int64
+	var wordCounts, categoryCounts []float64
+	for rows.Next() {
+		var id int64
+		var wordCount, categoryCount int
+		if err := rows.Scan(&id, &wordCount, &categoryCount); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read articles: %w", err)
+		}
+		ids = append(ids, id)
+		wordCounts = append(wordCounts, float64(wordCount))
+		categoryCounts = append(categoryCounts, float64(categoryCount))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read articles: %w", err)
+	}
+
+	ranks := pageRank(ids, adjList)
+	pageRanks := make([]float64, len(ids))
+	for i, id := range ids {
+		pageRanks[i] = ranks[id]
+	}
+	pageRanks = normalize(pageRanks)
+	quality := normalize(categoryCounts)
+	wordCounts = normalize(wordCounts)
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_significance"); err != nil {
+		return fmt.Errorf("failed to clear article_significance: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO article_significance (article_id, normalized_pagerank, quality_score, normalized_word_count, significance_score)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, id := range ids {
+		score := pagerankWeight*pageRanks[i] + qualityWeight*quality[i] + wordCountWeight*wordCounts[i]
+		if _, err := stmt.ExecContext(ctx, id, pageRanks[i], quality[i], wordCounts[i], score); err != nil {
+			return fmt.Errorf("failed to store significance score: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit significance scores: %w", err)
+	}
+	w.logger.Info("Computed significance scores", "articles", len(ids))
+	return nil
+}
+
+// GetSignificance returns the significance score of an article and its
+// components
+func (w *Wiki) GetSignificance(ctx context.Context, id int64) (*Significance, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	significance := Significance{ArticleID: id}
+	err := w.db.QueryRowContext(ctx, `
+		SELECT significance_score, normalized_pagerank, quality_score, normalized_word_count
+		FROM article_significance
+		WHERE article_id = ?
+	`, id).Scan(&significance.Score, &significance.NormalizedPageRank, &significance.QualityScore, &significance.NormalizedWordCount)
+	if err == nil {
+		return &significance, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to query significance score: %w", err)
+	}
+
+	var count int
+	if err := w.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM articles WHERE id = ?", id).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to look up article: %w", err)
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
+	return nil, fmt.Errorf("%w: %d", ErrSignificanceNotComputed, id)
+}
+
+// GetArticlesBySignificance is GetArticlesByNamespace ordered by decreasing
+// significance score; articles without a score come last, in ID order
+func (w *Wiki) GetArticlesBySignificance(ctx context.Context, ns int, limit, offset int) ([]*ArticleMeta, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := w.db.QueryContext(ctx, `
+		SELECT a.id, a.title, a.namespace, a.redirect
+		FROM articles a
+		LEFT JOIN article_significance s ON s.article_id = a.id
+		WHERE a.namespace = ?
+		ORDER BY s.significance_score IS NULL, s.significance_score DESC, a.id
+		LIMIT ? OFFSET ?
+	`, ns, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list articles by significance: %w", err)
+	}
+	defer rows.Close()
+
+	return scanArticleMetas(rows)
+}
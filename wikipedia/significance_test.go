@@ -0,0 +1,102 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestComputeSignificanceScores(t *testing.T) {
+	// Every article links to Physics, which has the highest PageRank; Cell has
+	// the most categories and Relativity the most words
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "The science of [[Matter]].\n[[Category:Science]]"},
+		testPage{ID: 2, Title: "Matter", Text: "Studied by [[Physics]].\n[[Category:Science]]"},
+		testPage{ID: 3, Title: "Relativity", Text: "A theory of [[Physics]] about space, time, gravity, light and the motion of bodies at high speeds."},
+		testPage{ID: 4, Title: "Cell", Text: "See [[Physics]].\n[[Category:Biology]]\n[[Category:Science]]\n[[Category:Life]]"},
+		testPage{ID: 5, Title: "Matters", Redirect: "Matter", Text: "#REDIRECT [[Matter]]"},
+	)
+	ctx := context.Background()
+
+	if _, err := w.GetSignificance(ctx, 1); !errors.Is(err, ErrSignificanceNotComputed) {
+		t.Fatalf("GetSignificance before ComputeSignificanceScores: got %v, want ErrSignificanceNotComputed", err)
+	}
+
+	if err := w.ComputeSignificanceScores(ctx); err != nil {
+		t.Fatalf("ComputeSignificanceScores: %v", err)
+	}
+
+	scores := make(map[int64]*Significance)
+	for id := int64(1); id <= 4; id++ {
+		significance, err := w.GetSignificance(ctx, id)
+		if err != nil {
+			t.Fatalf("GetSignificance(%d): %v", id, err)
+		}
+		scores[id] = significance
+
+		want := 0.4*significance.NormalizedPageRank + 0.4*significance.QualityScore + 0.2*significance.NormalizedWordCount
+		if math.Abs(significance.Score-want) > 1e-9 {
+			t.Errorf("article %d: score %v, want %v", id, significance.Score, want)
+		}
+		for name, v := range map[string]float64{"pagerank": significance.NormalizedPageRank, "quality": significance.QualityScore, "word count": significance.NormalizedWordCount} {
+			if v < 0 || v > 1 {
+				t.Errorf("article %d: normalized %s %v out of [0,1]", id, name, v)
+			}
+		}
+	}
+
+	if got := scores[1].NormalizedPageRank; got != 1 {
+		t.Errorf("Physics normalized PageRank = %v, want 1", got)
+	}
+	// Relativity and Cell have the lowest PageRank, as nothing links to them
+	if scores[3].NormalizedPageRank != 0 || scores[4].NormalizedPageRank != 0 {
+		t.Errorf("normalized PageRank of unlinked articles = %v, %v, want 0", scores[3].NormalizedPageRank, scores[4].NormalizedPageRank)
+	}
+	if scores[4].QualityScore != 1 || scores[3].QualityScore != 0 {
+		t.Errorf("quality scores of Cell and Relativity = %v, %v, want 1 and 0", scores[4].QualityScore, scores[3].QualityScore)
+	}
+	if scores[3].NormalizedWordCount != 1 {
+		t.Errorf("Relativity normalized word count = %v, want 1", scores[3].NormalizedWordCount)
+	}
+
+	// Redirects are not scored
+	if _, err := w.GetSignificance(ctx, 5); !errors.Is(err, ErrSignificanceNotComputed) {
+		t.Errorf("GetSignificance of a redirect: got %v, want ErrSignificanceNotComputed", err)
+	}
+	if _, err := w.GetSignificance(ctx, 99); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("GetSignificance of a missing article: got %v, want ErrArticleNotFound", err)
+	}
+
+	articles, err := w.GetArticlesBySignificance(ctx, 0, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArticlesBySignificance: %v", err)
+	}
+	if len(articles) != 5 {
+		t.Fatalf("GetArticlesBySignificance returned %d articles, want 5", len(articles))
+	}
+	for i := 1; i < 4; i++ {
+		if prev, cur := scores[articles[i-1].ID], scores[articles[i].ID]; prev.Score < cur.Score {
+			t.Errorf("article %d (%v) listed before %d (%v)", prev.ArticleID, prev.Score, cur.ArticleID, cur.Score)
+		}
+	}
+	if articles[4].ID != 5 {
+		t.Errorf("last article = %d, want the unscored redirect 5", articles[4].ID)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	// 1 and 2 link to each other, 3 links to 1 and 4 has no links
+	ranks := pageRank([]int64{1, 2, 3, 4}, map[int64][]int64{1: {2}, 2: {1}, 3: {1, 99}})
+
+	sum := 0.0
+	for _, rank := range ranks {
+		sum += rank
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("ranks sum to %v, want 1", sum)
+	}
+	if !(ranks[1] > ranks[2] && ranks[2] > ranks[3] && ranks[3] == ranks[4]) {
+		t.Errorf("ranks = %v, want 1 > 2 > 3 = 4", ranks)
+	}
+}
@@ -0,0 +1,107 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+)
+
+// DBStats summarizes the contents of the database
+type DBStats struct {
+	Articles             int64  `json:"articles"`
+	Redirects            int64  `json:"redirects"`
+	IndexEntries         int64  `json:"index_entries"`
+	OrphanedIndexEntries *int64 `json:"orphaned_index_entries,omitempty"`
+	Namespaces           int64  `json:"namespaces"`
+	DBSizeBytes          int64  `json:"db_size_bytes"`
+	FTSVersion           string `json:"fts_version"`
+
+	// NamespaceCounts gives the name and number of articles of each namespace
+	NamespaceCounts []NamespaceStat `json:"namespace_counts"`
+}
+
+// Stats returns article, redirect, index entry and namespace counts, the
+// number of articles of each namespace along with its name, and the size of
+// the database file. Counting the orphaned index entries scans the whole
+// index, so they are only counted with countOrphans.
+func (w *Wiki) Stats(countOrphans bool) (*DBStats, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	stats := &DBStats{FTSVersion: w.ftsVersion}
+	queries := []struct {
+		dest  *int64
+		query string
+	}{
+		{&stats.Articles, "SELECT COUNT(*) FROM articles"},
+		{&stats.Redirects, "SELECT COUNT(*) FROM articles WHERE redirect != ''"},
+		{&stats.IndexEntries, "SELECT COUNT(*) FROM index_entries"},
+		{&stats.Namespaces, "SELECT COUNT(DISTINCT namespace) FROM articles"},
+		{&stats.DBSizeBytes, "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()"},
+	}
+	for _, q := range queries {
+		if err := w.db.QueryRow(q.query).Scan(q.dest); err != nil {
+			return nil, fmt.Errorf("failed to compute database stats: %w", err)
+		}
+	}
+	if countOrphans {
+		var orphans int64
+		if err := w.db.QueryRow(orphanedIndexEntriesSQL).Scan(&orphans); err != nil {
+			return nil, fmt.Errorf("failed to count orphaned index entries: %w", err)
+		}
+		stats.OrphanedIndexEntries = &orphans
+	}
+
+	var err error
+	if stats.NamespaceCounts, err = w.namespaceStats(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// RedirectStat is a redirect target along with the number of redirects
+// pointing to it
+type RedirectStat struct {
+	TargetTitle string `json:"target_title"`
+	Count       int    `json:"count"`
+}
+
+// TopRedirectTargets returns the limit titles the most redirects point to,
+// most redirected first. Targets with a section ("Title#Section") are counted
+// separately from the title itself.
+func (w *Wiki) TopRedirectTargets(limit int) ([]RedirectStat, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	rows, err := w.db.Query(`
+		SELECT redirect, COUNT(*)
+		FROM articles
+		WHERE redirect != ''
+		GROUP BY redirect
+		ORDER BY COUNT(*) DESC, redirect
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query redirect targets: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []RedirectStat{}
+	for rows.Next() {
+		var stat RedirectStat
+		if err := rows.Scan(&stat.TargetTitle, &stat.Count); err != nil {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
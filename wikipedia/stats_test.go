@@ -0,0 +1,83 @@
+package wikipedia
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	w := newFixtureWiki(t)
+	importFixture(t, w)
+
+	stats, err := w.Stats(true)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.OrphanedIndexEntries == nil {
+		t.Fatal("Stats(true) did not count the orphaned index entries")
+	}
+
+	// Only the main namespace is imported, so the index entries of the 5
+	// templates and 5 categories have no article
+	counts := []struct {
+		name      string
+		got, want int64
+	}{
+		{"articles", stats.Articles, 90},
+		{"redirects", stats.Redirects, 5},
+		{"index entries", stats.IndexEntries, 100},
+		{"orphaned index entries", *stats.OrphanedIndexEntries, 10},
+		{"namespaces", stats.Namespaces, 1},
+	}
+	for _, c := range counts {
+		if c.got != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, c.got, c.want)
+		}
+	}
+	if stats.DBSizeBytes <= 0 {
+		t.Errorf("got a database size of %d bytes", stats.DBSizeBytes)
+	}
+	if stats.FTSVersion != w.ftsVersion || stats.FTSVersion == "" {
+		t.Errorf("got FTS version %q, want %q", stats.FTSVersion, w.ftsVersion)
+	}
+	if len(stats.NamespaceCounts) != 1 || stats.NamespaceCounts[0].ID != 0 || stats.NamespaceCounts[0].Count != 90 {
+		t.Errorf("got namespace counts %+v, want 90 articles in namespace 0", stats.NamespaceCounts)
+	}
+
+	// The orphans are only counted on request
+	if stats, err := w.Stats(false); err != nil || stats.OrphanedIndexEntries != nil {
+		t.Errorf("Stats(false) = %+v, %v, want no orphaned index entry count", stats, err)
+	}
+}
+
+func TestTopRedirectTargets(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Mercury", Text: "Mercury may refer to several things."},
+		testPage{ID: 2, Title: "Hg", Redirect: "Mercury (element)"},
+		testPage{ID: 3, Title: "Quicksilver", Redirect: "Mercury (element)"},
+		testPage{ID: 4, Title: "Element 80", Redirect: "Mercury (element)"},
+		testPage{ID: 5, Title: "Mercury planet", Redirect: "Mercury (planet)"},
+		testPage{ID: 6, Title: "First planet", Redirect: "Mercury (planet)"},
+		testPage{ID: 7, Title: "Freddie", Redirect: "Freddie Mercury"},
+		testPage{ID: 8, Title: "Farrokh Bulsara", Redirect: "Freddie Mercury"},
+		testPage{ID: 9, Title: "Hermes (Roman)", Redirect: "Mercury (mythology)"},
+	)
+
+	// Ties are ordered by title
+	want := []RedirectStat{
+		{"Mercury (element)", 3},
+		{"Freddie Mercury", 2},
+		{"Mercury (planet)", 2},
+		{"Mercury (mythology)", 1},
+	}
+	for _, limit := range []int{10, 2} {
+		got, err := w.TopRedirectTargets(limit)
+		if err != nil {
+			t.Fatalf("TopRedirectTargets(%d): %v", limit, err)
+		}
+		expected := want[:min(limit, len(want))]
+		if !slices.Equal(got, expected) {
+			t.Errorf("TopRedirectTargets(%d) = %v, want %v", limit, got, expected)
+		}
+	}
+}
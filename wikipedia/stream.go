@@ -0,0 +1,44 @@
+package wikipedia
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/d4l3k/go-pbzip2"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	bzip2Magic = []byte{0x42, 0x5A} // "BZ"
+	gzipMagic  = []byte{0x1F, 0x8B}
+	zstdMagic  = []byte{0x28, 0xB5, 0x2F, 0xFD}
+)
+
+// newStreamReader returns a reader of the decompressed contents of a dump
+// file. The format is detected from its first bytes: bzip2, gzip and
+// Zstandard files are decompressed, anything else is read as is.
+func newStreamReader(f io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, bzip2Magic):
+		// Use pbzip2 for parallel decompression
+		return pbzip2.NewReader(br)
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.Equal(magic, zstdMagic):
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
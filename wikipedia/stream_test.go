@@ -0,0 +1,122 @@
+package wikipedia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// readFixtureArticles returns the decompressed XML of the fixture dump
+func readFixtureArticles(tb testing.TB) []byte {
+	tb.Helper()
+
+	f, err := os.Open(filepath.Join("..", "testdata", fixtureArticlesFile))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := newStreamReader(f)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+func gzipCompress(tb testing.TB, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		tb.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdCompress(tb testing.TB, data []byte) []byte {
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll(data, nil)
+}
+
+func TestNewStreamReader(t *testing.T) {
+	xml := readFixtureArticles(t)
+	bz2, err := os.ReadFile(filepath.Join("..", "testdata", fixtureArticlesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bzip2", bz2},
+		{"gzip", gzipCompress(t, xml)},
+		{"zstd", zstdCompress(t, xml)},
+		{"uncompressed", xml},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newStreamReader(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("newStreamReader: %v", err)
+			}
+			defer r.Close()
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !bytes.Equal(got, xml) {
+				t.Errorf("got %d bytes, want the %d bytes of the fixture XML", len(got), len(xml))
+			}
+		})
+	}
+}
+
+// The fixture dump recompressed with Zstandard imports like the original
+func TestProcessZstdDump(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "articles.xml.zst"), zstdCompress(t, readFixtureArticles(t)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWiki(dir, "index.txt", "articles.xml.zst").WithNamespaces(0, 10, 14)
+	w.SetLogger(testLogger)
+	t.Cleanup(func() { w.Close() })
+	if err := w.ProcessArticlesSequential(-1); err != nil {
+		t.Fatalf("ProcessArticlesSequential: %v", err)
+	}
+
+	var articles int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&articles); err != nil {
+		t.Fatal(err)
+	}
+	if articles != 100 {
+		t.Errorf("got %d articles, want 100", articles)
+	}
+
+	article, err := w.GetArticleByID(42)
+	if err != nil {
+		t.Fatalf("GetArticleByID(42): %v", err)
+	}
+	if article.Title != "Article 42" {
+		t.Errorf("got title %q, want Article 42", article.Title)
+	}
+}
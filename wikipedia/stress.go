@@ -0,0 +1,130 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StressTestResult summarizes the queries run by RunStressTest
+type StressTestResult struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P99LatencyMs float64 `json:"p99_latency_ms"`
+	ErrorRate    float64 `json:"error_rate"`
+}
+
+// stressSampleSize is the number of articles sampled as stress test inputs
+const stressSampleSize = 1000
+
+// stressSample picks random articles to use as stress test inputs
+func (w *Wiki) stressSample(ctx context.Context) ([]ArticleMeta, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var minID, maxID int64
+	if err := w.db.QueryRowContext(ctx, "SELECT COALESCE(MIN(id), 0), COALESCE(MAX(id), 0) FROM articles").Scan(&minID, &maxID); err != nil {
+		return nil, fmt.Errorf("failed to read article ID range: %w", err)
+	}
+	if maxID == 0 {
+		return nil, fmt.Errorf("no articles to stress test")
+	}
+
+	// Look up random IDs instead of ORDER BY RANDOM(), which scans the table
+	sample := make([]ArticleMeta, 0, stressSampleSize)
+	for i := 0; i < stressSampleSize; i++ {
+		var meta ArticleMeta
+		start := minID + rand.Int63n(maxID-minID+1)
+		err := w.db.QueryRowContext(ctx, "SELECT id, title FROM articles WHERE id >= ? ORDER BY id LIMIT 1", start).Scan(&meta.ID, &meta.Title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample articles: %w", err)
+		}
+		sample = append(sample, meta)
+	}
+	return sample, nil
+}
+
+// RunStressTest runs concurrency workers for duration, each repeatedly calling
+// SearchTitles, GetArticleByID or GetArticle with inputs drawn from random
+// articles, and reports the latency and error rate of those calls
+func (w *Wiki) RunStressTest(ctx context.Context, duration time.Duration, concurrency int) (*StressTestResult, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	sample, err := w.stressSample(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+	var errorCount int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+
+			var local []time.Duration
+			var localErrors int64
+			for ctx.Err() == nil {
+				article := sample[rng.Intn(len(sample))]
+				start := time.Now()
+				var err error
+				switch rng.Intn(3) {
+				case 0:
+					query := article.Title
+					if words := strings.Fields(query); len(words) > 0 {
+						query = words[0]
+					}
+					_, err = w.SearchTitles(query, 20, 0)
+				case 1:
+					_, err = w.GetArticleByID(article.ID)
+				default:
+					_, err = w.GetArticle(article.Title)
+				}
+				local = append(local, time.Since(start))
+				if err != nil {
+					localErrors++
+				}
+			}
+
+			mu.Lock()
+			latencies = append(latencies, local...)
+			errorCount += localErrors
+			mu.Unlock()
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+
+	result := &StressTestResult{Requests: int64(len(latencies)), Errors: errorCount}
+	if len(latencies) == 0 {
+		return result, nil
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	toMs := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	result.AvgLatencyMs = toMs(total / time.Duration(len(latencies)))
+	result.P99LatencyMs = toMs(latencies[(len(latencies)*99)/100])
+	result.ErrorRate = float64(errorCount) / float64(len(latencies))
+
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package wikipedia
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunStressTest(t *testing.T) {
+	w := newTestWiki(t,
+		testPage{ID: 1, Title: "Physics", Text: "The science of matter."},
+		testPage{ID: 2, Title: "Quantum mechanics", Text: "The physics of small things."},
+		testPage{ID: 5, Title: "Chemistry", Text: "The science of substances."},
+	)
+
+	result, err := w.RunStressTest(context.Background(), 100*time.Millisecond, 4)
+	if err != nil {
+		t.Fatalf("RunStressTest: %v", err)
+	}
+	if result.Requests == 0 {
+		t.Fatalf("RunStressTest ran no requests: %+v", result)
+	}
+	// Every sampled article exists, so none of the calls fail
+	if result.Errors != 0 || result.ErrorRate != 0 {
+		t.Errorf("RunStressTest = %+v, want no errors", result)
+	}
+	if result.AvgLatencyMs < 0 || result.P99LatencyMs < 0 {
+		t.Errorf("RunStressTest = %+v, want non-negative latencies", result)
+	}
+}
+
+func TestRunStressTestEmpty(t *testing.T) {
+	w := newTestWiki(t)
+
+	if _, err := w.RunStressTest(context.Background(), 10*time.Millisecond, 1); err == nil {
+		t.Error("RunStressTest on an empty database succeeded, want an error")
+	}
+}
@@ -0,0 +1,62 @@
+package wikipedia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultSummaryChars is the summary length used when none is given
+const DefaultSummaryChars = 500
+
+// ArticleSummary is the lead paragraph of an article, for previews
+type ArticleSummary struct {
+	ID      int64  `json:"id"`
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+
+	// Truncated is set when the content of the article was cut when imported,
+	// so that the summary may be incomplete
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetArticleSummary returns the first paragraph of the plain text of an
+// article, cut at a word boundary to at most maxChars characters followed by
+// "…". Articles imported without -strip-text are converted with
+// StripWikitext. maxChars defaults to DefaultSummaryChars.
+func (w *Wiki) GetArticleSummary(id int64, maxChars int) (*ArticleSummary, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	title, text, err := w.articlePlainText(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxChars <= 0 {
+		maxChars = DefaultSummaryChars
+	}
+	var truncated bool
+	if err := w.db.QueryRow("SELECT COALESCE(truncated, 0) FROM articles WHERE id = ?", id).Scan(&truncated); err != nil {
+		return nil, fmt.Errorf("failed to query article: %w", err)
+	}
+
+	lead, _, _ := strings.Cut(strings.TrimSpace(text), "\n\n")
+	return &ArticleSummary{ID: id, Title: title, Summary: truncateWords(strings.TrimSpace(lead), maxChars), Truncated: truncated}, nil
+}
+
+// truncateWords shortens s to at most n characters, cutting after the last
+// whole word and appending "…"
+func truncateWords(s string, n int) string {
+	cut := truncateChars(s, n)
+	if len(cut) == len(s) {
+		return s
+	}
+	if i := strings.LastIndexAny(cut, " \n"); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
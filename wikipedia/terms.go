@@ -0,0 +1,191 @@
+package wikipedia
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TermFrequency is the number of occurrences of a term, in one article or
+// across all of them
+type TermFrequency struct {
+	Term      string `json:"term"`
+	Frequency int    `json:"frequency"`
+}
+
+// termBatchSize is the number of articles whose terms are stored per
+// transaction by BuildWordFrequencyIndex
+const termBatchSize = 1000
+
+// countTerms counts the lowercased words of text, split on whitespace with
+// the surrounding punctuation stripped
+func countTerms(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, field := range strings.Fields(text) {
+		term := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		if term != "" {
+			counts[strings.ToLower(term)]++
+		}
+	}
+	return counts
+}
+
+// BuildWordFrequencyIndex replaces the contents of word_frequencies with the
+// number of occurrences of each term in the plain text of every main namespace
+// article. Articles imported without -strip-text are converted with
+// StripWikitext, and redirects are skipped.
+func (w *Wiki) BuildWordFrequencyIndex() error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.db.Exec("DELETE FROM word_frequencies"); err != nil {
+		return fmt.Errorf("failed to clear word_frequencies: %w", err)
+	}
+
+	var processed int
+	var lastID int64
+	for {
+		rows, err := w.db.Query(`
+			SELECT id, COALESCE(content, content_compressed), plain_text FROM articles
+			WHERE id > ? AND namespace = 0 AND (redirect IS NULL OR redirect = '')
+			ORDER BY id
+			LIMIT ?
+		`, lastID, termBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read articles: %w", err)
+		}
+
+		ids := make([]int64, 0, termBatchSize)
+		counts := make([]map[string]int, 0, termBatchSize)
+		for rows.Next() {
+			var content contentText
+			var plainText sql.NullString
+			if err := rows.Scan(&lastID, &content, &plainText); err != nil {
+				rows.Close()
+				return err
+			}
+			text := plainText.String
+			if !plainText.Valid {
+				text = StripWikitext(string(content))
+			}
+			ids = append(ids, lastID)
+			counts = append(counts, countTerms(text))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		if err := w.storeTermFrequencies(ids, counts); err != nil {
+			return err
+		}
+		processed += len(ids)
+	}
+
+	w.logger.Info("Built word frequency index", "articles", processed)
+	return nil
+}
+
+// storeTermFrequencies inserts the term counts of the articles with the given
+// IDs in a single transaction
+func (w *Wiki) storeTermFrequencies(ids []int64, counts []map[string]int) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO word_frequencies (article_id, term, frequency) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, id := range ids {
+		for term, frequency := range counts[i] {
+			if _, err := stmt.Exec(id, term, frequency); err != nil {
+				return fmt.Errorf("failed to store term frequency: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTopTerms returns the most frequent terms across all articles, as counted
+// by BuildWordFrequencyIndex
+func (w *Wiki) GetTopTerms(limit int) ([]TermFrequency, error) {
+	return w.topTerms(`
+		SELECT term, SUM(frequency) AS total
+		FROM word_frequencies
+		GROUP BY term
+		ORDER BY total DESC, term
+		LIMIT ?
+	`, limit)
+}
+
+// articleTermsSQL selects the most frequent terms of an article
+const articleTermsSQL = `
+	SELECT term, frequency
+	FROM word_frequencies
+	WHERE article_id = ?
+	ORDER BY frequency DESC, term
+	LIMIT ?
+`
+
+// GetArticleTopTerms returns the most frequent terms of an article, as counted
+// by BuildWordFrequencyIndex
+func (w *Wiki) GetArticleTopTerms(id int64, limit int) ([]TermFrequency, error) {
+	return w.topTerms(articleTermsSQL, id, limit)
+}
+
+// topTerms runs a query selecting terms and frequencies, with limit as its
+// last argument
+func (w *Wiki) topTerms(query string, args ...interface{}) ([]TermFrequency, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit := args[len(args)-1].(int); limit <= 0 {
+		args[len(args)-1] = 20
+	}
+
+	return w.queryTerms(query, args...)
+}
+
+// queryTerms is topTerms for callers that already hold w.mu
+func (w *Wiki) queryTerms(query string, args ...interface{}) ([]TermFrequency, error) {
+	rows, err := w.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query term frequencies: %w", err)
+	}
+	defer rows.Close()
+
+	terms := []TermFrequency{}
+	for rows.Next() {
+		var term TermFrequency
+		if err := rows.Scan(&term.Term, &term.Frequency); err != nil {
+			continue
+		}
+		terms = append(terms, term)
+	}
+
+	return terms, rows.Err()
+}
@@ -0,0 +1,31 @@
+package wikipedia
+
+import "unicode/utf8"
+
+// DefaultMaxArticleBytes is the content size stored per article unless set
+// with WithMaxArticleBytes
+const DefaultMaxArticleBytes = 10 * 1024 * 1024
+
+// WithMaxArticleBytes sets the size in bytes at which ProcessArticles cuts the
+// content of an article, marking it as truncated; 0 restores
+// DefaultMaxArticleBytes
+func (w *Wiki) WithMaxArticleBytes(n int) *Wiki {
+	if n <= 0 {
+		n = DefaultMaxArticleBytes
+	}
+	w.maxArticleBytes = n
+	return w
+}
+
+// truncateContent cuts content to at most max bytes without splitting a UTF-8
+// character, and reports whether it was cut
+func truncateContent(content string, max int) (string, bool) {
+	if len(content) <= max {
+		return content, false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut], true
+}
@@ -0,0 +1,113 @@
+package wikipedia
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// VerifyResult reports the outcome of the database checks run by Verify
+type VerifyResult struct {
+	// IntegrityCheck holds the messages of PRAGMA integrity_check, "ok" when
+	// the database file is sound
+	IntegrityCheck []string `json:"integrity_check"`
+
+	Articles                  int64 `json:"articles"`
+	IndexedArticles           int64 `json:"indexed_articles"`
+	ArticlesWithoutIndexEntry int64 `json:"articles_without_index_entry"`
+	BrokenRedirects           int64 `json:"broken_redirects"`
+	ArticlesMissingContent    int64 `json:"articles_missing_content"`
+
+	// CompressedArticles are stored gzip-compressed (see WithCompression), in
+	// CompressedBytes instead of UncompressedBytes
+	CompressedArticles int64 `json:"compressed_articles"`
+	CompressedBytes    int64 `json:"compressed_bytes"`
+	UncompressedBytes  int64 `json:"uncompressed_bytes"`
+
+	OK bool `json:"ok"`
+}
+
+// Verify checks the integrity of the database file and the consistency of an
+// import: every article should have an index entry, every redirect should
+// point to a stored title and every article should have content. Index
+// entries without an article are expected for pages outside the processed
+// namespaces and are not reported as errors.
+func (w *Wiki) Verify() (*VerifyResult, error) {
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	result := &VerifyResult{}
+
+	rows, err := w.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read integrity check: %w", err)
+		}
+		result.IntegrityCheck = append(result.IntegrityCheck, message)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+
+	counts := []struct {
+		dest  *int64
+		query string
+	}{
+		{&result.Articles, "SELECT COUNT(*) FROM articles"},
+		{&result.IndexedArticles, "SELECT COUNT(*) FROM (SELECT article_id FROM index_entries GROUP BY article_id)"},
+		{&result.ArticlesWithoutIndexEntry, "SELECT COUNT(*) FROM articles WHERE id NOT IN (SELECT article_id FROM index_entries)"},
+		// Redirects may point to a section: "United States#History"
+		{&result.BrokenRedirects, `
+			SELECT COUNT(*) FROM articles a
+			WHERE a.redirect != '' AND NOT EXISTS (
+				SELECT 1 FROM articles t
+				WHERE t.title = CASE WHEN instr(a.redirect, '#') > 0
+					THEN substr(a.redirect, 1, instr(a.redirect, '#') - 1)
+					ELSE a.redirect END
+			)`},
+		{&result.ArticlesMissingContent, "SELECT COUNT(*) FROM articles WHERE (content IS NULL OR content = '') AND content_compressed IS NULL"},
+		{&result.CompressedArticles, "SELECT COUNT(*) FROM articles WHERE content_compressed IS NOT NULL"},
+		{&result.CompressedBytes, "SELECT COALESCE(SUM(LENGTH(content_compressed)), 0) FROM articles"},
+	}
+	for _, c := range counts {
+		if err := w.db.QueryRow(c.query).Scan(c.dest); err != nil {
+			return nil, fmt.Errorf("failed to verify database: %w", err)
+		}
+	}
+
+	// The last 4 bytes of a gzip stream hold the uncompressed size
+	rows, err = w.db.Query("SELECT substr(content_compressed, -4) FROM articles WHERE content_compressed IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify database: %w", err)
+	}
+	for rows.Next() {
+		var footer []byte
+		if err := rows.Scan(&footer); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to verify database: %w", err)
+		}
+		if len(footer) == 4 {
+			result.UncompressedBytes += int64(binary.LittleEndian.Uint32(footer))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to verify database: %w", err)
+	}
+
+	result.OK = len(result.IntegrityCheck) == 1 && result.IntegrityCheck[0] == "ok" &&
+		result.ArticlesWithoutIndexEntry == 0 &&
+		result.BrokenRedirects == 0 &&
+		result.ArticlesMissingContent == 0
+
+	return result, nil
+}
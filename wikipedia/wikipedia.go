@@ -2,41 +2,201 @@ package wikipedia
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
-
-	"github.com/d4l3k/go-pbzip2"
-	_ "github.com/mattn/go-sqlite3"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"time"
 )
 
 type Wiki struct {
 	dbPath       string
-	db           *sql.DB
+	db           *retryDB
 	indexFile    string
 	articlesFile string
 	mu           sync.RWMutex
 	initialized  bool
 	ftsVersion   string // "fts5", "fts4", or "none"
+	legacyFTS    bool   // the full-text index cannot read compressed content (see RebuildFTS)
+	stripText    bool   // store plain text when processing articles
+	resume       bool   // skip articles committed by a previous ProcessArticles run
+	incremental  bool   // skip articles stored with the same revision timestamp
+	cache        *ArticleCache
+	namespaces   map[int]bool // namespaces stored by ProcessArticles, main only when empty
+	dryRun       bool         // parse the dump without writing to the database
+	mailtoLinks  bool         // store mailto: links in external_links
+	readOnly     bool         // opened by OpenReadOnly, writers return ErrReadOnly
+	gzipContent  bool         // store content gzip-compressed (see WithCompression)
+	logSearches  bool         // record searches for GetPopularCategories
+	logger       *slog.Logger
+
+	// maxArticleBytes is the content size stored per article (see
+	// WithMaxArticleBytes)
+	maxArticleBytes int
+
+	// maxRetries is the number of retries of statements failing because the
+	// database is locked (see WithMaxRetries)
+	maxRetries int
+
+	// Connection pool settings applied by Open (see WithConnPool)
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxIdleTime time.Duration
+
+	// adjList caches the link graph of BuildAdjacencyList, nil until loaded;
+	// adjGeneration counts its invalidations
+	adjMu         sync.RWMutex
+	adjList       map[int64][]int64
+	adjGeneration int
+
+	// popularCache holds the results of GetPopularCategories by time and
+	// limit until they expire
+	popularMu    sync.Mutex
+	popularCache map[string]popularCategories
 }
 
+// Default connection pool settings of a Wiki
+const (
+	DefaultMaxOpenConns    = 10
+	DefaultMaxIdleConns    = 5
+	DefaultConnMaxIdleTime = 5 * time.Minute
+)
+
 type Article struct {
 	ID        int64  `json:"id"`
 	Title     string `json:"title"`
 	Namespace int    `json:"namespace"`
 	Content   string `json:"content"`
 	Redirect  string `json:"redirect,omitempty"`
+
+	// Ambiguity is set when the article is a disambiguation page
+	Ambiguity *AmbiguityReport `json:"ambiguity,omitempty"`
+
+	// ResolvedFrom lists the redirect titles followed to reach this article
+	ResolvedFrom []string `json:"resolved_from,omitempty"`
+
+	// Revision metadata of the dump the article was imported from
+	RevisionID        string `json:"revision_id,omitempty"`
+	RevisionTimestamp string `json:"revision_timestamp,omitempty"`
+	ContributorName   string `json:"contributor_name,omitempty"`
+	ContributorID     string `json:"contributor_id,omitempty"`
+
+	// Snippet is an excerpt of the matching content, set by SearchContent and
+	// by highlighted searches
+	Snippet string `json:"snippet,omitempty"`
+
+	// HighlightedTitle is the title with the matching terms between <b> and
+	// </b>, set by highlighted searches
+	HighlightedTitle string `json:"highlighted_title,omitempty"`
+
+	// WordCount is the number of whitespace-separated words of the wikitext
+	WordCount int `json:"word_count,omitempty"`
+
+	// Truncated is set when the content was cut at the maximum article size
+	// when imported (see WithMaxArticleBytes)
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// articleColumns selects a full article from the articles table, in the order
+// of Article.scanFields
+const articleColumns = `id, title, namespace, COALESCE(content, content_compressed), redirect,
+	COALESCE(revision_id, ''), COALESCE(revision_timestamp, ''),
+	COALESCE(contributor_name, ''), COALESCE(contributor_id, ''), COALESCE(word_count, 0),
+	COALESCE(truncated, 0)`
+
+// scanFields returns the scan destinations matching articleColumns
+func (a *Article) scanFields() []interface{} {
+	return []interface{}{
+		&a.ID, &a.Title, &a.Namespace, (*contentText)(&a.Content), &a.Redirect,
+		&a.RevisionID, &a.RevisionTimestamp, &a.ContributorName, &a.ContributorID, &a.WordCount,
+		&a.Truncated,
+	}
+}
+
+// SchemaVersion is the version of the database schema this code writes. The
+// schema built by createTables is version 1; later changes are migrations.
+const SchemaVersion = 5
+
+// migration upgrades the database schema to version by running sql
+type migration struct {
+	version int
+	sql     string
+}
+
+// migrations are run in order by Open on databases with an older schema
+// version. A schema change bumps SchemaVersion and appends a migration, e.g.
+// {2, "ALTER TABLE articles ADD COLUMN ..."}; createTables is left unchanged so
+// that new databases reach the same schema through the migrations.
+var migrations = []migration{
+	{2, "ALTER TABLE articles ADD COLUMN content_compressed BLOB"},
+	{3, "CREATE TABLE IF NOT EXISTS namespaces (id INTEGER PRIMARY KEY, name TEXT, canonical TEXT)"},
+	{4, `CREATE TABLE IF NOT EXISTS word_frequencies (article_id INTEGER NOT NULL, term TEXT NOT NULL, frequency INTEGER NOT NULL, PRIMARY KEY (article_id, term));
+		CREATE INDEX IF NOT EXISTS idx_word_frequencies_term ON word_frequencies(term)`},
+	{5, "ALTER TABLE articles ADD COLUMN truncated BOOLEAN DEFAULT 0"},
+}
+
+// DefaultMaxRedirects is the number of redirect hops GetArticle follows
+const DefaultMaxRedirects = 3
+
+var (
+	// ErrRedirectLoop is returned when a redirect chain leads back to itself
+	ErrRedirectLoop = errors.New("circular redirect")
+
+	// ErrTooManyRedirects is returned when a redirect chain is longer than allowed
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ErrReadOnly is returned by methods writing to a database opened with
+	// OpenReadOnly
+	ErrReadOnly = errors.New("database is opened read-only")
+)
+
+// derivedColumns are the articles columns computed from the wikitext when an
+// article is stored; derivedValues returns their values in the same order
+var derivedColumns = []string{"infobox_data", "birth_year", "death_year", "is_living", "content_hash", "word_count"}
+
+// derivedValues computes the derivedColumns values for an article's wikitext
+func derivedValues(content string) []interface{} {
+	birthYear, deathYear, isLiving := biographyValues(content)
+	return []interface{}{infoboxJSON(content), birthYear, deathYear, isLiving, contentHash(content), len(strings.Fields(content))}
+}
+
+// storeDerivedRows replaces the rows of the tables extracted from an
+// article's wikitext: its internal, interlanguage and external links, its
+// categories and its media references
+func (w *Wiki) storeDerivedRows(ctx context.Context, db execer, id int64, content string) error {
+	if err := storeLinks(ctx, db, id, content); err != nil {
+		return err
+	}
+	if err := storeLanguageLinks(ctx, db, id, content); err != nil {
+		return err
+	}
+	if err := storeExternalLinks(ctx, db, id, content, w.mailtoLinks); err != nil {
+		return err
+	}
+	if err := storeCategories(ctx, db, id, content); err != nil {
+		return err
+	}
+	return storeMedia(ctx, db, id, content)
 }
 
+// insertArticleSQL stores a parsed article, its content either as is or
+// compressed (see WithCompression), along with its derived columns, its plain
+// text, which is only computed when requested (see WithStripText), its
+// revision metadata and whether its content was truncated
+var insertArticleSQL = `
+	INSERT OR REPLACE INTO articles (id, title, namespace, content, content_compressed, redirect, ` + strings.Join(derivedColumns, ", ") + `, plain_text,
+		revision_id, revision_timestamp, contributor_name, contributor_id, truncated)
+	VALUES (?, ?, ?, ?, ?, ?` + strings.Repeat(", ?", len(derivedColumns)) + `, ?, ?, ?, ?, ?, ?)
+`
+
 type IndexEntry struct {
 	Seek int64
 	ID   int64
@@ -48,11 +208,54 @@ func NewWiki(dumpPath, indexFile, articlesFile string) *Wiki {
 		indexFile:    filepath.Join(dumpPath, indexFile),
 		articlesFile: filepath.Join(dumpPath, articlesFile),
 		dbPath:       filepath.Join(dumpPath, "wikipedia.db"),
+		cache:        NewArticleCache(DefaultCacheSize),
+		logger:       slog.Default(),
+
+		maxArticleBytes: DefaultMaxArticleBytes,
+		maxRetries:      DefaultMaxRetries,
+
+		maxOpenConns:    DefaultMaxOpenConns,
+		maxIdleConns:    DefaultMaxIdleConns,
+		connMaxIdleTime: DefaultConnMaxIdleTime,
+	}
+}
+
+// WithConnPool sets the maximum number of open and idle database connections
+// and how long a connection may stay idle before being closed, with the
+// semantics of the database/sql setters. Concurrent reads rely on the WAL
+// journal mode set by Open. It must be called before Open.
+func (w *Wiki) WithConnPool(maxOpen, maxIdle int, maxIdleTime time.Duration) *Wiki {
+	w.maxOpenConns = maxOpen
+	w.maxIdleConns = maxIdle
+	w.connMaxIdleTime = maxIdleTime
+	return w
+}
+
+// SetLogger sets the logger of the import and maintenance messages, the
+// default slog logger when l is nil. It must be called before the Wiki is used.
+func (w *Wiki) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.Default()
 	}
+	w.logger = l
 }
 
 // Open initializes the database connection
 func (w *Wiki) Open() error {
+	return w.open(false)
+}
+
+// OpenReadOnly initializes the database connection in read-only mode, for
+// serving without accidental writes: SQLite rejects every write, and LoadIndex,
+// ProcessArticles, DeleteArticle and RebuildFTS return ErrReadOnly. The number
+// of connections is not limited. The database must exist and be migrated to
+// SchemaVersion. It does nothing if the database is already open.
+func (w *Wiki) OpenReadOnly() error {
+	return w.open(true)
+}
+
+// open is Open, or OpenReadOnly when readOnly is set
+func (w *Wiki) open(readOnly bool) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -60,16 +263,30 @@ func (w *Wiki) Open() error {
 		return nil
 	}
 
+	var db *sql.DB
 	var err error
-	w.db, err = sql.Open("sqlite3", w.dbPath+"?_journal_mode=WAL&_sync=OFF&_cache_size=10000")
+	if readOnly {
+		db, err = sql.Open(sqliteDriver, "file:"+w.dbPath+"?mode=ro&_cache_size=10000")
+	} else {
+		db, err = sql.Open(sqliteDriver, w.dbPath+"?_journal_mode=WAL&_sync=OFF&_cache_size=10000&_recursive_triggers=1")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
-
-	// Enable WAL mode for better concurrency
-	_, err = w.db.Exec("PRAGMA journal_mode=WAL")
-	if err != nil {
-		return fmt.Errorf("failed to set WAL mode: %w", err)
+	w.db = &retryDB{DB: db, maxRetries: w.maxRetries}
+	w.db.SetMaxOpenConns(w.maxOpenConns)
+	if readOnly {
+		w.db.SetMaxOpenConns(0)
+	}
+	w.db.SetMaxIdleConns(w.maxIdleConns)
+	w.db.SetConnMaxIdleTime(w.connMaxIdleTime)
+
+	// Enable WAL mode for better concurrency; a read-only connection cannot
+	// change the journal mode and uses the one of the database
+	if !readOnly {
+		if _, err := w.db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+			return fmt.Errorf("failed to set WAL mode: %w", err)
+		}
 	}
 
 	// Set cache size to reduce memory usage
@@ -81,11 +298,30 @@ func (w *Wiki) Open() error {
 	// Check SQLite compile options to see if FTS5 is available
 	w.checkFTS5Availability()
 
+	if readOnly {
+		if err := w.checkReadOnlySchema(); err != nil {
+			return err
+		}
+		w.readOnly = true
+		w.initialized = true
+		return nil
+	}
+
+	// Refuse databases written by a newer version before changing anything
+	version, err := w.schemaVersion()
+	if err != nil {
+		return err
+	}
+
 	// Create tables
 	if err := w.createTables(); err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	if err := w.migrate(version); err != nil {
+		return err
+	}
+
 	w.initialized = true
 	return nil
 }
@@ -105,7 +341,7 @@ func (w *Wiki) Close() error {
 func (w *Wiki) checkFTS5Availability() {
 	rows, err := w.db.Query("PRAGMA compile_options")
 	if err != nil {
-		log.Printf("Warning: Could not check SQLite compile options: %v", err)
+		w.logger.Warn("Could not check SQLite compile options", "error", err)
 		return
 	}
 	defer rows.Close()
@@ -128,15 +364,11 @@ func (w *Wiki) checkFTS5Availability() {
 	}
 
 	if !hasFTS5 {
-		log.Printf("WARNING: FTS5 is not available in this SQLite build.")
-		log.Printf("SQLite compile options: %v", options)
-		log.Printf("To enable FTS5:")
-		log.Printf("  1. Rebuild go-sqlite3 with: go build -tags sqlite_fts5")
-		log.Printf("  2. Or use a system SQLite compiled with --enable-fts5")
-		log.Printf("  3. Or use the sqlite_use_icu build tag for go-sqlite3")
-		log.Printf("Falling back to FTS4 or LIKE queries.")
+		w.logger.Warn("FTS5 is not available in this SQLite build, falling back to FTS4 or LIKE queries",
+			"compile_options", options,
+			"hint", "rebuild go-sqlite3 with go build -tags sqlite_fts5, use a system SQLite compiled with --enable-fts5, or use the sqlite_use_icu build tag")
 	} else {
-		log.Printf("FTS5 is available in SQLite build")
+		w.logger.Info("FTS5 is available in SQLite build")
 	}
 }
 
@@ -157,11 +389,35 @@ func (w *Wiki) createTables() error {
 		return fmt.Errorf("failed to create articles table: %w", err)
 	}
 
+	// Columns added after the initial schema
+	columns := []struct{ name, definition string }{
+		{"infobox_data", "TEXT"},
+		{"birth_year", "INTEGER"},
+		{"death_year", "INTEGER"},
+		{"is_living", "BOOLEAN"},
+		{"content_hash", "TEXT"},
+		{"plain_text", "TEXT"},
+		{"revision_id", "TEXT"},
+		{"revision_timestamp", "TEXT"},
+		{"contributor_name", "TEXT"},
+		{"contributor_id", "TEXT"},
+		{"word_count", "INTEGER"},
+	}
+	for _, column := range columns {
+		if err := w.ensureColumn("articles", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
 	// Create indexes
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_articles_title ON articles(title)",
 		"CREATE INDEX IF NOT EXISTS idx_articles_namespace ON articles(namespace)",
 		"CREATE INDEX IF NOT EXISTS idx_articles_redirect ON articles(redirect)",
+		"CREATE INDEX IF NOT EXISTS idx_articles_infobox_type ON articles(JSON_EXTRACT(infobox_data, '$.type'))",
+		"CREATE INDEX IF NOT EXISTS idx_articles_birth_year ON articles(birth_year)",
+		"CREATE INDEX IF NOT EXISTS idx_articles_content_hash ON articles(content_hash)",
+		"CREATE INDEX IF NOT EXISTS idx_articles_word_count ON articles(word_count)",
 	}
 
 	for _, idx := range indexes {
@@ -171,94 +427,20 @@ func (w *Wiki) createTables() error {
 	}
 
 	// Check if FTS table already exists and detect version
-	var existingSQL string
-	err := w.db.QueryRow(`
-		SELECT sql FROM sqlite_master
-		WHERE name = 'articles_fts' AND type = 'table'
-	`).Scan(&existingSQL)
-
-	ftsVersion := "none"
-	if err == nil && existingSQL != "" {
-		// Table exists, detect version from SQL
-		if strings.Contains(strings.ToLower(existingSQL), "fts5") {
-			ftsVersion = "fts5"
-		} else if strings.Contains(strings.ToLower(existingSQL), "fts4") {
-			ftsVersion = "fts4"
-		}
-		log.Printf("Detected existing %s table", ftsVersion)
+	ftsVersion, exists := w.existingFTSVersion()
+	if exists {
+		w.logger.Info("Detected existing full-text table", "fts", ftsVersion)
 	} else {
-		// Table doesn't exist, try to create FTS5 first
-		createFTS5 := `
-		CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5(
-			title,
-			content,
-			content_rowid=id,
-			content='articles'
-		)`
-
-		if _, err := w.db.Exec(createFTS5); err != nil {
-			// FTS5 not available, try FTS4
-			log.Printf("FTS5 not available, trying FTS4: %v", err)
-			createFTS4 := `
-			CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts4(
-				title,
-				content,
-				content='articles'
-			)`
-			if _, err := w.db.Exec(createFTS4); err != nil {
-				log.Printf("FTS4 also not available, will use LIKE queries: %v", err)
-				ftsVersion = "none"
-			} else {
-				ftsVersion = "fts4"
-				// FTS4 triggers (slightly different syntax)
-				triggers := []string{
-					`CREATE TRIGGER IF NOT EXISTS articles_ai AFTER INSERT ON articles BEGIN
-						INSERT INTO articles_fts(docid, title, content) VALUES (new.id, new.title, new.content);
-					END`,
-					`CREATE TRIGGER IF NOT EXISTS articles_ad AFTER DELETE ON articles BEGIN
-						DELETE FROM articles_fts WHERE docid = old.id;
-					END`,
-					`CREATE TRIGGER IF NOT EXISTS articles_au AFTER UPDATE ON articles BEGIN
-						DELETE FROM articles_fts WHERE docid = old.id;
-						INSERT INTO articles_fts(docid, title, content) VALUES (new.id, new.title, new.content);
-					END`,
-				}
-				for _, trigger := range triggers {
-					if _, err := w.db.Exec(trigger); err != nil {
-						log.Printf("Warning: failed to create FTS4 trigger: %v", err)
-					}
-				}
-			}
-		} else {
-			ftsVersion = "fts5"
-			// FTS5 triggers
-			triggers := []string{
-				`CREATE TRIGGER IF NOT EXISTS articles_ai AFTER INSERT ON articles BEGIN
-					INSERT INTO articles_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
-				END`,
-				`CREATE TRIGGER IF NOT EXISTS articles_ad AFTER DELETE ON articles BEGIN
-					DELETE FROM articles_fts WHERE rowid = old.id;
-				END`,
-				`CREATE TRIGGER IF NOT EXISTS articles_au AFTER UPDATE ON articles BEGIN
-					DELETE FROM articles_fts WHERE rowid = old.id;
-					INSERT INTO articles_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
-				END`,
-			}
-			for _, trigger := range triggers {
-				if _, err := w.db.Exec(trigger); err != nil {
-					log.Printf("Warning: failed to create FTS5 trigger: %v", err)
-				}
-			}
-		}
+		ftsVersion = w.createFTS()
 	}
 
 	// Store FTS version for later use
 	w.ftsVersion = ftsVersion
 
 	if w.ftsVersion != "none" {
-		log.Printf("Using %s for full-text search", w.ftsVersion)
+		w.logger.Info("Using full-text search", "fts", w.ftsVersion)
 	} else {
-		log.Printf("FTS not available, using LIKE-based search")
+		w.logger.Warn("FTS not available, using LIKE-based search")
 	}
 
 	// Index entries table for fast lookup
@@ -273,28 +455,335 @@ func (w *Wiki) createTables() error {
 		return fmt.Errorf("failed to create index_entries table: %w", err)
 	}
 
-	if _, err := w.db.Exec("CREATE INDEX IF NOT EXISTS idx_index_entries_seek ON index_entries(seek)"); err != nil {
-		return fmt.Errorf("failed to create index_entries index: %w", err)
+	indexEntriesIndexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_index_entries_seek ON index_entries(seek)",
+		"CREATE INDEX IF NOT EXISTS idx_index_entries_article ON index_entries(article_id)",
+	}
+	for _, idx := range indexEntriesIndexes {
+		if _, err := w.db.Exec(idx); err != nil {
+			return fmt.Errorf("failed to create index_entries index: %w", err)
+		}
+	}
+
+	if err := w.createImportTables(); err != nil {
+		return err
+	}
+
+	if err := w.createNgramTables(); err != nil {
+		return err
+	}
+
+	if err := w.createLinkTables(); err != nil {
+		return err
+	}
+
+	if err := w.createLanguageLinkTables(); err != nil {
+		return err
+	}
+
+	if err := w.createExternalLinkTables(); err != nil {
+		return err
+	}
+
+	if err := w.createCategoryTables(); err != nil {
+		return err
+	}
+
+	if err := w.createMediaTables(); err != nil {
+		return err
+	}
+
+	if err := w.createDuplicateTables(); err != nil {
+		return err
+	}
+
+	if err := w.createReadingListTables(); err != nil {
+		return err
+	}
+
+	if err := w.createSearchLogTables(); err != nil {
+		return err
+	}
+
+	if err := w.createSignificanceTables(); err != nil {
+		return err
+	}
+
+	if err := w.createProgressTable(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// existingFTSVersion returns the version of the articles_fts table ("none"
+// when it is neither FTS5 nor FTS4) and whether the table exists. It sets
+// legacyFTS when the table reads its content from articles instead of
+// ftsContentView.
+func (w *Wiki) existingFTSVersion() (string, bool) {
+	var existingSQL string
+	err := w.db.QueryRow(`
+		SELECT sql FROM sqlite_master
+		WHERE name = 'articles_fts' AND type = 'table'
+	`).Scan(&existingSQL)
+	if err != nil || existingSQL == "" {
+		return "none", false
+	}
+
+	definition := strings.ToLower(existingSQL)
+	w.legacyFTS = !strings.Contains(definition, ftsContentView)
+	switch {
+	case strings.Contains(definition, "fts5"):
+		return "fts5", true
+	case strings.Contains(definition, "fts4"):
+		return "fts4", true
+	}
+	return "none", true
+}
+
+// ftsContentView is the external content table of the full-text index: the
+// articles with their decompressed content. FTS4 reads it by rowid.
+const ftsContentView = "articles_fts_content"
+
+// createFTS creates the articles_fts table with FTS5, or FTS4 when FTS5 is
+// not available, along with the triggers keeping it in sync with articles,
+// and returns the version used ("none" when neither is available). The index
+// reads the content through ftsContentView, so that compressed articles are
+// indexed and have snippets.
+func (w *Wiki) createFTS() string {
+	ftsVersion := "none"
+
+	// Try FTS5 first
+	statements := []string{
+		`CREATE VIEW IF NOT EXISTS ` + ftsContentView + ` AS
+		SELECT id, title, ` + contentSQL("articles") + ` AS content FROM articles`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5(
+			title,
+			content,
+			content_rowid=id,
+			content='` + ftsContentView + `'
+		)`,
+	}
+	// FTS5 removes the terms of the values passed to its delete command
+	triggers := []string{
+		`CREATE TRIGGER IF NOT EXISTS articles_ai AFTER INSERT ON articles BEGIN
+			INSERT INTO articles_fts(rowid, title, content) VALUES (new.id, new.title, ` + contentSQL("new") + `);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS articles_ad AFTER DELETE ON articles BEGIN
+			INSERT INTO articles_fts(articles_fts, rowid, title, content) VALUES ('delete', old.id, old.title, ` + contentSQL("old") + `);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS articles_au AFTER UPDATE ON articles BEGIN
+			INSERT INTO articles_fts(articles_fts, rowid, title, content) VALUES ('delete', old.id, old.title, ` + contentSQL("old") + `);
+			INSERT INTO articles_fts(rowid, title, content) VALUES (new.id, new.title, ` + contentSQL("new") + `);
+		END`,
+	}
+
+	if err := w.execAll(statements); err != nil {
+		// FTS5 not available, try FTS4
+		w.logger.Info("FTS5 not available, trying FTS4", "error", err)
+		statements = []string{
+			"DROP VIEW IF EXISTS " + ftsContentView,
+			`CREATE VIEW ` + ftsContentView + ` AS
+			SELECT id AS rowid, title, ` + contentSQL("articles") + ` AS content FROM articles`,
+			`CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts4(
+				title,
+				content,
+				content='` + ftsContentView + `'
+			)`,
+		}
+		// FTS4 reads the terms to remove from the content table, so they are
+		// removed before the row changes
+		triggers = []string{
+			`CREATE TRIGGER IF NOT EXISTS articles_ai AFTER INSERT ON articles BEGIN
+				INSERT INTO articles_fts(docid, title, content) VALUES (new.id, new.title, ` + contentSQL("new") + `);
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS articles_bd BEFORE DELETE ON articles BEGIN
+				DELETE FROM articles_fts WHERE docid = old.id;
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS articles_bu BEFORE UPDATE ON articles BEGIN
+				DELETE FROM articles_fts WHERE docid = old.id;
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS articles_au AFTER UPDATE ON articles BEGIN
+				INSERT INTO articles_fts(docid, title, content) VALUES (new.id, new.title, ` + contentSQL("new") + `);
+			END`,
+		}
+		if err := w.execAll(statements); err != nil {
+			w.logger.Warn("FTS4 also not available, will use LIKE queries", "error", err)
+			w.db.Exec("DROP VIEW IF EXISTS " + ftsContentView)
+			return ftsVersion
+		}
+		ftsVersion = "fts4"
+	} else {
+		ftsVersion = "fts5"
+	}
+
+	for _, trigger := range triggers {
+		if _, err := w.db.Exec(trigger); err != nil {
+			w.logger.Warn("Failed to create full-text trigger", "fts", ftsVersion, "error", err)
+		}
+	}
+	return ftsVersion
+}
+
+// execAll runs statements in order, stopping at the first error
+func (w *Wiki) execAll(statements []string) error {
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureColumn adds a column to an existing table if it is missing, so that
+// databases created by older versions pick up new columns
+func (w *Wiki) ensureColumn(table, column, definition string) error {
+	rows, err := w.db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+
+	if _, err := w.db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	w.logger.Info("Added column", "table", table, "column", column)
+	return nil
+}
+
+// schemaVersion returns the schema version stored in the database, storing
+// version 1 for databases created before versioning or not created yet. It
+// fails if the database was written by a newer version of the code.
+func (w *Wiki) schemaVersion() (int, error) {
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)",
+		"INSERT INTO schema_version (version) SELECT 1 WHERE NOT EXISTS (SELECT 1 FROM schema_version)",
+	}
+	for _, statement := range statements {
+		if _, err := w.db.Exec(statement); err != nil {
+			return 0, fmt.Errorf("failed to store schema version: %w", err)
+		}
+	}
+	return w.storedSchemaVersion()
+}
+
+// storedSchemaVersion reads the schema version without writing, 1 when it is
+// not stored. It fails if the database was written by a newer version of the
+// code.
+func (w *Wiki) storedSchemaVersion() (int, error) {
+	var tables int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'").Scan(&tables); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	version := 1
+	if tables > 0 {
+		err := w.db.QueryRow("SELECT version FROM schema_version").Scan(&version)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("failed to read schema version: %w", err)
+		}
+	}
+
+	if version > SchemaVersion {
+		return 0, fmt.Errorf("database %s has schema version %d, newer than version %d supported by this build: upgrade wikipedia_sqlite or rebuild the database", w.dbPath, version, SchemaVersion)
+	}
+	return version, nil
+}
+
+// checkReadOnlySchema checks that a database opened read-only needs no
+// migration, and detects its FTS version
+func (w *Wiki) checkReadOnlySchema() error {
+	version, err := w.storedSchemaVersion()
+	if err != nil {
+		return err
 	}
+	if version < SchemaVersion {
+		return fmt.Errorf("database %s has schema version %d, older than version %d: open it once without read-only mode to migrate it", w.dbPath, version, SchemaVersion)
+	}
+
+	w.ftsVersion, _ = w.existingFTSVersion()
+	w.logger.Info("Opened database read-only", "fts", w.ftsVersion)
+	return nil
+}
+
+// migrate runs the migrations newer than version, each in a transaction
+// storing its version
+func (w *Wiki) migrate(version int) error {
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
 
+		tx, err := w.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate schema to version %d: %w", m.version, err)
+		}
+		if _, err := tx.Exec("UPDATE schema_version SET version = ?", m.version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to store schema version %d: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+		}
+		w.logger.Info("Migrated database schema", "from", version, "to", m.version)
+		version = m.version
+	}
 	return nil
 }
 
 // LoadIndex loads the index file into the database
 func (w *Wiki) LoadIndex(limit int) error {
+	return w.LoadIndexWithProgress(limit, nil)
+}
+
+// LoadIndexWithProgress is LoadIndex sending a ProgressEvent on progress after
+// every batch and once done; a nil channel disables events
+func (w *Wiki) LoadIndexWithProgress(limit int, progress chan<- ProgressEvent) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+	if w.dryRun {
+		return w.dryRunLoadIndex(limit, progress)
+	}
+
 	if err := w.Open(); err != nil {
 		return err
 	}
 
+	start := time.Now()
+	total := 0
+	if limit > 0 {
+		total = limit
+	}
+
 	f, err := os.Open(w.indexFile)
 	if err != nil {
 		return fmt.Errorf("failed to open index file: %w", err)
 	}
 	defer f.Close()
 
-	r, err := pbzip2.NewReader(f)
+	r, err := newStreamReader(f)
 	if err != nil {
-		return fmt.Errorf("failed to create bzip2 reader: %w", err)
+		return fmt.Errorf("failed to create stream reader: %w", err)
 	}
 	defer r.Close()
 
@@ -302,7 +791,7 @@ func (w *Wiki) LoadIndex(limit int) error {
 	buf := make([]byte, 0, 16*1024)
 	scanner.Buffer(buf, 16*1024)
 
-	log.Printf("Reading index file %s...", w.indexFile)
+	w.logger.Info("Reading index file", "file", w.indexFile)
 
 	// Begin transaction for batch inserts
 	tx, err := w.db.Begin()
@@ -339,7 +828,7 @@ func (w *Wiki) LoadIndex(limit int) error {
 
 		_, err = stmt.Exec(seek, id)
 		if err != nil {
-			log.Printf("Error inserting index entry: %v", err)
+			w.logger.Error("Error inserting index entry", "error", err)
 			continue
 		}
 
@@ -348,7 +837,8 @@ func (w *Wiki) LoadIndex(limit int) error {
 			if err := tx.Commit(); err != nil {
 				return fmt.Errorf("failed to commit transaction: %w", err)
 			}
-			log.Printf("Processed %d index entries", i)
+			w.logger.Info("Processed index entries", "count", i)
+			sendProgress(progress, PhaseLoadIndex, i, total, start)
 
 			// Start new transaction
 			tx, err = w.db.Begin()
@@ -374,34 +864,90 @@ func (w *Wiki) LoadIndex(limit int) error {
 		return fmt.Errorf("failed to commit final transaction: %w", err)
 	}
 
-	log.Printf("Done loading index! Processed %d entries", i)
+	w.logger.Info("Done loading index", "count", i)
+	sendProgress(progress, PhaseLoadIndex, i, i, start)
 	return nil
 }
 
 // ProcessArticles processes the articles dump and stores them in the database
 // This processes the entire XML stream and stores articles that are in the index
 func (w *Wiki) ProcessArticles(limit int) error {
+	return w.ProcessArticlesWithProgress(limit, nil)
+}
+
+// ProcessArticlesWithProgress is ProcessArticles sending a ProgressEvent on
+// progress after every batch and once done; a nil channel disables events
+func (w *Wiki) ProcessArticlesWithProgress(limit int, progress chan<- ProgressEvent) error {
+	return w.processArticles(limit, progress, true)
+}
+
+// ProcessArticlesSequential is ProcessArticles for an articles file without
+// its index: index_entries is not consulted, so every page of the selected
+// namespaces is stored. It is slower than ProcessArticlesParallel, which
+// needs the index to split the file into streams, and the progress total is
+// unknown unless limit is set.
+func (w *Wiki) ProcessArticlesSequential(limit int) error {
+	return w.processArticles(limit, nil, false)
+}
+
+// processArticles stores the pages of the articles file, only those in the
+// index when useIndex is set
+func (w *Wiki) processArticles(limit int, progress chan<- ProgressEvent, useIndex bool) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+	if w.dryRun {
+		return w.dryRunProcessArticles(limit, progress)
+	}
+
 	if err := w.Open(); err != nil {
 		return err
 	}
+	if err := w.checkCompression(); err != nil {
+		return err
+	}
+
+	start := time.Now()
 
 	// Get all article IDs from index to know which articles to process
-	rows, err := w.db.Query("SELECT DISTINCT article_id FROM index_entries")
-	if err != nil {
-		return fmt.Errorf("failed to query index entries: %w", err)
+	var indexSet map[int64]bool
+	total := limit
+	if useIndex {
+		rows, err := w.db.Query("SELECT DISTINCT article_id FROM index_entries")
+		if err != nil {
+			return fmt.Errorf("failed to query index entries: %w", err)
+		}
+		defer rows.Close()
+
+		indexSet = make(map[int64]bool)
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				continue
+			}
+			indexSet[id] = true
+		}
+
+		w.logger.Info("Found articles to process from index", "count", len(indexSet))
+
+		total = len(indexSet)
+		if limit > 0 && limit < total {
+			total = limit
+		}
 	}
-	defer rows.Close()
 
-	indexSet := make(map[int64]bool)
-	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
-			continue
+	var resumeAfter int64
+	var err error
+	if w.resume {
+		if resumeAfter, err = w.lastProcessedArticleID(); err != nil {
+			return err
 		}
-		indexSet[id] = true
+		w.logger.Info("Resuming after article", "id", resumeAfter)
 	}
 
-	log.Printf("Found %d articles to process from index", len(indexSet))
+	if err := w.importSiteNamespaces(); err != nil {
+		return err
+	}
 
 	f, err := os.Open(w.articlesFile)
 	if err != nil {
@@ -409,113 +955,277 @@ func (w *Wiki) ProcessArticles(limit int) error {
 	}
 	defer f.Close()
 
-	// Process articles in batches
-	batchSize := 1000
-	tx, err := w.db.Begin()
+	run, err := w.beginImportRun()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
+	w.logger.Info("Starting import run", "run", run.id)
 
-	stmt, err := tx.Prepare(`
-		INSERT OR REPLACE INTO articles (id, title, namespace, content, redirect)
-		VALUES (?, ?, ?, ?, ?)
-	`)
+	batch, err := w.newArticleBatch(run, progress, total, start)
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return err
 	}
-	defer stmt.Close()
+	defer batch.abort()
 
-	// Use pbzip2 for parallel decompression
-	r, err := pbzip2.NewReader(f)
+	r, err := newStreamReader(f)
 	if err != nil {
-		return fmt.Errorf("failed to create bzip2 reader: %w", err)
+		return fmt.Errorf("failed to create stream reader: %w", err)
 	}
 	defer r.Close()
 
 	decoder := xml.NewDecoder(r)
-	count := 0
-	processed := 0
 
-	log.Printf("Processing articles from %s...", w.articlesFile)
+	w.logger.Info("Processing articles", "file", w.articlesFile)
 
 	for {
-		var page Page
-		err := decoder.Decode(&page)
+		token, err := decoder.Token()
 		if err != nil {
-			// EOF or other error - stop processing
-			if err == io.EOF {
-				break
+			// A truncated or malformed file ends the import with what was read
+			if err != io.EOF {
+				w.logger.Error("XML read error", "error", err)
 			}
-			// Log non-EOF errors but continue
-			log.Printf("XML decode error: %v", err)
+			break
+		}
+		// The <mediawiki> root and <siteinfo> header are skipped
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "page" {
 			continue
 		}
 
-		// Only process articles in the main namespace (0)
-		if page.NS != 0 {
+		var page Page
+		if err := decoder.DecodeElement(&page, &start); err != nil {
+			// Log page errors but continue
+			w.logger.Error("XML decode error", "error", err)
 			continue
 		}
 
-		// Check if this article is in our index
-		if !indexSet[int64(page.ID)] {
+		// Only process pages in the selected namespaces (see WithNamespaces)
+		if !w.processesNamespace(page.NS) {
 			continue
 		}
 
-		redirect := ""
-		if len(page.Redirect) > 0 {
-			redirect = page.Redirect[0].Title
+		// Check if this article is in our index
+		if indexSet != nil && !indexSet[int64(page.ID)] {
+			continue
 		}
 
-		// Truncate content if too large (to avoid memory issues)
-		content := page.Text
-		if len(content) > 10*1024*1024 { // 10MB max per article
-			content = content[:10*1024*1024]
+		if int64(page.ID) <= resumeAfter {
+			continue
 		}
 
-		_, err = stmt.Exec(page.ID, page.Title, page.NS, content, redirect)
-		if err != nil {
-			log.Printf("Error inserting article %d: %v", page.ID, err)
-			continue
+		if err := batch.add(&page); err != nil {
+			return err
 		}
 
-		count++
-		processed++
+		if limit > 0 && batch.processed >= limit {
+			break
+		}
+	}
 
-		if count >= batchSize {
-			if err := tx.Commit(); err != nil {
-				return fmt.Errorf("failed to commit transaction: %w", err)
-			}
-			log.Printf("Processed %d articles", processed)
+	return w.finishArticleBatch(batch)
+}
 
-			tx, err = w.db.Begin()
-			if err != nil {
-				return fmt.Errorf("failed to begin transaction: %w", err)
-			}
-			stmt, err = tx.Prepare(`
-				INSERT OR REPLACE INTO articles (id, title, namespace, content, redirect)
-				VALUES (?, ?, ?, ?, ?)
-			`)
-			if err != nil {
-				return fmt.Errorf("failed to prepare statement: %w", err)
-			}
-			count = 0
-		}
+// articleBatchSize is the number of articles committed per transaction
+const articleBatchSize = 1000
+
+// articleBatch writes processed articles to the database, committing every
+// articleBatchSize articles along with the resume point
+type articleBatch struct {
+	w         *Wiki
+	run       *importRun
+	tx        *sql.Tx
+	stmt      *sql.Stmt
+	count     int
+	processed int
+	skipped   int // unchanged articles of an incremental import
+	lastID    int64
+
+	progress chan<- ProgressEvent
+	total    int
+	start    time.Time
+}
 
-		if limit > 0 && processed >= limit {
-			break
+// newArticleBatch begins the first transaction of an import run
+func (w *Wiki) newArticleBatch(run *importRun, progress chan<- ProgressEvent, total int, start time.Time) (*articleBatch, error) {
+	b := &articleBatch{w: w, run: run, progress: progress, total: total, start: start}
+	if err := b.begin(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *articleBatch) begin() error {
+	tx, err := b.w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := b.run.prepare(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(insertArticleSQL)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	b.tx, b.stmt, b.count = tx, stmt, 0
+	return nil
+}
+
+// abort rolls back the current transaction of b when an import fails; it does
+// nothing once the transaction is committed
+func (b *articleBatch) abort() {
+	b.tx.Rollback()
+}
+
+// add stores a page along with its derived rows; errors specific to the page
+// are logged and the page skipped
+func (b *articleBatch) add(page *Page) error {
+	w := b.w
+	redirect := ""
+	if len(page.Redirect) > 0 {
+		redirect = page.Redirect[0].Title
+	}
+
+	if w.incremental && b.unchanged(page) {
+		b.skipped++
+		b.lastID = int64(page.ID)
+		return nil
+	}
+
+	// Truncate content if too large (to avoid memory issues)
+	content, truncated := truncateContent(page.Text, w.maxArticleBytes)
+	if truncated {
+		w.logger.Warn("Truncated article content", "id", page.ID, "title", page.Title, "bytes", len(page.Text), "max_bytes", w.maxArticleBytes)
+	}
+
+	if err := b.run.record(int64(page.ID), content); err != nil {
+		w.logger.Error("Error recording article for import run", "id", page.ID, "error", err)
+		return nil
+	}
+
+	stored, compressed, err := w.contentValues(content)
+	if err != nil {
+		w.logger.Error("Error compressing article", "id", page.ID, "error", err)
+		return nil
+	}
+
+	values := append([]interface{}{page.ID, page.Title, page.NS, stored, compressed, redirect}, derivedValues(content)...)
+	values = append(values, w.plainTextValue(content), page.RevisionID, page.Timestamp, page.Username, page.UserID, truncated)
+	if _, err := b.stmt.Exec(values...); err != nil {
+		w.logger.Error("Error inserting article", "id", page.ID, "error", err)
+		return nil
+	}
+
+	if err := w.storeDerivedRows(context.Background(), b.tx, int64(page.ID), content); err != nil {
+		w.logger.Error("Error storing links of article", "id", page.ID, "error", err)
+	}
+
+	b.count++
+	b.processed++
+	b.lastID = int64(page.ID)
+
+	if b.count >= articleBatchSize {
+		if err := saveProgress(b.tx, b.lastID); err != nil {
+			return err
+		}
+		if err := b.tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
 		}
+		w.invalidateAdjacencyList()
+		w.logger.Info("Processed articles", "count", b.processed)
+		sendProgress(b.progress, PhaseProcessArticles, b.processed, b.total, b.start)
+		return b.begin()
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
+// finishArticleBatch commits the last articles of b, closes its import run and
+// optimizes the full-text index
+func (w *Wiki) finishArticleBatch(b *articleBatch) error {
+	if b.lastID > 0 {
+		if err := saveProgress(b.tx, b.lastID); err != nil {
+			return err
+		}
+	}
+	if err := b.tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit final transaction: %w", err)
 	}
 
-	log.Printf("Done processing articles! Processed %d articles", processed)
+	if err := w.finishImportRun(b.run); err != nil {
+		return err
+	}
+	w.cache.purge()
+	w.invalidateAdjacencyList()
+
+	w.logger.Info("Done processing articles", "count", b.processed,
+		"run", b.run.id, "inserted", b.run.added, "updated", b.run.updated, "skipped", b.skipped)
+	if err := w.OptimizeFTS(); err != nil {
+		return err
+	}
+	sendProgress(b.progress, PhaseProcessArticles, b.processed, b.total, b.start)
 	return nil
 }
 
-// GetArticle retrieves an article by title
+// LookupOptions controls how GetArticleByTitle matches a title
+type LookupOptions struct {
+	// CaseInsensitive retries with a case-insensitive match when no title
+	// matches exactly
+	CaseInsensitive bool
+	// FollowRedirects resolves redirects to their target, up to
+	// MaxRedirectDepth hops
+	FollowRedirects  bool
+	MaxRedirectDepth int
+}
+
+// DefaultLookupOptions are the options used by GetArticle: case-insensitive,
+// following up to DefaultMaxRedirects redirects
+var DefaultLookupOptions = LookupOptions{
+	CaseInsensitive:  true,
+	FollowRedirects:  true,
+	MaxRedirectDepth: DefaultMaxRedirects,
+}
+
+// GetArticle retrieves an article by title with DefaultLookupOptions
 func (w *Wiki) GetArticle(title string) (*Article, error) {
+	return w.GetArticleContext(context.Background(), title)
+}
+
+// GetArticleContext is GetArticle aborting its queries when ctx is done
+func (w *Wiki) GetArticleContext(ctx context.Context, title string) (*Article, error) {
+	return w.GetArticleByTitleContext(ctx, title, DefaultLookupOptions)
+}
+
+// GetArticleFollowRedirects retrieves an article by title and follows up to
+// maxDepth redirects to their target; maxDepth 0 returns the redirect itself
+func (w *Wiki) GetArticleFollowRedirects(title string, maxDepth int) (*Article, error) {
+	return w.GetArticleByTitle(title, LookupOptions{
+		CaseInsensitive:  true,
+		FollowRedirects:  maxDepth > 0,
+		MaxRedirectDepth: maxDepth,
+	})
+}
+
+// GetArticleByTitle retrieves an article by exact title, then, if enabled, by
+// case-insensitive title, and resolves redirects when opts.FollowRedirects is
+// set. If a redirect target is missing, the redirect is returned.
+func (w *Wiki) GetArticleByTitle(title string, opts LookupOptions) (*Article, error) {
+	return w.GetArticleByTitleContext(context.Background(), title, opts)
+}
+
+// GetArticleByTitleContext is GetArticleByTitle aborting its queries when ctx
+// is done
+func (w *Wiki) GetArticleByTitleContext(ctx context.Context, title string, opts LookupOptions) (*Article, error) {
+	maxDepth := 0
+	if opts.FollowRedirects {
+		maxDepth = opts.MaxRedirectDepth
+	}
+
+	key := articleTitleKey(title, opts.CaseInsensitive, maxDepth)
+	if article, ok := w.cache.get(key); ok {
+		return article, nil
+	}
+
 	if err := w.Open(); err != nil {
 		return nil, err
 	}
@@ -523,105 +1233,481 @@ func (w *Wiki) GetArticle(title string) (*Article, error) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
+	article, err := w.getArticle(ctx, title, opts.CaseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []string
+	seen := map[string]bool{article.Title: true}
+	for article.Redirect != "" && maxDepth > 0 {
+		if len(chain) == maxDepth {
+			return nil, fmt.Errorf("%w: %s", ErrTooManyRedirects, strings.Join(append(chain, article.Title), " -> "))
+		}
+
+		// Redirects may point to a section: "United States#History"
+		target, _, _ := strings.Cut(article.Redirect, "#")
+		next, err := w.getArticle(ctx, target, opts.CaseInsensitive)
+		if errors.Is(err, ErrArticleNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, article.Title)
+		if seen[next.Title] {
+			return nil, fmt.Errorf("%w: %s", ErrRedirectLoop, strings.Join(append(chain, next.Title), " -> "))
+		}
+		seen[next.Title] = true
+		article = next
+	}
+
+	article.ResolvedFrom = chain
+	w.cache.add(key, article)
+	return article, nil
+}
+
+// getArticle looks up a single article by exact title, then by
+// case-insensitive title if caseInsensitive is set, without following
+// redirects; the caller must hold w.mu
+func (w *Wiki) getArticle(ctx context.Context, title string, caseInsensitive bool) (*Article, error) {
 	// Try exact match first
 	var article Article
-	err := w.db.QueryRow(`
-		SELECT id, title, namespace, content, redirect
+	err := w.db.QueryRowContext(ctx, `
+		SELECT `+articleColumns+`
 		FROM articles
 		WHERE title = ?
 		LIMIT 1
-	`, title).Scan(&article.ID, &article.Title, &article.Namespace, &article.Content, &article.Redirect)
+	`, title).Scan(article.scanFields()...)
 
 	if err == nil {
+		if isDisambiguation(article.Content) {
+			if report, err := w.ambiguity(ctx, article.Title); err == nil {
+				article.Ambiguity = report
+			}
+		}
 		return &article, nil
 	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to query article: %w", err)
+	}
+	if !caseInsensitive {
+		return nil, fmt.Errorf("%w: %s", ErrArticleNotFound, title)
+	}
 
 	// Try case-insensitive match
-	caser := cases.Title(language.AmericanEnglish)
-	titleCase := caser.String(strings.ToLower(title))
-	err = w.db.QueryRow(`
-		SELECT id, title, namespace, content, redirect
+	err = w.db.QueryRowContext(ctx, `
+		SELECT `+articleColumns+`
 		FROM articles
 		WHERE LOWER(title) = LOWER(?)
 		LIMIT 1
-	`, titleCase).Scan(&article.ID, &article.Title, &article.Namespace, &article.Content, &article.Redirect)
+	`, title).Scan(article.scanFields()...)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrArticleNotFound, title)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("article not found: %s", title)
+		return nil, fmt.Errorf("failed to query article: %w", err)
 	}
 
 	return &article, nil
 }
 
-// SearchTitles searches for article titles using FTS or LIKE queries
-func (w *Wiki) SearchTitles(query string, limit int) ([]string, error) {
-	if err := w.Open(); err != nil {
+// Title search queries, selecting the id, title and score of the hits. The
+// FTS queries match the MATCH expression of ParseSearchQuery; FTS5 breaks
+// ties of rank by rowid so that pages never overlap, and FTS4 has no ranking
+// and, like the LIKE fallback matching a substring of the title, orders hits
+// by title.
+const (
+	fts5SearchSQL = `
+		SELECT rowid, title, -rank
+		FROM articles_fts
+		WHERE articles_fts MATCH ?
+		ORDER BY rank, rowid`
+	fts4SearchSQL = `
+		SELECT docid, title, 0
+		FROM articles_fts
+		WHERE articles_fts MATCH ?
+		ORDER BY title`
+	likeSearchSQL = `
+		SELECT id, title, 0
+		FROM articles
+		WHERE title LIKE ?
+		ORDER BY title`
+
+	// The highlighted searches also return the highlighted title and a snippet
+	// of the content; the FTS4 and LIKE ones return the content, highlighted
+	// in Go
+	fts5HighlightSQL = `
+		SELECT rowid, title, -rank, highlight(articles_fts, 0, '<b>', '</b>'), snippet(articles_fts, 1, '<b>', '</b>', '…', 32)
+		FROM articles_fts
+		WHERE articles_fts MATCH ?
+		ORDER BY rank, rowid`
+	fts4HighlightSQL = `
+		SELECT a.id, a.title, 0, NULL, COALESCE(a.content, a.content_compressed)
+		FROM articles_fts
+		JOIN articles a ON a.id = articles_fts.docid
+		WHERE articles_fts MATCH ?
+		ORDER BY a.title`
+	likeHighlightSQL = `
+		SELECT id, title, 0, NULL, COALESCE(content, content_compressed)
+		FROM articles
+		WHERE title LIKE ?
+		ORDER BY title`
+)
+
+// titleSearchSQL returns the title search query of the full-text index in
+// use, the highlighted one when highlight is set, or "" without full-text
+// search
+func (w *Wiki) titleSearchSQL(highlight bool) string {
+	switch {
+	case w.ftsVersion == "fts5" && highlight:
+		return fts5HighlightSQL
+	case w.ftsVersion == "fts5":
+		return fts5SearchSQL
+	case w.ftsVersion == "fts4" && highlight:
+		return fts4HighlightSQL
+	case w.ftsVersion == "fts4":
+		return fts4SearchSQL
+	}
+	return ""
+}
+
+// searchRows runs ftsSQL with the MATCH expression of query followed by args,
+// or likeSQL with the %query% pattern instead when ftsSQL is empty, when the
+// query has no letter or digit to match, or when the FTS query fails. The
+// fallback only applies to this search.
+func (w *Wiki) searchRows(ctx context.Context, query, ftsSQL, likeSQL string, args ...interface{}) (*sql.Rows, error) {
+	if match := ParseSearchQuery(query); ftsSQL != "" && match != "" {
+		rows, err := w.db.QueryContext(ctx, ftsSQL, append([]interface{}{match}, args...)...)
+		if err == nil {
+			return rows, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		w.logger.Warn("FTS query failed, falling back to LIKE", "query", query, "error", err)
+	}
+	return w.db.QueryContext(ctx, likeSQL, append([]interface{}{"%" + query + "%"}, args...)...)
+}
+
+// SearchTitles searches for article titles using FTS or LIKE queries, skipping
+// the first offset hits
+func (w *Wiki) SearchTitles(query string, limit, offset int) ([]string, error) {
+	return w.SearchTitlesContext(context.Background(), query, limit, offset)
+}
+
+// SearchTitlesContext is SearchTitles aborting its query when ctx is done
+func (w *Wiki) SearchTitlesContext(ctx context.Context, query string, limit, offset int) ([]string, error) {
+	results, err := w.SearchTitleResults(ctx, query, limit, offset)
+	if err != nil {
 		return nil, err
 	}
 
+	var titles []string
+	for _, result := range results {
+		titles = append(titles, result.Title)
+	}
+
+	return titles, nil
+}
+
+// SearchTitleResults performs the same search as SearchTitles but also returns
+// the article ID and FTS score of every hit
+func (w *Wiki) SearchTitleResults(ctx context.Context, query string, limit, offset int) ([]SearchResult, error) {
+	var results []SearchResult
+	err := w.StreamSearchResults(ctx, query, limit, offset, func(result SearchResult) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// CountSearchResults returns the total number of hits of a title search, for
+// paginating with the offset of SearchTitles
+func (w *Wiki) CountSearchResults(ctx context.Context, query string) (int, error) {
+	return w.CountSearchResultsWithOptions(ctx, query, SearchOptions{})
+}
+
+// CountSearchResultsWithOptions counts the results of
+// SearchArticlesWithOptions
+func (w *Wiki) CountSearchResultsWithOptions(ctx context.Context, query string, opts SearchOptions) (int, error) {
+	if err := w.Open(); err != nil {
+		return 0, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	// Counting the search query itself keeps the count consistent with the
+	// results, including when the FTS query fails and search falls back to LIKE
+	filter, filterArgs := opts.filter()
+	countSQL := func(searchSQL string) string {
+		if filter == "" {
+			return "SELECT COUNT(*) FROM (" + searchSQL + ")"
+		}
+		return `
+			WITH hits(id, title, score) AS (` + searchSQL + `)
+			SELECT COUNT(*)
+			FROM hits
+			JOIN articles a ON a.id = hits.id
+			WHERE ` + filter
+	}
+
+	ftsSQL := w.titleSearchSQL(false)
+	if ftsSQL != "" {
+		ftsSQL = countSQL(ftsSQL)
+	}
+	rows, err := w.searchRows(ctx, query, ftsSQL, countSQL(likeSearchSQL), filterArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("search count failed: %w", err)
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("search count failed: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("search count failed: %w", err)
+	}
+	return count, nil
+}
+
+// StreamSearchResults runs the title search and calls fn for each hit as it is
+// read from SQLite, without buffering the result set. Iteration stops at the
+// first error returned by fn.
+func (w *Wiki) StreamSearchResults(ctx context.Context, query string, limit, offset int, fn func(SearchResult) error) error {
+	defer observeSince(searchDuration, time.Now())
+
+	if err := w.Open(); err != nil {
+		return err
+	}
+
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
 	if limit <= 0 {
 		limit = 20
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	var rows *sql.Rows
-	var err error
-
-	// Use FTS if available, otherwise fall back to LIKE
-	if w.ftsVersion == "fts5" || w.ftsVersion == "fts4" {
-		// Build FTS query - escape special characters and add prefix matching
-		// FTS special characters: ", ', \
-		escapedQuery := strings.ReplaceAll(query, `"`, `""`)
-		escapedQuery = strings.ReplaceAll(escapedQuery, `'`, `''`)
-		// For prefix matching, add * to the end of the query
-		ftsQuery := escapedQuery + "*"
-
-		rows, err = w.db.Query(`
-			SELECT DISTINCT title
-			FROM articles_fts
-			WHERE articles_fts MATCH ?
-			ORDER BY rank
-			LIMIT ?
-		`, ftsQuery, limit)
+	ftsSQL := w.titleSearchSQL(false)
+	if ftsSQL != "" {
+		ftsSQL += " LIMIT ? OFFSET ?"
+	}
+	rows, err := w.searchRows(ctx, query, ftsSQL, likeSearchSQL+" LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	defer rows.Close()
 
-		if err != nil {
-			// FTS query failed, fall back to LIKE
-			log.Printf("FTS query failed, falling back to LIKE: %v", err)
-			w.ftsVersion = "none"
+	for rows.Next() {
+		var result SearchResult
+		if err := rows.Scan(&result.ID, &result.Title, &result.Score); err != nil {
+			continue
+		}
+		if err := fn(result); err != nil {
+			return err
 		}
 	}
 
-	// If FTS is not available or failed, use LIKE query
-	if w.ftsVersion == "none" || rows == nil {
-		rows, err = w.db.Query(`
-			SELECT DISTINCT title
-			FROM articles
-			WHERE title LIKE ?
-			ORDER BY title
-			LIMIT ?
-		`, "%"+query+"%", limit)
-		if err != nil {
-			return nil, fmt.Errorf("search failed: %w", err)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	return ctx.Err()
+}
+
+// SearchOptions controls the results of SearchArticlesWithOptions
+type SearchOptions struct {
+	// WithContent includes the article content in the results
+	WithContent bool
+
+	// MinWords and MaxWords keep the articles whose word count is within
+	// bounds; 0 leaves the bound open
+	MinWords int
+	MaxWords int
+
+	// Category keeps the articles of a category, given with or without its
+	// "Category:" prefix
+	Category string
+
+	// Highlight sets the HighlightedTitle and Snippet of the results, with the
+	// FTS5 highlight() and snippet() functions when available
+	Highlight bool
+
+	// ExcludeRedirects keeps the content articles, RedirectsOnly the redirects,
+	// e.g. to find the aliases of a term
+	ExcludeRedirects bool
+	RedirectsOnly    bool
+}
+
+// filter returns the SQL condition on the articles table "a" selecting the
+// articles of opts, and its arguments
+func (opts SearchOptions) filter() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if opts.Category != "" {
+		conditions = append(conditions, "a.id IN (SELECT article_id FROM categories WHERE category_name = ?)")
+		args = append(args, categoryName(opts.Category))
+	}
+	if opts.MinWords > 0 {
+		conditions = append(conditions, "a.word_count >= ?")
+		args = append(args, opts.MinWords)
+	}
+	if opts.MaxWords > 0 {
+		conditions = append(conditions, "a.word_count <= ?")
+		args = append(args, opts.MaxWords)
+	}
+	if opts.ExcludeRedirects {
+		conditions = append(conditions, "(a.redirect IS NULL OR a.redirect = '')")
+	}
+	if opts.RedirectsOnly {
+		conditions = append(conditions, "a.redirect IS NOT NULL AND a.redirect != ''")
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// SearchArticles runs the title search and returns the id, title, namespace,
+// redirect and word count of each hit, without content
+func (w *Wiki) SearchArticles(query string, limit, offset int) ([]*Article, error) {
+	return w.SearchArticlesContext(context.Background(), query, limit, offset)
+}
+
+// SearchArticlesContext is SearchArticles aborting its query when ctx is done
+func (w *Wiki) SearchArticlesContext(ctx context.Context, query string, limit, offset int) ([]*Article, error) {
+	return w.SearchArticlesWithOptions(ctx, query, limit, offset, SearchOptions{})
+}
+
+// SearchInCategory is SearchArticles restricted to the articles of a
+// category; an empty category searches all articles
+func (w *Wiki) SearchInCategory(query, category string, limit, offset int) ([]*Article, error) {
+	return w.SearchArticlesWithOptions(context.Background(), query, limit, offset, SearchOptions{Category: category})
+}
+
+// SearchArticlesWithContent is SearchArticles including the article content
+func (w *Wiki) SearchArticlesWithContent(query string, limit, offset int) ([]*Article, error) {
+	return w.SearchArticlesWithOptions(context.Background(), query, limit, offset, SearchOptions{WithContent: true})
+}
+
+// SearchArticlesWithOptions joins the title search hits back to the articles
+// table in a single query, keeping the order of the search, and aborts it when
+// ctx is done
+func (w *Wiki) SearchArticlesWithOptions(ctx context.Context, query string, limit, offset int, opts SearchOptions) ([]*Article, error) {
+	defer observeSince(searchDuration, time.Now())
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	content := "''"
+	if opts.WithContent {
+		content = "COALESCE(a.content, a.content_compressed)"
+	}
+	// Without a filter, the page of hits is picked before the join; with one,
+	// the filtered join is paginated
+	filter, filterArgs := opts.filter()
+	ftsSQL, likeSQL := w.titleSearchSQL(opts.Highlight), likeSearchSQL
+	hitColumns, highlightColumns := "id, title, score", ""
+	if opts.Highlight {
+		likeSQL = likeHighlightSQL
+		hitColumns += ", highlighted_title, snippet"
+		highlightColumns = ", hits.highlighted_title, hits.snippet"
+	}
+	joinSQL := func(searchSQL string) string {
+		if filter == "" {
+			return `
+				WITH hits(` + hitColumns + `) AS (` + searchSQL + ` LIMIT ? OFFSET ?)
+				SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0), ` + content + highlightColumns + `
+				FROM hits
+				JOIN articles a ON a.id = hits.id
+				ORDER BY hits.score DESC, hits.title, hits.id`
 		}
+		return `
+			WITH hits(` + hitColumns + `) AS (` + searchSQL + `)
+			SELECT a.id, a.title, a.namespace, a.redirect, COALESCE(a.word_count, 0), ` + content + highlightColumns + `
+			FROM hits
+			JOIN articles a ON a.id = hits.id
+			WHERE ` + filter + `
+			ORDER BY hits.score DESC, hits.title, hits.id
+			LIMIT ? OFFSET ?`
+	}
+	if ftsSQL != "" {
+		ftsSQL = joinSQL(ftsSQL)
+	}
+	rows, err := w.searchRows(ctx, query, ftsSQL, joinSQL(likeSQL), append(filterArgs, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
 	}
 	defer rows.Close()
 
-	var titles []string
+	var terms []string
+	if opts.Highlight {
+		terms = searchTerms(query)
+	}
+
+	articles := []*Article{}
 	for rows.Next() {
-		var title string
-		if err := rows.Scan(&title); err != nil {
+		var article Article
+		dest := []interface{}{&article.ID, &article.Title, &article.Namespace, &article.Redirect, &article.WordCount, (*contentText)(&article.Content)}
+		var highlightedTitle sql.NullString
+		var snippet contentText
+		if opts.Highlight {
+			dest = append(dest, &highlightedTitle, &snippet)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			continue
 		}
-		titles = append(titles, title)
+		if opts.Highlight {
+			if highlightedTitle.Valid {
+				article.HighlightedTitle = highlightedTitle.String
+				article.Snippet = string(snippet)
+			} else {
+				// The LIKE search returns the content instead of a snippet
+				article.HighlightedTitle = highlightTerms(article.Title, terms)
+				if len(terms) > 0 {
+					article.Snippet = highlightTerms(excerptAround(string(snippet), terms[0]), terms)
+				}
+			}
+		}
+		articles = append(articles, &article)
 	}
 
-	return titles, nil
+	return articles, rows.Err()
 }
 
 // GetArticleByID retrieves an article by ID
 func (w *Wiki) GetArticleByID(id int64) (*Article, error) {
+	return w.GetArticleByIDContext(context.Background(), id)
+}
+
+// GetArticleByIDContext is GetArticleByID aborting its query when ctx is done
+func (w *Wiki) GetArticleByIDContext(ctx context.Context, id int64) (*Article, error) {
+	defer observeSince(articleFetchDuration, time.Now())
+
+	key := articleIDKey(id)
+	if article, ok := w.cache.get(key); ok {
+		return article, nil
+	}
+
 	if err := w.Open(); err != nil {
 		return nil, err
 	}
@@ -630,19 +1716,82 @@ func (w *Wiki) GetArticleByID(id int64) (*Article, error) {
 	defer w.mu.RUnlock()
 
 	var article Article
-	err := w.db.QueryRow(`
-		SELECT id, title, namespace, content, redirect
+	err := w.db.QueryRowContext(ctx, `
+		SELECT `+articleColumns+`
 		FROM articles
 		WHERE id = ?
-	`, id).Scan(&article.ID, &article.Title, &article.Namespace, &article.Content, &article.Redirect)
-
+	`, id).Scan(article.scanFields()...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrArticleNotFound, id)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("article not found: %d", id)
+		return nil, fmt.Errorf("failed to query article: %w", err)
 	}
 
+	w.cache.add(key, &article)
 	return &article, nil
 }
 
+// MaxBatchIDs is the maximum number of IDs accepted by GetArticlesByIDs
+const MaxBatchIDs = 500
+
+// ErrTooManyIDs is returned when a batch lookup exceeds MaxBatchIDs
+var ErrTooManyIDs = fmt.Errorf("too many IDs (max %d)", MaxBatchIDs)
+
+// GetArticlesByIDs retrieves several articles with a single query, in the
+// order of ids. IDs that are not in the database are omitted.
+func (w *Wiki) GetArticlesByIDs(ids []int64) ([]*Article, error) {
+	if len(ids) > MaxBatchIDs {
+		return nil, ErrTooManyIDs
+	}
+	if len(ids) == 0 {
+		return []*Article{}, nil
+	}
+
+	if err := w.Open(); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := w.db.Query(`
+		SELECT `+articleColumns+`
+		FROM articles
+		WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query articles: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]*Article, len(ids))
+	for rows.Next() {
+		var article Article
+		if err := rows.Scan(article.scanFields()...); err != nil {
+			continue
+		}
+		byID[article.ID] = &article
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	articles := make([]*Article, 0, len(byID))
+	for _, id := range ids {
+		if article, ok := byID[id]; ok {
+			articles = append(articles, article)
+			delete(byID, id)
+		}
+	}
+	return articles, nil
+}
+
 // Page represents a Wikipedia page in XML format
 type Page struct {
 	XMLName    xml.Name   `xml:"page"`
@@ -0,0 +1,424 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// importFixture loads the index of the fixture dump and processes its
+// articles
+func importFixture(tb testing.TB, w *Wiki) {
+	tb.Helper()
+
+	if err := w.LoadIndex(-1); err != nil {
+		tb.Fatalf("LoadIndex: %v", err)
+	}
+	if err := w.ProcessArticles(-1); err != nil {
+		tb.Fatalf("ProcessArticles: %v", err)
+	}
+}
+
+func TestLoadIndex(t *testing.T) {
+	w := newFixtureWiki(t)
+	if err := w.LoadIndex(-1); err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+
+	var entries, streams int
+	if err := w.db.QueryRow("SELECT COUNT(*), COUNT(DISTINCT seek) FROM index_entries").Scan(&entries, &streams); err != nil {
+		t.Fatal(err)
+	}
+	if entries != 100 {
+		t.Errorf("got %d index entries, want 100", entries)
+	}
+	if streams != 10 {
+		t.Errorf("got %d distinct seek offsets, want 10", streams)
+	}
+
+	result, err := w.CheckIndex()
+	if err != nil {
+		t.Fatalf("CheckIndex: %v", err)
+	}
+	if len(result.InvalidSeeks) > 0 {
+		t.Errorf("invalid seek offsets: %v", result.InvalidSeeks)
+	}
+}
+
+func TestProcessArticles(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []int
+		want       map[int]int
+	}{
+		{"main namespace only", nil, map[int]int{0: 90}},
+		{"templates and categories", []int{0, 10, 14}, map[int]int{0: 90, 10: 5, 14: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newFixtureWiki(t).WithNamespaces(tt.namespaces...)
+			importFixture(t, w)
+
+			got := make(map[int]int)
+			rows, err := w.db.Query("SELECT namespace, COUNT(*) FROM articles GROUP BY namespace")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var ns, count int
+				if err := rows.Scan(&ns, &count); err != nil {
+					t.Fatal(err)
+				}
+				got[ns] = count
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got articles per namespace %v, want %v", got, tt.want)
+			}
+			for ns, count := range tt.want {
+				if got[ns] != count {
+					t.Errorf("namespace %d: got %d articles, want %d", ns, got[ns], count)
+				}
+			}
+
+			article, err := w.GetArticleByID(18)
+			if err != nil {
+				t.Fatalf("GetArticleByID(18): %v", err)
+			}
+			if article.Title != "Alias 18" || article.Redirect != "Article 17" {
+				t.Errorf("got article %q redirecting to %q, want Alias 18 redirecting to Article 17", article.Title, article.Redirect)
+			}
+		})
+	}
+}
+
+func TestProcessArticlesWriteError(t *testing.T) {
+	w := newTestWiki(t, testPage{ID: 1, Title: "Physics", Text: "Physics."})
+
+	// Saving the resume point after the first batch fails
+	if _, err := w.db.Exec("DROP TABLE progress"); err != nil {
+		t.Fatal(err)
+	}
+	var pages []testPage
+	for id := 2; id <= articleBatchSize+1; id++ {
+		pages = append(pages, testPage{ID: id, Title: fmt.Sprintf("Article %d", id), Text: "Text."})
+	}
+	writeTestDump(t, w.articlesFile, pages)
+	if err := w.ProcessArticlesSequential(-1); err == nil {
+		t.Fatal("ProcessArticlesSequential without a progress table succeeded, want an error")
+	}
+
+	// The batch is rolled back rather than left open on its connection
+	if inUse := w.db.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections in use after the failed import, want 0", inUse)
+	}
+	var count int
+	if err := w.db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&count); err != nil || count != 1 {
+		t.Errorf("%d articles after the failed import (%v), want 1", count, err)
+	}
+}
+
+func TestSearchTitles(t *testing.T) {
+	w := newFixtureWiki(t)
+	importFixture(t, w)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		// The redirects Alias 18 to Alias 90
+		{"Alias", 5},
+		{"alia", 5},
+		{"Alias 54", 1},
+		{"Alias NOT 54", 4},
+		// Articles 40 and 41 link to Article 42, and the full-text index
+		// covers the content
+		{`"Article 42"`, 3},
+		{"Article OR Alias", 90},
+		{"nonexistent", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			titles, err := w.SearchTitles(tt.query, 100, 0)
+			if err != nil {
+				t.Fatalf("SearchTitles(%q): %v", tt.query, err)
+			}
+			if len(titles) != tt.want {
+				t.Errorf("SearchTitles(%q) returned %d titles %v, want %d", tt.query, len(titles), titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchTitlesPagination(t *testing.T) {
+	w := newFixtureWiki(t)
+	importFixture(t, w)
+
+	const query = "Article OR Alias"
+	all, err := w.SearchTitles(query, 100, 0)
+	if err != nil {
+		t.Fatalf("SearchTitles: %v", err)
+	}
+	total, err := w.CountSearchResults(context.Background(), query)
+	if err != nil {
+		t.Fatalf("CountSearchResults: %v", err)
+	}
+	if total != len(all) {
+		t.Errorf("CountSearchResults = %d, want the %d results", total, len(all))
+	}
+
+	// Pages of 25 end with a partial page and then an empty one
+	var paged []string
+	seen := make(map[string]bool)
+	for offset := 0; ; offset += 25 {
+		page, err := w.SearchTitles(query, 25, offset)
+		if err != nil {
+			t.Fatalf("SearchTitles at offset %d: %v", offset, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, title := range page {
+			if seen[title] {
+				t.Errorf("%q is on more than one page", title)
+			}
+			seen[title] = true
+		}
+		paged = append(paged, page...)
+	}
+	if !slices.Equal(paged, all) {
+		t.Errorf("the pages hold %d titles, want the %d results in order", len(paged), len(all))
+	}
+}
+
+func TestGetArticleByIDNotFound(t *testing.T) {
+	w := newFixtureWiki(t)
+	importFixture(t, w)
+
+	if _, err := w.GetArticleByID(1000); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("GetArticleByID(1000): got %v, want ErrArticleNotFound", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.GetArticleByIDContext(ctx, 3); err == nil || errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("GetArticleByIDContext with a cancelled context: got %v, want a query error", err)
+	}
+}
+
+func TestGetArticleNotFound(t *testing.T) {
+	w := newFixtureWiki(t)
+	importFixture(t, w)
+
+	for _, opts := range []LookupOptions{{}, {CaseInsensitive: true}} {
+		if _, err := w.GetArticleByTitle("Article 1000", opts); !errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("GetArticleByTitle(Article 1000, %+v): got %v, want ErrArticleNotFound", opts, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := w.GetArticleByTitleContext(ctx, "Article 3", opts); err == nil || errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("GetArticleByTitleContext(%+v) with a cancelled context: got %v, want a query error", opts, err)
+		}
+	}
+}
+
+func TestGetArticlesByIDs(t *testing.T) {
+	w := newFixtureWiki(t)
+	importFixture(t, w)
+
+	tooMany := make([]int64, MaxBatchIDs+1)
+	for i := range tooMany {
+		tooMany[i] = int64(i + 1)
+	}
+	tests := []struct {
+		name string
+		ids  []int64
+		want []int64
+		err  error
+	}{
+		{"empty", nil, []int64{}, nil},
+		{"in the order of ids", []int64{12, 3, 7}, []int64{12, 3, 7}, nil},
+		{"partial hits", []int64{5, 1000, 6, 96}, []int64{5, 6}, nil},
+		{"duplicates", []int64{4, 4}, []int64{4}, nil},
+		{"over the limit", tooMany, nil, ErrTooManyIDs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articles, err := w.GetArticlesByIDs(tt.ids)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			got := []int64{}
+			for _, article := range articles {
+				got = append(got, article.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got articles %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentReads(t *testing.T) {
+	w := newFixtureWiki(t).WithConnPool(10, 5, time.Minute)
+	importFixture(t, w)
+
+	const readers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id := int64((r*50+i)%85 + 1)
+				if _, err := w.GetArticleByID(id); err != nil {
+					errs <- fmt.Errorf("GetArticleByID(%d): %w", id, err)
+					return
+				}
+				if _, err := w.SearchTitles(fmt.Sprintf("Article %d", id), 10, 0); err != nil {
+					errs <- fmt.Errorf("SearchTitles: %w", err)
+					return
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if strings.Contains(err.Error(), "database is locked") {
+			t.Errorf("concurrent read failed with a locked database: %v", err)
+		} else {
+			t.Error(err)
+		}
+	}
+	if stats := w.db.Stats(); stats.MaxOpenConnections != 10 {
+		t.Errorf("got at most %d open connections, want 10", stats.MaxOpenConnections)
+	}
+}
+
+// benchArticles is the number of articles of the benchmark database
+const benchArticles = 10000
+
+var (
+	benchOnce sync.Once
+	benchDir  string
+	benchWiki *Wiki
+	benchErr  error
+)
+
+// benchWords are the words of the benchmark articles, so that each one is in
+// a tenth of the titles and a fifth of the contents
+var benchWords = []string{"River", "Mountain", "History", "Music", "Science", "City", "Football", "Novel", "Battle", "Island"}
+
+// sharedBenchWiki returns a Wiki on a database of benchArticles articles,
+// imported once for every benchmark and removed by TestMain
+func sharedBenchWiki(b *testing.B) *Wiki {
+	b.Helper()
+
+	benchOnce.Do(func() {
+		if benchDir, benchErr = os.MkdirTemp("", "wikipedia-bench-"); benchErr != nil {
+			return
+		}
+		pages := make([]testPage, benchArticles)
+		for i := range pages {
+			id := i + 1
+			pages[i] = testPage{
+				ID:    id,
+				Title: fmt.Sprintf("%s %d", benchWords[id%len(benchWords)], id),
+				Text: fmt.Sprintf("This article about the %s of %s links to [[%s %d]].",
+					benchWords[id%len(benchWords)], benchWords[(id/2)%len(benchWords)],
+					benchWords[(id+1)%len(benchWords)], id%benchArticles+1),
+			}
+		}
+		writeTestDump(b, benchDir+"/articles.xml", pages)
+		benchWiki = NewWiki(benchDir, "index.txt", "articles.xml")
+		benchWiki.SetLogger(testLogger)
+		benchErr = benchWiki.ProcessArticlesSequential(-1)
+	})
+	if benchErr != nil {
+		b.Fatalf("failed to create the benchmark database: %v", benchErr)
+	}
+	return benchWiki
+}
+
+// benchmarkSearchTitles searches the shared database with the full-text index
+// ftsVersion, skipping the benchmark when the build uses another one
+func benchmarkSearchTitles(b *testing.B, ftsVersion string) {
+	w := sharedBenchWiki(b)
+	if ftsVersion == "none" {
+		// Hiding the index makes searches use LIKE queries
+		prev := w.ftsVersion
+		w.ftsVersion = "none"
+		defer func() { w.ftsVersion = prev }()
+	} else if w.ftsVersion != ftsVersion {
+		b.Skipf("the database uses %s, see the sqlite_fts5 build tag", w.ftsVersion)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		titles, err := w.SearchTitles(benchWords[i%len(benchWords)], 20, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(titles) != 20 {
+			b.Fatalf("got %d titles, want 20", len(titles))
+		}
+	}
+}
+
+// Typical numbers on a single-core x86 server: FTS5 and FTS4 searches take about
+// 3ms since they rank the 2000 matches of a word, LIKE searches 0.3 to 0.5ms
+// since they stop at the 20th matching title, and reading an article by ID or
+// title takes about 30µs. Only one of the FTS benchmarks runs in a build:
+//
+//	go test ./wikipedia -run ^$ -bench . -benchmem
+//	go test ./wikipedia -tags sqlite_fts5 -run ^$ -bench . -benchmem
+
+func BenchmarkSearchTitlesFTS5(b *testing.B) { benchmarkSearchTitles(b, "fts5") }
+
+func BenchmarkSearchTitlesFTS4(b *testing.B) { benchmarkSearchTitles(b, "fts4") }
+
+func BenchmarkSearchTitlesLIKE(b *testing.B) { benchmarkSearchTitles(b, "none") }
+
+func BenchmarkGetArticleByID(b *testing.B) {
+	w := sharedBenchWiki(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := w.GetArticleByID(int64(i%benchArticles + 1)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetArticle(b *testing.B) {
+	w := sharedBenchWiki(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := i%benchArticles + 1
+		if _, err := w.GetArticle(fmt.Sprintf("%s %d", benchWords[id%len(benchWords)], id)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}